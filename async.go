@@ -0,0 +1,88 @@
+package fscache
+
+import (
+	"runtime"
+	"time"
+)
+
+type (
+	// Future object holds the result of an asynchronous operation
+	Future struct {
+		done  chan struct{}
+		value interface{}
+		err   error
+	}
+
+	// asyncPool runs the asynchronous operations of a Memdis
+	asyncPool struct {
+		jobs chan func()
+	}
+)
+
+// WithAsyncWorkers sets the number of workers running the operations of SetAsync() and GetAsync(),
+// runtime.NumCPU() by default
+func WithAsyncWorkers(workers int) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.asyncWorkers = workers
+	}
+}
+
+// SetAsync() adds a new data into the in-memmory storage like Set(), from a worker of the pool.
+// The returned Future can be ignored to fire and forget the write, or awaited to get its error.
+func (md *Memdis) SetAsync(key string, value interface{}, duration ...time.Duration) *Future {
+	return md.async(func() (interface{}, error) {
+		return nil, md.Set(key, value, duration...)
+	})
+}
+
+// GetAsync() retrieves a data from the in-memmory storage like Get(), from a worker of the pool
+func (md *Memdis) GetAsync(key string) *Future {
+	return md.async(func() (interface{}, error) {
+		return md.Get(key)
+	})
+}
+
+// Wait() waits for the operation to complete and returns its result
+func (f *Future) Wait() (_ interface{}, err error) {
+	defer recoverPanic(&err)
+
+	<-f.done
+	return f.value, f.err
+}
+
+// Done() returns a channel closed once the operation completed
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// async queues op to the worker pool, which is started on first use.
+// Callers block while the queue is full, so writes are never dropped.
+func (md *Memdis) async(op func() (interface{}, error)) *Future {
+	md.state().asyncOnce.Do(func() {
+		workers := md.asyncWorkers
+		if workers <= 0 {
+			workers = runtime.NumCPU()
+		}
+
+		md.asyncPool = &asyncPool{
+			jobs: make(chan func(), workers*64),
+		}
+		for i := 0; i < workers; i++ {
+			go func() {
+				for job := range md.asyncPool.jobs {
+					job()
+				}
+			}()
+		}
+	})
+
+	f := &Future{
+		done: make(chan struct{}),
+	}
+	md.asyncPool.jobs <- func() {
+		f.value, f.err = op()
+		close(f.done)
+	}
+
+	return f
+}
@@ -0,0 +1,31 @@
+package fscache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetAsyncGetAsync(t *testing.T) {
+	ch := Cache{}
+	WithAsyncWorkers(2)(&ch)
+
+	futures := make([]*Future, 0, 10)
+	for i := 0; i < 10; i++ {
+		futures = append(futures, ch.Memdis().SetAsync(fmt.Sprintf("async%d", i), i))
+	}
+	for _, future := range futures {
+		_, err := future.Wait()
+		assert.NoError(t, err)
+	}
+
+	_, err := ch.Memdis().SetAsync("async1", "again").Wait()
+	assert.Equal(t, errKeyExists, err)
+
+	future := ch.Memdis().GetAsync("async3")
+	<-future.Done()
+	value, err := future.Wait()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, value)
+}
@@ -0,0 +1,70 @@
+package fscache
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+type (
+	// ItemError object is the failure of an item of a bulk operation
+	ItemError struct {
+		// Index is the position of the item in the bulk operation, -1 when the item is identified by its Key
+		Index int
+		// Key is the key of the item, empty when the item is identified by its Index
+		Key string
+		Err error
+	}
+
+	// BulkError object is returned by the bulk operations, SetMany(), DelMany() and Insert().Many(),
+	// with the failure of each item instead of the first one only. errors.Is and errors.As match the
+	// errors of all the items.
+	BulkError struct {
+		Items []*ItemError
+	}
+)
+
+// Error returns the error of the item along with the item it failed for
+func (e *ItemError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("%q: %v", e.Key, e.Err)
+	}
+
+	return fmt.Sprintf("item %d: %v", e.Index, e.Err)
+}
+
+// Unwrap returns the error of the item
+func (e *ItemError) Unwrap() error {
+	return e.Err
+}
+
+// Error returns the errors of the items, one per line
+func (e *BulkError) Error() string {
+	return errors.Join(e.Unwrap()...).Error()
+}
+
+// Unwrap returns the errors of the items
+func (e *BulkError) Unwrap() []error {
+	errs := make([]error, len(e.Items))
+	for i, item := range e.Items {
+		errs[i] = item
+	}
+
+	return errs
+}
+
+// bulkError returns a BulkError with the failed items ordered by index then key, nil if none failed
+func bulkError(items []*ItemError) error {
+	if len(items) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].Index != items[j].Index {
+			return items[i].Index < items[j].Index
+		}
+		return items[i].Key < items[j].Key
+	})
+
+	return &BulkError{Items: items}
+}
@@ -0,0 +1,59 @@
+package fscache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelMany(t *testing.T) {
+	ch := Cache{}
+	assert.NoError(t, ch.Memdis().Set("delmany1", 1))
+	assert.NoError(t, ch.Memdis().Set("delmany2", 2))
+
+	err := ch.Memdis().DelMany("delmany1", "missing1", "delmany2", "missing2")
+
+	var bulkErr *BulkError
+	assert.ErrorAs(t, err, &bulkErr)
+	assert.Len(t, bulkErr.Items, 2)
+	assert.Equal(t, "missing1", bulkErr.Items[0].Key)
+	assert.Equal(t, "missing2", bulkErr.Items[1].Key)
+	assert.ErrorIs(t, err, errKeyNotFound)
+
+	// the keys found were deleted anyway
+	assert.Equal(t, 0, ch.Memdis().Size())
+}
+
+func TestSetManyDuplicateErrors(t *testing.T) {
+	ch := Cache{}
+	WithDuplicatePolicy(DuplicateError)(&ch)
+	assert.NoError(t, ch.Memdis().Set("existing", "value"))
+
+	_, err := ch.Memdis().SetManyWithReport([]map[string]MemdisData{
+		{"dup": {Value: 1}, "existing": {Value: 2}},
+		{"dup": {Value: 3}},
+	})
+
+	var bulkErr *BulkError
+	assert.ErrorAs(t, err, &bulkErr)
+	assert.Len(t, bulkErr.Items, 2)
+	assert.Equal(t, "dup", bulkErr.Items[0].Key)
+	assert.Equal(t, "existing", bulkErr.Items[1].Key)
+}
+
+func Test_InsertManyBulkError(t *testing.T) {
+	ch := Cache{}
+
+	saved, err := ch.Memgodb().Collection("bulks").Insert(nil).Many([]interface{}{
+		map[string]interface{}{"name": "john"},
+		42,
+		map[string]interface{}{"name": "jane"},
+	})
+
+	var bulkErr *BulkError
+	assert.ErrorAs(t, err, &bulkErr)
+	assert.Len(t, bulkErr.Items, 1)
+	assert.Equal(t, 1, bulkErr.Items[0].Index)
+	// the valid records were inserted anyway
+	assert.Len(t, saved, 2)
+}
@@ -0,0 +1,139 @@
+package fscache
+
+import "time"
+
+// BulkLoader object sets the datas of BulkLoad() into a new storage
+type BulkLoader struct {
+	md      *Memdis
+	data    map[string]MemdisData
+	digests map[string]string
+	// objects is the number of objects of the new storage, each key set being an object of its own
+	objects uint64
+}
+
+// BulkLoad() replaces all the datas of the in-memmory storage with the ones set by fn.
+// fn runs on a single goroutine without taking any lock, and the new storage is built and swapped in atomically
+// once it returns, so readers see either all the old datas or all the new ones. Nothing is swapped if fn fails.
+// The time series, the geo sets and the mapped snapshot are left as they are. No KeyEvent is sent for the datas
+// replaced or loaded, and they are not counted as deleted.
+func (md *Memdis) BulkLoad(fn func(bl *BulkLoader) error) (err error) {
+	defer recoverPanic(&err)
+
+	bl := &BulkLoader{
+		md:      md,
+		data:    make(map[string]MemdisData),
+		digests: make(map[string]string),
+	}
+
+	if err := fn(bl); err != nil {
+		return err
+	}
+
+	md.state().mu.RLock()
+	interning, internMinSize, maxMemory := md.interned != nil, md.internMinSize, md.maxMemory
+	md.state().mu.RUnlock()
+
+	// the new storage is built without holding the lock, its datas ordered as if they were set from scratch
+	var (
+		interned             map[string]*internedValue
+		totalCost, totalSize int64
+		sequence             uint64
+		now                  = time.Now()
+	)
+	if interning {
+		interned = make(map[string]*internedValue)
+	}
+	for key, data := range bl.data {
+		sequence++
+		data.createdAt = now
+		data.sequence = sequence
+		data.accessed = sequence
+		data = internValue(interned, internMinSize, data)
+		data.cost = costOf(data)
+		data.priority = float64(data.cost)
+		if maxMemory > 0 {
+			data.size = entrySize(key, data.Value)
+		}
+		totalCost += data.cost
+		totalSize += data.size
+		bl.data[key] = data
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	md.silenced = true
+	defer func() { md.silenced = false }()
+
+	md.storage = bl.data
+	md.recency = nil
+	md.priorities = nil
+	md.totalCost = totalCost
+	md.totalSize = totalSize
+	md.inflation = 0
+	md.objects = max(md.objects, bl.objects)
+	md.sequence = max(md.sequence, sequence)
+	md.clock = max(md.clock, sequence)
+	if interning {
+		md.interned = interned
+	}
+	md.swapDigests(bl.digests)
+	md.state().sets.Add(uint64(len(bl.data)))
+	md.state().dirty.Store(true)
+	md.evict()
+
+	return nil
+}
+
+// swapDigests replaces the original keys of the digested keys of the storage with digests, keeping the ones the
+// mapped snapshot still serves. The caller must hold md.state().mu.
+func (md *Memdis) swapDigests(digests map[string]string) {
+	md.state().digestsMu.Lock()
+	defer md.state().digestsMu.Unlock()
+
+	if md.mapped != nil {
+		for key, original := range md.digests {
+			if _, ok := md.mapped.index[key]; ok && !md.mapped.deleted[key] {
+				if _, ok := digests[key]; !ok {
+					digests[key] = original
+				}
+			}
+		}
+	}
+	md.digests = digests
+}
+
+// Set() adds a data to the new storage, replacing the one already set with the same key
+func (bl *BulkLoader) Set(key string, value interface{}, duration ...time.Duration) (err error) {
+	defer recoverPanic(&err)
+
+	key, digestOf, err := bl.md.digestedKey(key)
+	if err != nil {
+		return err
+	}
+	if digestOf != "" {
+		bl.digests[key] = digestOf
+	}
+
+	// a data replacing another one stays in its object
+	object := bl.data[key].object
+	if object == 0 {
+		bl.objects++
+		object = bl.objects
+	}
+
+	ttl := bl.md.ttlOf(duration)
+	bl.data[key] = MemdisData{
+		Value:    value,
+		Duration: expiresAt(ttl),
+		TTL:      ttl,
+		object:   object,
+	}
+
+	return nil
+}
+
+// Len() returns the number of datas set so far
+func (bl *BulkLoader) Len() int {
+	return len(bl.data)
+}
@@ -0,0 +1,67 @@
+package fscache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkLoad(t *testing.T) {
+	ch := Cache{}
+	assert.NoError(t, ch.Memdis().Set("old", "value"))
+
+	err := ch.Memdis().BulkLoad(func(bl *BulkLoader) error {
+		for i := 0; i < 1000; i++ {
+			if err := bl.Set(fmt.Sprintf("bulk%d", i), i); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1000, ch.Memdis().Size())
+
+	_, err = ch.Memdis().Get("old")
+	assert.Equal(t, errKeyNotFound, err)
+	value, err := ch.Memdis().Get("bulk999")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 999, value)
+
+	// nothing is swapped when the load fails
+	err = ch.Memdis().BulkLoad(func(bl *BulkLoader) error {
+		_ = bl.Set("partial", "value")
+		return errKeyNotFound
+	})
+	assert.Equal(t, errKeyNotFound, err)
+	assert.EqualValues(t, 1000, ch.Memdis().Size())
+}
+
+func TestBulkLoadKeepsOtherState(t *testing.T) {
+	ch := Cache{}
+	assert.NoError(t, ch.Memdis().Set("old", "value"))
+	assert.NoError(t, ch.Memdis().TSAdd("temperature", time.Now(), 21))
+	assert.NoError(t, ch.Memdis().GeoAdd("cities", GeoMember{Name: "Paris", Longitude: 2.35, Latitude: 48.85}))
+	deletes := ch.Memdis().Stats().Deletes
+
+	assert.NoError(t, ch.Memdis().BulkLoad(func(bl *BulkLoader) error {
+		for _, key := range []string{"bulk1", "bulk2", "bulk1"} {
+			if err := bl.Set(key, key); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+
+	// the replaced datas are not counted as deleted, and every key loaded is an object of its own
+	assert.Equal(t, deletes, ch.Memdis().Stats().Deletes)
+	assert.Len(t, ch.Memdis().KeyValuePairs(), 2)
+
+	// the series and the geo sets are not part of the load
+	samples, err := ch.Memdis().TSRange("temperature", time.Now().Add(-time.Hour), time.Now())
+	assert.NoError(t, err)
+	assert.Len(t, samples, 1)
+	_, err = ch.Memdis().GeoPos("cities", "Paris")
+	assert.NoError(t, err)
+}
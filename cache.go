@@ -1,8 +1,14 @@
 package fscache
 
 import (
+	"context"
+	"net/http"
 	"os"
+	"reflect"
+	"sync"
+	"sync/atomic"
 	"time"
+	"unsafe"
 
 	"github.com/robfig/cron/v3"
 	"github.com/rs/zerolog"
@@ -15,26 +21,239 @@ var (
 type (
 	// MemdisData object
 	MemdisData struct {
-		Value    interface{}
+		Value interface{}
+		// Duration is the time the data expires at, the zero time means it never expires
 		Duration time.Time
+		// TTL is the duration the data was set with. SetMany() derives Duration from it when Duration is not set
+		TTL time.Duration
+
+		// loader is used to refresh the data, if it was set with GetOrLoad()
+		loader LoaderContext
+		// loadedKey is the key the caller gave GetOrLoad(), passed to loader by the refreshes
+		loadedKey string
+		// digestOf is the key a data set with a digested key was set with, recorded by store()
+		digestOf string
+		// cost is the weight of the data against the WithMaxCost budget
+		cost int64
+		// priority is the GreedyDual priority of the data, the lowest one is evicted first
+		priority float64
+		// rank is the priority the data was set with, datas with the lowest rank are evicted first
+		rank int
+		// tags are the tags the data was set with
+		tags []string
+		// createdAt is the time the data was first set
+		createdAt time.Time
+		// sequence is the order the data was first set in
+		sequence uint64
+		// version is the write which set the data, so the refreshes don't replace a data written since they started
+		version uint64
+		// object is the data object the data was set with, KeyValuePairs() returns the datas of an object together
+		object uint64
+		// size is the estimated size in bytes of the data, when WithMaxMemory is used
+		size int64
+		// accessed is the access clock of the last read or write of the data, for the LRU eviction
+		accessed uint64
+		// loadTime is the time the loader took to return the value
+		loadTime time.Duration
+		// hits is the number of times the data was read
+		hits int64
+		// internKey is the content hash of the value shared with other datas, if it is interned
+		internKey string
 	}
 
 	// Memdis object instance
 	Memdis struct {
 		logger zerolog.Logger
+		// shared holds the *memdisState of Memdis, see state()
+		shared unsafe.Pointer
 		// storage for key value pair storage
-		storage []map[string]MemdisData
+		storage map[string]MemdisData
+
+		// refreshAhead is the remaining lifetime under which a data is refreshed by its loader
+		refreshAhead time.Duration
+		// earlyExpirationBeta scales the probabilistic early refreshes of the loaded datas, disabled when 0
+		earlyExpirationBeta float64
+		// refreshing holds the keys currently being refreshed in the background
+		refreshing map[string]bool
+		// loading holds the loads of GetOrLoad() in flight, which the concurrent misses of their key wait for
+		loading map[string]*pendingLoad
+		// loaderLimits bound the number of loaders running at once
+		loaderLimits loaderLimits
+		// events queues the changes of the keys once Events() is called
+		events *keyEvents
+		// silenced stops the events of the bulk operations
+		silenced bool
+
+		// defaultExpiration is the ttl of datas set with DefaultExpiration
+		defaultExpiration time.Duration
+		// overWriteResetsTTL makes overwrites without a duration drop the expiration of datas
+		overWriteResetsTTL bool
+
+		// duplicatePolicy defines how SetMany() handles keys set more than once or already set
+		duplicatePolicy DuplicatePolicy
+
+		// keyTransform canonicalizes and validates the keys of every operation
+		keyTransform KeyTransform
+		// keyDigestThreshold is the length above which keys are stored as a digest
+		keyDigestThreshold int
+		// digests maps the digested keys to their original key
+		digests map[string]string
+
+		// maxCost is the budget of the total cost of the datas, 0 means unbounded
+		maxCost   int64
+		totalCost int64
+		// inflation is the GreedyDual aging value, the priority of the last evicted data
+		inflation float64
+		// maxEntries is the maximum number of datas, 0 means unbounded
+		maxEntries int
+		// clock is the access clock of the last read or write of a data
+		clock uint64
+		// maxMemory is the budget of the estimated size in bytes of the datas, 0 means unbounded
+		maxMemory int64
+		totalSize int64
+		// containerFraction is the fraction of the container memory limit maxMemory is set to, see WithMaxMemoryFromCgroup
+		containerFraction float64
+		// admission is the frequency sketch of the TinyLFU admission filter, nil when disabled
+		admission *frequencySketch
+		// recency and priorities order the datas for the LRU and GreedyDual evictions, nil until an eviction needs them
+		recency    *recencyOrder
+		priorities *priorityOrder
+
+		// interned are the values shared by datas with the same content, nil when interning is disabled
+		interned      map[string]*internedValue
+		internMinSize int
+
+		// typeNames are the friendly type names returned by TypeOf()
+		typeNames map[reflect.Type]string
+
+		// writeBarrier makes mutations wait while SaveSnapshot() writes the datas
+		writeBarrier bool
+		// mapped serves the datas of the snapshot file mapped with MapSnapshot(), nil when none is mapped
+		mapped *mappedSnapshot
+		// persistPrefixes are the key prefixes SaveSnapshot() writes, all the keys when empty
+		persistPrefixes []string
+
+		// series are the time series, kept apart from the key value datas
+		series map[string]*timeSeries
+		// geo are the coordinates of the members of the geo keys, kept apart from the key value datas
+		geo map[string]map[string]geoPoint
+
+		// redactions are the patterns of the keys redacted in the logs
+		redactions []string
+
+		// asyncWorkers is the number of workers of asyncPool
+		asyncWorkers int
+		asyncPool    *asyncPool
+
+		// loadWorkers is the number of goroutines decoding the snapshot file, runtime.GOMAXPROCS when 0
+		loadWorkers int
+
+		// memoryPressure configures the eviction of datas under memory pressure, nil when disabled
+		memoryPressure *MemoryPressure
+		// fullPolicy is how new datas are handled once Memdis is full
+		fullPolicy FullPolicy
+		// opTimeout is the time after which the ForEachParallel() scans are aborted, never when 0
+		opTimeout time.Duration
+		// iterationOrder is the order the datas are enumerated in
+		iterationOrder IterationOrder
+		// sequence is the sequence of the last data set
+		sequence uint64
+		// version is the version of the last data written
+		version uint64
+		// objects is the object of the last data object set
+		objects uint64
+
+		// chaos injects latency and failures into the operations, nil unless WithChaos is used
+		chaos *chaosMonkey
+
+		// recorder records the operations, nil unless WithRecorder is used
+		recorder *recorder
+
+		// janitorInterval is how often the janitor removes the expired datas, with the cronJob when 0
+		janitorInterval time.Duration
+
+		// onExpired is called with the keys removed by each expiration sweep
+		onExpired func(keys []string)
+	}
+
+	// memdisState holds the locks and the counters of Memdis. It is allocated on first use and shared by the copies
+	// of Memdis, so a Memdis value can be built and copied before it is used.
+	memdisState struct {
+		// mu guards storage
+		mu sync.RWMutex
+		// refreshingMu guards refreshing
+		refreshingMu sync.Mutex
+		// loadingMu guards loading
+		loadingMu sync.Mutex
+		// digestsMu guards digests
+		digestsMu sync.Mutex
+		// asyncOnce starts asyncPool
+		asyncOnce sync.Once
+
+		// persistSnapshot makes the cronJob write the datas into the snapshot file
+		persistSnapshot atomic.Bool
+		// dirty is set when the datas changed since the last snapshot
+		dirty atomic.Bool
+		// pressured is set while the heap exceeds its target and the full policy is not FullEvict
+		pressured atomic.Bool
+
+		// hits, misses, sets, deletes, evictions and expirations are the counters returned by Stats()
+		hits        atomic.Uint64
+		misses      atomic.Uint64
+		sets        atomic.Uint64
+		deletes     atomic.Uint64
+		evictions   atomic.Uint64
+		expirations atomic.Uint64
+		// droppedEvents is the number of KeyEvents dropped because the queue of Events() was full
+		droppedEvents atomic.Uint64
+
+		// reads buffers the reads done holding mu for reading, see applyReads()
+		reads chan bufferedRead
 	}
 
 	// Memgodb object instance
 	Memgodb struct {
 		logger zerolog.Logger
+
+		// writeBarrier makes mutations wait while Persist() writes the datas
+		writeBarrier bool
+		// persistCollections are the collections Persist() writes, all of them when empty
+		persistCollections []string
+		// idGenerator generates the id of the records, a UUID is used when nil
+		idGenerator func() string
+		// clock returns the createdAt and updatedAt of the records, time.Now is used when nil
+		clock func() time.Time
+		// encryptions are the field encryptions of the collections, replaced as a whole by EncryptFields
+		encryptions map[string]*fieldEncryption
+		// collations are the collations of the collections, replaced as a whole by SetCollation
+		collations map[string]Collation
+		// redactions are the patterns of the fields redacted by Redact()
+		redactions []string
+		// loadWorkers is the number of goroutines decoding the loaded files, runtime.GOMAXPROCS when 0
+		loadWorkers int
+		// opTimeout is the time after which the queries are aborted, never when 0
+		opTimeout time.Duration
+		// iterationOrder is the order the records are enumerated in
+		iterationOrder IterationOrder
+		// chaos injects latency and failures into the operations, nil unless WithChaos is used
+		chaos *chaosMonkey
 	}
 
 	// Cache object
 	Cache struct {
 		MemdisInstance  Memdis
 		MemgodbInstance Memgodb
+
+		// lifecycleMu guards the background jobs started by Start()
+		lifecycleMu sync.Mutex
+		// jobs runs the cronJob, nil when the background jobs are not started
+		jobs *cron.Cron
+		// stopJobs stops the background goroutines
+		stopJobs context.CancelFunc
+		// background tracks the background goroutines, so Stop() can wait for them
+		background sync.WaitGroup
+		// err is the first error of the options, returned by Err()
+		err error
 	}
 
 	// Operations lists all available operations on the fscache
@@ -47,66 +266,99 @@ type (
 		// Memgodb gives you a MongoDB-like feature similarly as you would with a MondoDB database
 		Memgodb() *Memgodb
 	}
+
+	// Lifecycle lists the operations running the background jobs of the cache
+	Lifecycle interface {
+		// Start() starts the background jobs of the cache
+		Start(ctx context.Context) error
+		// Stop() stops the background jobs of the cache
+		Stop(ctx context.Context) error
+		// Close() stops the background jobs of the cache, waiting for them to complete
+		Close() error
+		// EnableSignalHandlers() persists the datas on SIGHUP and logs stats on SIGUSR1
+		EnableSignalHandlers() (stop func())
+	}
+
+	// Observer lists the operations reporting the state of the cache
+	Observer interface {
+		// Err() returns the first error of the options passed to New(), e.g. a seed file that cannot be loaded
+		Err() error
+		// Stats() returns all the counters, sizes and config of the cache
+		Stats() Stats
+		// StatsHandler() returns an http.Handler serving Stats() as json
+		StatsHandler() http.Handler
+	}
+
+	// Views lists the operations serving the datas of the cache through other APIs
+	Views interface {
+		// ReadView returns an immutable point-in-time copy of the Memdis storage for heavy readers
+		ReadView() *ReadView
+		// Redis returns a RedisAdapter implementing the most used go-redis commands on top of Memdis
+		Redis() *RedisAdapter
+		// Lookup() resolves a mixed batch of Memdis gets and Memgodb finds in one pass
+		Lookup(ctx context.Context, req LookupRequest) (LookupResult, error)
+	}
 )
 
-// New initializes an instance of the in-memory storage cache
-func New() Operations {
-	var memdicSorage []map[string]MemdisData
+// New initializes an instance of the in-memory storage cache, and starts its background jobs.
+// The cache returned also implements Lifecycle, Observer and Views, use NewCache() to call them without assertions.
+func New(opts ...Option) Operations {
+	ch := NewCache(opts...)
+	ch.Start(context.Background())
+
+	op := Operations(ch)
+	return op
+}
+
+// NewCache initializes an instance of the in-memory storage cache without starting its background jobs,
+// so they can be tied to the lifecycle of the application with Start() and Stop()
+func NewCache(opts ...Option) *Cache {
 	logger := zerolog.New(os.Stderr).With().Timestamp().Logger()
 
-	md := Memdis{
-		logger:  logger,
-		storage: memdicSorage,
+	ch := &Cache{
+		MemdisInstance: Memdis{
+			logger:  logger,
+			storage: make(map[string]MemdisData),
+		},
+		MemgodbInstance: Memgodb{
+			logger: logger,
+		},
 	}
 
-	Memgodb := Memgodb{
-		logger: logger,
+	for _, opt := range opts {
+		opt(ch)
 	}
+	ch.MemdisInstance.maxMemoryFromCgroup(os.DirFS("/"))
 
-	ch := Cache{
-		MemdisInstance:  md,
-		MemgodbInstance: Memgodb,
-	}
+	return ch
+}
 
-	c := cron.New()
+// runJobs runs the jobs of the cronJob
+func (c *Cache) runJobs() {
+	logger := c.MemdisInstance.logger
+	defer logPanic(logger)
 
-	// cron job set to run every 1 minute
-	c.AddFunc("*/1 * * * *", func() {
-		if debug {
-			logger.Info().Msg("cron job running...")
-		}
+	if debug {
+		logger.Info().Msg("cron job running...")
+	}
 
-		if persistMemgodbData {
-			if err := ch.MemgodbInstance.Persist(); err != nil {
-				if debug {
-					logger.Info().Msgf("persist error: %v", err)
-				}
+	if persistMemgodbData.Load() {
+		if err := c.MemgodbInstance.Persist(); err != nil {
+			if debug {
+				logger.Info().Msgf("persist error: %v", err)
 			}
 		}
+	}
+
+	c.MemdisInstance.expire()
 
-		for i := 0; i < len(ch.MemdisInstance.storage); i++ {
-			for _, value := range ch.MemdisInstance.storage[i] {
-				currenctTime := time.Now()
-				if currenctTime.Before(value.Duration) {
-					if debug {
-						logger.Info().Msgf("data object [%v] got expired ", ch.MemdisInstance.storage[i])
-					}
-					// take the data from off the array object
-					ch.MemdisInstance.storage = append(ch.MemdisInstance.storage[:i], ch.MemdisInstance.storage[i+1:]...)
-					// decrement the array index by 1 since an object have been taken off the array
-					i--
-				}
+	if c.MemdisInstance.state().persistSnapshot.Load() {
+		if err := c.MemdisInstance.SaveSnapshot(); err != nil {
+			if debug {
+				logger.Info().Msgf("snapshot error: %v", err)
 			}
 		}
-	})
-
-	c.Start()
-	if debug {
-		logger.Info().Msgf("cron job entries ::: %v", c.Entries())
 	}
-
-	op := Operations(&ch)
-	return op
 }
 
 // Debug() enables debug to get certain logs
@@ -114,14 +366,39 @@ func (c *Cache) Debug() {
 	debug = true
 }
 
+// Err() returns the first error of the options passed to New() or NewCache(), nil if they all succeeded.
+// The cache stays usable when an option fails, the failed option being skipped.
+func (c *Cache) Err() error {
+	return c.err
+}
+
+// fail records the error of an option, only the first one being kept
+func (c *Cache) fail(err error) {
+	if debug {
+		c.MemdisInstance.logger.Error().Msg(err.Error())
+	}
+
+	if c.err == nil {
+		c.err = err
+	}
+}
+
 // KeyValue returns methods for key-value pair storage
 func (c *Cache) Memdis() *Memdis {
 	return &c.MemdisInstance
 }
 
+// state returns the locks and the counters of Memdis, allocating them on first use
+func (md *Memdis) state() *memdisState {
+	if state := atomic.LoadPointer(&md.shared); state != nil {
+		return (*memdisState)(state)
+	}
+
+	atomic.CompareAndSwapPointer(&md.shared, nil, unsafe.Pointer(&memdisState{reads: make(chan bufferedRead, readBufferSize)}))
+	return (*memdisState)(atomic.LoadPointer(&md.shared))
+}
+
 // Memgodb returns methods for Memgodb-like storage
 func (c *Cache) Memgodb() *Memgodb {
-	return &Memgodb{
-		logger: c.MemgodbInstance.logger,
-	}
+	return &c.MemgodbInstance
 }
@@ -0,0 +1,19 @@
+package fscache
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewInterfaces(t *testing.T) {
+	fs := NewCache()
+	var op Operations = fs
+
+	// the capabilities added to the cache are kept out of Operations
+	assert.Implements(t, (*Lifecycle)(nil), op)
+	assert.Implements(t, (*Observer)(nil), op)
+	assert.Implements(t, (*Views)(nil), op)
+	assert.Equal(t, 3, reflect.TypeOf((*Operations)(nil)).Elem().NumMethod())
+}
@@ -0,0 +1,111 @@
+package fscache
+
+import (
+	"bufio"
+	"io/fs"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultContainerFraction is the fraction of the container memory limit the heap is checked against by default
+	defaultContainerFraction = 0.9
+	// defaultMaxMemoryFraction is the fraction of the container memory limit WithMaxMemoryFromCgroup sets the
+	// WithMaxMemory budget to by default
+	defaultMaxMemoryFraction = 0.5
+	// cgroupUnlimited is the smallest cgroup v1 limit meaning no limit, the page aligned max int64
+	cgroupUnlimited = 1 << 62
+)
+
+// ContainerMemoryLimit returns the memory limit of the cgroup (v1 or v2) the process runs in, such as the limit of
+// its Kubernetes container, and false if there is none.
+func ContainerMemoryLimit() (int64, bool) {
+	return cgroupMemoryLimit(os.DirFS("/"))
+}
+
+// WithMaxMemoryFromCgroup sets the WithMaxMemory budget to fraction of the memory limit of the container (cgroup v1
+// or v2) the process runs in, e.g. of its Kubernetes pod, so it needs no manual tuning. A fraction of 0 or less uses
+// 0.5, so the process keeps room for the overhead of the datas and for the application. WithMaxMemory overrides it,
+// and nothing is bounded outside of a container with a memory limit.
+func WithMaxMemoryFromCgroup(fraction float64) Option {
+	return func(c *Cache) {
+		if fraction <= 0 {
+			fraction = defaultMaxMemoryFraction
+		}
+		c.MemdisInstance.containerFraction = fraction
+	}
+}
+
+// maxMemoryFromCgroup sets the WithMaxMemory budget to the fraction set with WithMaxMemoryFromCgroup of the memory
+// limit of the cgroup read from the root filesystem fsys, unless it is already set, and accounts for the datas
+// already loaded, e.g. by WithSeedFile
+func (md *Memdis) maxMemoryFromCgroup(fsys fs.FS) {
+	if md.maxMemory > 0 || md.containerFraction <= 0 {
+		return
+	}
+
+	limit, ok := cgroupMemoryLimit(fsys)
+	if !ok {
+		return
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	md.maxMemory = int64(float64(limit) * md.containerFraction)
+	for key, data := range md.storage {
+		data.size = entrySize(key, data.Value)
+		md.totalSize += data.size
+		md.storage[key] = data
+	}
+	md.evict()
+}
+
+// cgroupMemoryLimit returns the memory limit of the cgroup of the process read from the root filesystem fsys
+func cgroupMemoryLimit(fsys fs.FS) (int64, bool) {
+	// cgroup v2 exposes the limit of the cgroup of the process, or of the namespace root inside a container
+	if group, ok := cgroupV2Path(fsys); ok {
+		for _, dir := range []string{path.Join("sys/fs/cgroup", group), "sys/fs/cgroup"} {
+			if limit, ok := readCgroupLimit(fsys, path.Join(dir, "memory.max")); ok {
+				return limit, true
+			}
+		}
+	}
+
+	return readCgroupLimit(fsys, "sys/fs/cgroup/memory/memory.limit_in_bytes")
+}
+
+// cgroupV2Path returns the path of the cgroup v2 of the process listed in /proc/self/cgroup
+func cgroupV2Path(fsys fs.FS) (string, bool) {
+	f, err := fsys.Open("proc/self/cgroup")
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if group, ok := strings.CutPrefix(scanner.Text(), "0::"); ok {
+			return strings.TrimPrefix(group, "/"), true
+		}
+	}
+
+	return "", false
+}
+
+// readCgroupLimit reads a memory limit file, "max" or a too large value meaning no limit
+func readCgroupLimit(fsys fs.FS, name string) (int64, bool) {
+	data, err := fs.ReadFile(fsys, name)
+	if err != nil {
+		return 0, false
+	}
+
+	limit, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil || limit <= 0 || limit >= cgroupUnlimited {
+		return 0, false
+	}
+
+	return limit, true
+}
@@ -0,0 +1,76 @@
+package fscache
+
+import (
+	"context"
+	runtimedebug "runtime/debug"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCgroupMemoryLimit(t *testing.T) {
+	v2 := fstest.MapFS{
+		"proc/self/cgroup":                       {Data: []byte("0::/kubepods/pod1\n")},
+		"sys/fs/cgroup/kubepods/pod1/memory.max": {Data: []byte("536870912\n")},
+	}
+	limit, ok := cgroupMemoryLimit(v2)
+	assert.True(t, ok)
+	assert.Equal(t, int64(512<<20), limit)
+
+	unlimited := fstest.MapFS{
+		"proc/self/cgroup":         {Data: []byte("0::/\n")},
+		"sys/fs/cgroup/memory.max": {Data: []byte("max\n")},
+	}
+	_, ok = cgroupMemoryLimit(unlimited)
+	assert.False(t, ok)
+
+	v1 := fstest.MapFS{
+		"proc/self/cgroup":                           {Data: []byte("4:memory:/docker/abc\n")},
+		"sys/fs/cgroup/memory/memory.limit_in_bytes": {Data: []byte("268435456\n")},
+	}
+	limit, ok = cgroupMemoryLimit(v1)
+	assert.True(t, ok)
+	assert.Equal(t, int64(256<<20), limit)
+
+	v1Unlimited := fstest.MapFS{
+		"sys/fs/cgroup/memory/memory.limit_in_bytes": {Data: []byte("9223372036854771712\n")},
+	}
+	_, ok = cgroupMemoryLimit(v1Unlimited)
+	assert.False(t, ok)
+}
+
+func TestWithMaxMemoryFromCgroup(t *testing.T) {
+	container := fstest.MapFS{
+		"proc/self/cgroup":                       {Data: []byte("0::/kubepods/pod1\n")},
+		"sys/fs/cgroup/kubepods/pod1/memory.max": {Data: []byte("536870912\n")},
+	}
+
+	ch := Cache{}
+	WithMaxMemoryFromCgroup(0)(&ch)
+	assert.NoError(t, ch.Memdis().Set("seeded", "value"))
+	ch.MemdisInstance.maxMemoryFromCgroup(container)
+	assert.Equal(t, int64(256<<20), ch.MemdisInstance.maxMemory)
+	assert.Equal(t, entrySize("seeded", "value"), ch.MemdisInstance.totalSize)
+
+	// WithMaxMemory overrides it
+	explicit := Cache{}
+	WithMaxMemory(1 << 20)(&explicit)
+	WithMaxMemoryFromCgroup(0.5)(&explicit)
+	explicit.MemdisInstance.maxMemoryFromCgroup(container)
+	assert.Equal(t, int64(1<<20), explicit.MemdisInstance.maxMemory)
+
+	// the budget is left unbounded unless it is asked for
+	unset := Cache{}
+	unset.MemdisInstance.maxMemoryFromCgroup(container)
+	assert.Zero(t, unset.MemdisInstance.maxMemory)
+
+	// the memory watcher leaves the soft memory limit of the process unchanged, even with a Limit
+	limit := runtimedebug.SetMemoryLimit(-1)
+	watched := NewCache(WithMemoryPressure(MemoryPressure{Limit: 1 << 40, Interval: time.Millisecond}))
+	assert.NoError(t, watched.Start(context.Background()))
+	time.Sleep(10 * time.Millisecond)
+	assert.NoError(t, watched.Close())
+	assert.Equal(t, limit, runtimedebug.SetMemoryLimit(-1))
+}
@@ -0,0 +1,73 @@
+package fscache
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInjected is returned by the operations failed on purpose by WithChaos
+	ErrInjected = errors.New("injected failure")
+)
+
+// Chaos object configures the misbehaviour WithChaos injects into the operations of the cache
+type Chaos struct {
+	// Latency is added to every operation
+	Latency time.Duration
+	// Jitter is the maximum random latency added on top of Latency
+	Jitter time.Duration
+	// ErrorRate is the fraction of the operations, between 0 and 1, failing with ErrInjected
+	ErrorRate float64
+	// Seed makes the injected jitter and failures reproducible, they are random when 0
+	Seed int64
+}
+
+// chaosMonkey injects the latency and failures configured with WithChaos
+type chaosMonkey struct {
+	Chaos
+
+	mu   sync.Mutex
+	rand *rand.Rand
+}
+
+// WithChaos injects artificial latency and failures into the Memdis and Memgodb operations returning an error,
+// so applications can verify their fallback behaviour when the cache misbehaves. It is meant for tests only.
+func WithChaos(chaos Chaos) Option {
+	return func(c *Cache) {
+		seed := chaos.Seed
+		if seed == 0 {
+			seed = time.Now().UnixNano()
+		}
+
+		monkey := &chaosMonkey{Chaos: chaos, rand: rand.New(rand.NewSource(seed))}
+		c.MemdisInstance.chaos = monkey
+		c.MemgodbInstance.chaos = monkey
+	}
+}
+
+// inject sleeps for the configured latency, and returns ErrInjected for the configured fraction of the calls.
+// It does nothing when m is nil.
+func (m *chaosMonkey) inject() error {
+	if m == nil {
+		return nil
+	}
+
+	m.mu.Lock()
+	latency := m.Latency
+	if m.Jitter > 0 {
+		latency += time.Duration(m.rand.Int63n(int64(m.Jitter)))
+	}
+	fail := m.ErrorRate > 0 && m.rand.Float64() < m.ErrorRate
+	m.mu.Unlock()
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	if fail {
+		return ErrInjected
+	}
+	return nil
+}
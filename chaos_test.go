@@ -0,0 +1,37 @@
+package fscache
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChaos(t *testing.T) {
+	ch := Cache{}
+	WithChaos(Chaos{ErrorRate: 1})(&ch)
+
+	assert.ErrorIs(t, ch.Memdis().Set("key", "value"), ErrInjected)
+	_, err := ch.Memgodb().Collection("chaosrecord").Insert(map[string]interface{}{"name": "chaos"}).One()
+	assert.ErrorIs(t, err, ErrInjected)
+
+	slow := Cache{}
+	WithChaos(Chaos{Latency: 20 * time.Millisecond, Jitter: 10 * time.Millisecond, Seed: 1})(&slow)
+
+	start := time.Now()
+	assert.NoError(t, slow.Memdis().Set("key", "value"))
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+
+	// half of the operations fail
+	flaky := Cache{}
+	WithChaos(Chaos{ErrorRate: 0.5, Seed: 1})(&flaky)
+
+	var failed int
+	for i := 0; i < 1000; i++ {
+		if _, err := flaky.Memdis().Get("missing"); errors.Is(err, ErrInjected) {
+			failed++
+		}
+	}
+	assert.InDelta(t, 500, failed, 100)
+}
@@ -0,0 +1,172 @@
+package fscache
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// Collation object defines how the strings of a collection are compared by filters and Sort()
+type Collation struct {
+	// CaseInsensitive folds the case of the strings, so "Jose" and "jose" match
+	CaseInsensitive bool
+	// IgnoreAccents strips the accents of the latin letters, so "José" and "Jose" match
+	IgnoreAccents bool
+	// Locale is the BCP 47 language tag, e.g. "fr", "de" or "sv", whose rules order the strings, using the Unicode
+	// Collation Algorithm. Without it, the strings are ordered code point by code point once folded.
+	Locale string
+
+	// collators are the collators of Locale, a collator not being safe for concurrent use
+	collators *sync.Pool
+}
+
+// accents maps the accented latin letters to their base letter
+var accents = map[rune]string{
+	'À': "A", 'Á': "A", 'Â': "A", 'Ã': "A", 'Ä': "A", 'Å': "A", 'Ā': "A", 'Ă': "A", 'Ą': "A",
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'Ç': "C", 'Ć': "C", 'Ĉ': "C", 'Ċ': "C", 'Č': "C", 'ç': "c", 'ć': "c", 'ĉ': "c", 'ċ': "c", 'č': "c",
+	'Ď': "D", 'Đ': "D", 'ď': "d", 'đ': "d",
+	'È': "E", 'É': "E", 'Ê': "E", 'Ë': "E", 'Ē': "E", 'Ĕ': "E", 'Ė': "E", 'Ę': "E", 'Ě': "E",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ĕ': "e", 'ė': "e", 'ę': "e", 'ě': "e",
+	'Ĝ': "G", 'Ğ': "G", 'Ġ': "G", 'Ģ': "G", 'ĝ': "g", 'ğ': "g", 'ġ': "g", 'ģ': "g",
+	'Ĥ': "H", 'Ħ': "H", 'ĥ': "h", 'ħ': "h",
+	'Ì': "I", 'Í': "I", 'Î': "I", 'Ï': "I", 'Ĩ': "I", 'Ī': "I", 'Ĭ': "I", 'Į': "I", 'İ': "I",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ĩ': "i", 'ī': "i", 'ĭ': "i", 'į': "i", 'ı': "i",
+	'Ĵ': "J", 'ĵ': "j", 'Ķ': "K", 'ķ': "k",
+	'Ĺ': "L", 'Ļ': "L", 'Ľ': "L", 'Ŀ': "L", 'Ł': "L", 'ĺ': "l", 'ļ': "l", 'ľ': "l", 'ŀ': "l", 'ł': "l",
+	'Ñ': "N", 'Ń': "N", 'Ņ': "N", 'Ň': "N", 'ñ': "n", 'ń': "n", 'ņ': "n", 'ň': "n",
+	'Ò': "O", 'Ó': "O", 'Ô': "O", 'Õ': "O", 'Ö': "O", 'Ø': "O", 'Ō': "O", 'Ŏ': "O", 'Ő': "O",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ŏ': "o", 'ő': "o",
+	'Œ': "OE", 'œ': "oe", 'Æ': "AE", 'æ': "ae", 'ß': "ss",
+	'Ŕ': "R", 'Ŗ': "R", 'Ř': "R", 'ŕ': "r", 'ŗ': "r", 'ř': "r",
+	'Ś': "S", 'Ŝ': "S", 'Ş': "S", 'Š': "S", 'ś': "s", 'ŝ': "s", 'ş': "s", 'š': "s",
+	'Ţ': "T", 'Ť': "T", 'Ŧ': "T", 'ţ': "t", 'ť': "t", 'ŧ': "t",
+	'Ù': "U", 'Ú': "U", 'Û': "U", 'Ü': "U", 'Ũ': "U", 'Ū': "U", 'Ŭ': "U", 'Ů': "U", 'Ű': "U", 'Ų': "U",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ũ': "u", 'ū': "u", 'ŭ': "u", 'ů': "u", 'ű': "u", 'ų': "u",
+	'Ŵ': "W", 'ŵ': "w", 'Ý': "Y", 'Ŷ': "Y", 'Ÿ': "Y", 'ý': "y", 'ÿ': "y", 'ŷ': "y",
+	'Ź': "Z", 'Ż': "Z", 'Ž': "Z", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// SetCollation sets how the strings of collection are compared by filters and Sort(), e.g. to make "José" match
+// "jose". It applies to the collections created with Collection() afterwards.
+func (n *Memgodb) SetCollation(collection string, collation Collation) {
+	memgodbMu.Lock()
+	defer memgodbMu.Unlock()
+
+	// the collections already created keep using the collations they were created with
+	collations := make(map[string]Collation, len(n.collations)+1)
+	for col, c := range n.collations {
+		collations[col] = c
+	}
+	if collation.Locale != "" {
+		tag := language.Make(collation.Locale)
+		var options []collate.Option
+		if collation.CaseInsensitive {
+			options = append(options, collate.IgnoreCase)
+		}
+		if collation.IgnoreAccents {
+			options = append(options, collate.IgnoreDiacritics)
+		}
+		collation.collators = &sync.Pool{New: func() any { return collate.New(tag, options...) }}
+	}
+	collations[collectionName(collection)] = collation
+	n.collations = collations
+}
+
+// Sort is a method available in Filter(), it makes All() return the records ordered by the value of field, using
+// the collation of the collection for strings. Records without the field come last.
+func (f *Filter) Sort(field string, descending bool) *Filter {
+	f.sortField = field
+	f.sortDescending = descending
+
+	return f
+}
+
+// sorted orders records as requested by Sort(), or in the order set with WithIterationOrder() without Sort()
+func (f *Filter) sorted(records []map[string]interface{}) []map[string]interface{} {
+	if f.sortField == "" {
+		return f.collection.ordered(records)
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		a, okA := records[i][f.sortField]
+		b, okB := records[j][f.sortField]
+		if !okA || !okB {
+			return okA
+		}
+
+		if f.sortDescending {
+			return f.collection.less(b, a)
+		}
+		return f.collection.less(a, b)
+	})
+
+	return records
+}
+
+// equal reports whether a filter value matches a field value, using the collation of the collection for strings
+func (c *Collection) equal(filter, value interface{}) bool {
+	a, okA := filter.(string)
+	b, okB := value.(string)
+	if okA && okB {
+		return c.collation.compare(a, b) == 0
+	}
+
+	return filter == value
+}
+
+// less reports whether a sorts before b. Numbers are compared by value, strings using the collation of the
+// collection, and the other values by their string representation.
+func (c *Collection) less(a, b interface{}) bool {
+	if x, ok := a.(float64); ok {
+		if y, ok := b.(float64); ok {
+			return x < y
+		}
+	}
+
+	x, y := fmt.Sprint(a), fmt.Sprint(b)
+	if order := c.collation.compare(x, y); order != 0 {
+		return order < 0
+	}
+
+	return x < y
+}
+
+// compare compares a and b like strings.Compare(), using the rules of the locale of the collation if any
+func (collation Collation) compare(a, b string) int {
+	if collation.collators == nil {
+		return strings.Compare(collation.key(a), collation.key(b))
+	}
+
+	collator := collation.collators.Get().(*collate.Collator)
+	defer collation.collators.Put(collator)
+
+	return collator.CompareString(a, b)
+}
+
+// key returns the form of s the collation compares without locale
+func (collation Collation) key(s string) string {
+	if collation.IgnoreAccents {
+		var b strings.Builder
+		for _, r := range s {
+			if base, ok := accents[r]; ok {
+				b.WriteString(base)
+			} else if !unicode.Is(unicode.Mn, r) {
+				// combining marks are the accents of the decomposed letters
+				b.WriteRune(r)
+			}
+		}
+		s = b.String()
+	}
+
+	if collation.CaseInsensitive {
+		s = strings.ToLower(s)
+	}
+
+	return s
+}
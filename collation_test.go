@@ -0,0 +1,70 @@
+package fscache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Collation(t *testing.T) {
+	ch := Cache{}
+	ch.Memgodb().SetCollation("collated", Collation{CaseInsensitive: true, IgnoreAccents: true})
+
+	for _, name := range []string{"Zoé", "josé", "Émile", "adam"} {
+		_, err := ch.Memgodb().Collection("collated").Insert(map[string]interface{}{"name": name}).One()
+		assert.NoError(t, err)
+	}
+
+	record, err := ch.Memgodb().Collection("collated").Filter(map[string]interface{}{"name": "JOSE"}).First()
+	assert.NoError(t, err)
+	assert.Equal(t, "josé", record["name"])
+
+	records, err := ch.Memgodb().Collection("collated").Filter(nil).Sort("name", false).All()
+	assert.NoError(t, err)
+	var names []string
+	for _, record := range records {
+		if record["colName"] == "collateds" {
+			names = append(names, record["name"].(string))
+		}
+	}
+	assert.Equal(t, []string{"adam", "Émile", "josé", "Zoé"}, names)
+
+	// the collections without collation compare strings exactly
+	_, err = ch.Memgodb().Collection("uncollated").Insert(map[string]interface{}{"name": "josé"}).One()
+	assert.NoError(t, err)
+	_, err = ch.Memgodb().Collection("uncollated").Filter(map[string]interface{}{"name": "jose"}).First()
+	assert.Error(t, err)
+}
+
+func Test_CollationLocale(t *testing.T) {
+	ch := Cache{}
+	ch.Memgodb().SetCollation("swedish", Collation{CaseInsensitive: true, Locale: "sv"})
+	ch.Memgodb().SetCollation("german", Collation{CaseInsensitive: true, IgnoreAccents: true, Locale: "de"})
+
+	sorted := func(collection string) []string {
+		for _, name := range []string{"Zebra", "öl", "Olaf", "apple"} {
+			_, err := ch.Memgodb().Collection(collection).Insert(map[string]interface{}{"name": name}).One()
+			assert.NoError(t, err)
+		}
+
+		records, err := ch.Memgodb().Collection(collection).Filter(nil).Sort("name", false).All()
+		assert.NoError(t, err)
+		var names []string
+		for _, record := range records {
+			if record["colName"] == collection+"s" {
+				names = append(names, record["name"].(string))
+			}
+		}
+		return names
+	}
+
+	// swedish sorts "ö" after "z", german with "o"
+	assert.Equal(t, []string{"apple", "Olaf", "Zebra", "öl"}, sorted("swedish"))
+	assert.Equal(t, []string{"apple", "öl", "Olaf", "Zebra"}, sorted("german"))
+
+	record, err := ch.Memgodb().Collection("german").Filter(map[string]interface{}{"name": "OL"}).First()
+	assert.NoError(t, err)
+	assert.Equal(t, "öl", record["name"])
+	_, err = ch.Memgodb().Collection("swedish").Filter(map[string]interface{}{"name": "ol"}).First()
+	assert.Error(t, err)
+}
@@ -0,0 +1,33 @@
+package fscache
+
+// compactMinCapacity is the capacity under which the Memgodb storage is never compacted automatically
+const compactMinCapacity = 64
+
+// Compact rebuilds the Memgodb storage into a slice fitting its records, releasing the memory still held
+// by the records which were deleted. It runs automatically once deletes leave most of the storage unused.
+func (n *Memgodb) Compact() {
+	memgodbMu.Lock()
+	defer memgodbMu.Unlock()
+
+	compactMemgodb()
+}
+
+// compactMemgodbIfNeeded compacts the storage once less than half of its capacity is used.
+// The caller must hold memgodbMu.
+func compactMemgodbIfNeeded() {
+	if cap(MemgodbStorage) >= compactMinCapacity && len(MemgodbStorage) < cap(MemgodbStorage)/2 {
+		compactMemgodb()
+	}
+}
+
+// compactMemgodb copies the records into a new storage so the old one, along with the deleted records
+// it still references, can be garbage collected. The caller must hold memgodbMu.
+func compactMemgodb() {
+	if MemgodbStorage == nil {
+		return
+	}
+
+	storage := make([]interface{}, len(MemgodbStorage))
+	copy(storage, MemgodbStorage)
+	MemgodbStorage = storage
+}
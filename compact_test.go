@@ -0,0 +1,29 @@
+package fscache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Compact(t *testing.T) {
+	ch := Cache{}
+
+	for i := 0; i < 2*compactMinCapacity; i++ {
+		_, err := ch.Memgodb().Collection("compaction").Insert(map[string]interface{}{"batch": "churn"}).One()
+		assert.NoError(t, err)
+	}
+
+	filter := map[string]interface{}{"batch": "churn"}
+	assert.NoError(t, ch.Memgodb().Collection("compaction").Delete(filter).All())
+
+	// deleting most of the records compacted the storage
+	memgodbMu.RLock()
+	assert.LessOrEqual(t, cap(MemgodbStorage), 2*len(MemgodbStorage)+compactMinCapacity)
+	memgodbMu.RUnlock()
+
+	ch.Memgodb().Compact()
+	memgodbMu.RLock()
+	assert.Equal(t, len(MemgodbStorage), cap(MemgodbStorage))
+	memgodbMu.RUnlock()
+}
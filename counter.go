@@ -0,0 +1,132 @@
+package fscache
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// errCounterNotFound counter not found
+	errCounterNotFound = errors.New("counter not found")
+	// errCounterExists counter already exists
+	errCounterExists = errors.New("counter already exist")
+)
+
+var (
+	// memgodbCounters are the materialized counters by name, guarded by memgodbMu
+	memgodbCounters map[string]*counter
+)
+
+// counter counts the records of a collection grouped by the value of a field
+type counter struct {
+	collectionName string
+	field          string
+	counts         map[string]int
+}
+
+// DefineCounter defines a counter of the records of collection grouped by the value of field, e.g. the count of
+// users per city. It is computed once from the records already stored, then kept up to date on every insert, update,
+// patch and delete instead of being recomputed by scanning. Records without the field are not counted.
+func (n *Memgodb) DefineCounter(name string, collection string, field string) (err error) {
+	defer recoverPanic(&err)
+
+	col := n.Collection(collection)
+
+	memgodbMu.Lock()
+	defer memgodbMu.Unlock()
+
+	if _, ok := memgodbCounters[name]; ok {
+		return errCounterExists
+	}
+
+	c := &counter{
+		collectionName: col.collectionName,
+		field:          field,
+		counts:         make(map[string]int),
+	}
+
+	objMaps, err := col.decodeMany(MemgodbStorage)
+	if err != nil {
+		return err
+	}
+	col.decrypt(objMaps)
+	for _, record := range objMaps {
+		c.count(record, 1)
+	}
+
+	if memgodbCounters == nil {
+		memgodbCounters = make(map[string]*counter)
+	}
+	memgodbCounters[name] = c
+
+	return nil
+}
+
+// Counter returns the counts of a counter defined with DefineCounter() by value of its field
+func (n *Memgodb) Counter(name string) (_ map[string]int, err error) {
+	defer recoverPanic(&err)
+
+	memgodbMu.RLock()
+	defer memgodbMu.RUnlock()
+
+	c, ok := memgodbCounters[name]
+	if !ok {
+		return nil, errCounterNotFound
+	}
+
+	counts := make(map[string]int, len(c.counts))
+	for value, count := range c.counts {
+		counts[value] = count
+	}
+
+	return counts, nil
+}
+
+// DropCounter removes a counter defined with DefineCounter()
+func (n *Memgodb) DropCounter(name string) (err error) {
+	defer recoverPanic(&err)
+
+	memgodbMu.Lock()
+	defer memgodbMu.Unlock()
+
+	if _, ok := memgodbCounters[name]; !ok {
+		return errCounterNotFound
+	}
+	delete(memgodbCounters, name)
+
+	return nil
+}
+
+// memgodbCount updates the counters for a record changing from previous to record, either being nil when the
+// record is inserted or deleted. The caller must hold memgodbMu.
+func memgodbCount(previous, record map[string]interface{}) {
+	for _, c := range memgodbCounters {
+		c.count(previous, -1)
+		c.count(record, 1)
+	}
+}
+
+// resetMemgodbCounters zeroes all the counters once the storage is emptied. The caller must hold memgodbMu.
+func resetMemgodbCounters() {
+	for _, c := range memgodbCounters {
+		c.counts = make(map[string]int)
+	}
+}
+
+// count adds delta to the count of the value of the field of record, if it belongs to the collection
+func (c *counter) count(record map[string]interface{}, delta int) {
+	if record == nil || record["colName"] != c.collectionName {
+		return
+	}
+
+	value, ok := record[c.field]
+	if !ok {
+		return
+	}
+
+	key := fmt.Sprint(value)
+	c.counts[key] += delta
+	if c.counts[key] <= 0 {
+		delete(c.counts, key)
+	}
+}
@@ -0,0 +1,67 @@
+package fscache
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_CounterEncryptedLoad(t *testing.T) {
+	ch := Cache{}
+	assert.NoError(t, ch.Memgodb().EncryptFields("sealed", []byte("0123456789abcdef0123456789abcdef"), "city"))
+	assert.NoError(t, ch.Memgodb().DefineCounter("sealed_by_city", "sealed", "city"))
+	defer ch.Memgodb().DropCounter("sealed_by_city")
+
+	_, err := ch.Memgodb().Collection("sealed").Insert(map[string]interface{}{"name": "john", "city": "Paris"}).One()
+	assert.NoError(t, err)
+	memgodbMu.RLock()
+	stored, err := json.Marshal(MemgodbStorage[len(MemgodbStorage)-1])
+	memgodbMu.RUnlock()
+	assert.NoError(t, err)
+
+	// the records loaded are counted by their plaintext, like the ones inserted
+	assert.NoError(t, ch.Memgodb().load(bytes.NewReader(stored)))
+	counts, err := ch.Memgodb().Counter("sealed_by_city")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"Paris": 2}, counts)
+}
+
+func Test_Counter(t *testing.T) {
+	ch := Cache{}
+
+	_, err := ch.Memgodb().Collection("counted").Insert(map[string]interface{}{"name": "john", "city": "Paris"}).One()
+	assert.NoError(t, err)
+
+	assert.NoError(t, ch.Memgodb().DefineCounter("counted_by_city", "counted", "city"))
+	defer ch.Memgodb().DropCounter("counted_by_city")
+	assert.Equal(t, errCounterExists, ch.Memgodb().DefineCounter("counted_by_city", "counted", "city"))
+
+	for _, user := range []map[string]interface{}{
+		{"name": "jane", "city": "Paris"},
+		{"name": "jack", "city": "Lagos"},
+		{"name": "jill", "city": "Lagos"},
+	} {
+		_, err := ch.Memgodb().Collection("counted").Insert(user).One()
+		assert.NoError(t, err)
+	}
+
+	counts, err := ch.Memgodb().Counter("counted_by_city")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"Paris": 2, "Lagos": 2}, counts)
+
+	assert.NoError(t, ch.Memgodb().Collection("counted").Patch(map[string]interface{}{"name": "jane"}, map[string]interface{}{"city": "Lyon"}))
+	assert.NoError(t, ch.Memgodb().Collection("counted").Delete(map[string]interface{}{"name": "jack"}).One())
+	_, err = ch.Memgodb().Collection("counted").Filter(map[string]interface{}{"name": "jack"}).First()
+	assert.Error(t, err)
+	_, err = ch.Memgodb().Collection("counted").Filter(map[string]interface{}{"name": "jill"}).First()
+	assert.NoError(t, err)
+
+	counts, err = ch.Memgodb().Counter("counted_by_city")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"Paris": 1, "Lagos": 1, "Lyon": 1}, counts)
+
+	_, err = ch.Memgodb().Counter("unknown")
+	assert.Equal(t, errCounterNotFound, err)
+}
@@ -0,0 +1,273 @@
+package fscache
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// errMergeType the values to merge are not of the same type
+	errMergeType = errors.New("cannot merge values of different types")
+)
+
+type (
+	// Mergeable is implemented by the CRDT value types, which merge deterministically with their replicas
+	// whatever the order the merges happen in
+	Mergeable interface {
+		// Merge merges other, a value of the same type, into the value
+		Merge(other Mergeable) error
+		// Clone returns a deep copy of the value
+		Clone() Mergeable
+	}
+
+	// GCounter object is a grow-only counter, each replica incrementing its own count
+	GCounter struct {
+		Counts map[string]uint64 `json:"counts"`
+	}
+
+	// PNCounter object is a counter which can be incremented and decremented, made of two GCounters
+	PNCounter struct {
+		Increments GCounter `json:"increments"`
+		Decrements GCounter `json:"decrements"`
+	}
+
+	// ORSet object is an observed-remove set of strings: an element removed and added concurrently is kept
+	ORSet struct {
+		// Adds are the unique tags of the additions of each element
+		Adds map[string]map[string]bool `json:"adds"`
+		// Removes are the tags of the additions observed by the removals of each element
+		Removes map[string]map[string]bool `json:"removes"`
+	}
+)
+
+// NewGCounter returns an empty GCounter
+func NewGCounter() *GCounter {
+	return &GCounter{Counts: make(map[string]uint64)}
+}
+
+// Increment adds n to the count of replica
+func (g *GCounter) Increment(replica string, n uint64) {
+	if g.Counts == nil {
+		g.Counts = make(map[string]uint64)
+	}
+	g.Counts[replica] += n
+}
+
+// Value returns the sum of the counts of all the replicas
+func (g *GCounter) Value() uint64 {
+	var value uint64
+	for _, count := range g.Counts {
+		value += count
+	}
+
+	return value
+}
+
+// Merge keeps the highest count of each replica
+func (g *GCounter) Merge(other Mergeable) error {
+	o, ok := other.(*GCounter)
+	if !ok {
+		return errMergeType
+	}
+
+	g.merge(o)
+	return nil
+}
+
+// merge keeps the highest count of each replica of g and o
+func (g *GCounter) merge(o *GCounter) {
+	if g.Counts == nil {
+		g.Counts = make(map[string]uint64)
+	}
+	for replica, count := range o.Counts {
+		if count > g.Counts[replica] {
+			g.Counts[replica] = count
+		}
+	}
+}
+
+// Clone returns a copy of the counter
+func (g *GCounter) Clone() Mergeable {
+	clone := NewGCounter()
+	clone.merge(g)
+
+	return clone
+}
+
+// NewPNCounter returns a PNCounter at zero
+func NewPNCounter() *PNCounter {
+	return &PNCounter{Increments: *NewGCounter(), Decrements: *NewGCounter()}
+}
+
+// Increment adds n, which may be negative, to the count of replica
+func (p *PNCounter) Increment(replica string, n int64) {
+	if n >= 0 {
+		p.Increments.Increment(replica, uint64(n))
+	} else {
+		p.Decrements.Increment(replica, uint64(-n))
+	}
+}
+
+// Value returns the increments minus the decrements of all the replicas
+func (p *PNCounter) Value() int64 {
+	return int64(p.Increments.Value() - p.Decrements.Value())
+}
+
+// Merge merges the increments and the decrements of other
+func (p *PNCounter) Merge(other Mergeable) error {
+	o, ok := other.(*PNCounter)
+	if !ok {
+		return errMergeType
+	}
+
+	p.Increments.merge(&o.Increments)
+	p.Decrements.merge(&o.Decrements)
+	return nil
+}
+
+// Clone returns a copy of the counter
+func (p *PNCounter) Clone() Mergeable {
+	clone := NewPNCounter()
+	clone.Merge(p)
+
+	return clone
+}
+
+// NewORSet returns an empty ORSet
+func NewORSet() *ORSet {
+	return &ORSet{
+		Adds:    make(map[string]map[string]bool),
+		Removes: make(map[string]map[string]bool),
+	}
+}
+
+// Add adds element on replica with a new unique tag
+func (s *ORSet) Add(replica, element string) {
+	s.init()
+	addTags(s.Adds, element, replica+":"+uuid.NewString())
+}
+
+// Remove removes element, discarding the additions observed so far
+func (s *ORSet) Remove(element string) {
+	s.init()
+	for tag := range s.Adds[element] {
+		addTags(s.Removes, element, tag)
+	}
+}
+
+// Contains reports whether element has an addition which was not removed
+func (s *ORSet) Contains(element string) bool {
+	for tag := range s.Adds[element] {
+		if !s.Removes[element][tag] {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Elements returns the elements of the set, sorted
+func (s *ORSet) Elements() []string {
+	elements := []string{}
+	for element := range s.Adds {
+		if s.Contains(element) {
+			elements = append(elements, element)
+		}
+	}
+	sort.Strings(elements)
+
+	return elements
+}
+
+// Merge keeps the additions and the removals of both sets
+func (s *ORSet) Merge(other Mergeable) error {
+	o, ok := other.(*ORSet)
+	if !ok {
+		return errMergeType
+	}
+
+	s.init()
+	for element, tags := range o.Adds {
+		for tag := range tags {
+			addTags(s.Adds, element, tag)
+		}
+	}
+	for element, tags := range o.Removes {
+		for tag := range tags {
+			addTags(s.Removes, element, tag)
+		}
+	}
+	return nil
+}
+
+// Clone returns a copy of the set
+func (s *ORSet) Clone() Mergeable {
+	clone := NewORSet()
+	clone.Merge(s)
+
+	return clone
+}
+
+// init makes the maps of a zero ORSet
+func (s *ORSet) init() {
+	if s.Adds == nil {
+		s.Adds = make(map[string]map[string]bool)
+	}
+	if s.Removes == nil {
+		s.Removes = make(map[string]map[string]bool)
+	}
+}
+
+// addTags adds tag to the tags of element
+func addTags(tags map[string]map[string]bool, element, tag string) {
+	if tags[element] == nil {
+		tags[element] = make(map[string]bool)
+	}
+	tags[element][tag] = true
+}
+
+// Merge() atomically merges value into the CRDT value of key, setting a copy of value if the key is not found,
+// and returns a copy of the merged value. The time to live of the key is kept.
+func (md *Memdis) Merge(key string, value Mergeable) (_ Mergeable, err error) {
+	defer recoverPanic(&err)
+
+	if err := md.chaos.inject(); err != nil {
+		return nil, err
+	}
+
+	key, digestOf, err := md.digestedKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	index, data, ok := md.lookup(key)
+	if !ok {
+		md.dropExpired(key)
+		if ok, err := md.room(1); !ok {
+			return value.Clone(), err
+		}
+
+		md.insert(key, MemdisData{Value: value.Clone(), digestOf: digestOf})
+		return value.Clone(), nil
+	}
+
+	current, ok := data.Value.(Mergeable)
+	if !ok {
+		return nil, errMergeType
+	}
+
+	// the stored value is replaced rather than mutated, so the copies returned before stay unchanged
+	merged := current.Clone()
+	if err := merged.Merge(value); err != nil {
+		return nil, err
+	}
+	data.Value = merged
+	md.replace(index, key, data)
+
+	return merged.Clone(), nil
+}
@@ -0,0 +1,64 @@
+package fscache
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCRDT(t *testing.T) {
+	// replicas converge whatever the order of the merges
+	a, b := NewPNCounter(), NewPNCounter()
+	a.Increment("a", 5)
+	b.Increment("b", 3)
+	b.Increment("b", -1)
+	ab, ba := a.Clone(), b.Clone()
+	assert.NoError(t, ab.Merge(b))
+	assert.NoError(t, ba.Merge(a))
+	assert.Equal(t, ab, ba)
+	assert.EqualValues(t, 7, ab.(*PNCounter).Value())
+
+	// merging is idempotent
+	assert.NoError(t, ab.Merge(b))
+	assert.EqualValues(t, 7, ab.(*PNCounter).Value())
+
+	g := NewGCounter()
+	g.Increment("a", 2)
+	assert.Equal(t, errMergeType, g.Merge(a))
+
+	// an element removed and added concurrently is kept
+	s1 := NewORSet()
+	s1.Add("a", "apple")
+	s1.Add("a", "pear")
+	s2 := s1.Clone().(*ORSet)
+	s1.Remove("apple")
+	s2.Add("b", "apple")
+	s2.Remove("pear")
+	assert.NoError(t, s1.Merge(s2))
+	assert.Equal(t, []string{"apple"}, s1.Elements())
+	assert.False(t, s1.Contains("pear"))
+
+	ch := Cache{}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			counter := NewGCounter()
+			counter.Increment(fmt.Sprintf("replica%d", i%5), uint64(i%5+1))
+			_, err := ch.Memdis().Merge("visits", counter)
+			assert.NoError(t, err)
+		}(i)
+	}
+	wg.Wait()
+
+	merged, err := ch.Memdis().Merge("visits", NewGCounter())
+	assert.NoError(t, err)
+	assert.EqualValues(t, 15, merged.(*GCounter).Value())
+
+	assert.NoError(t, ch.Memdis().Set("plain", 1))
+	_, err = ch.Memdis().Merge("plain", NewGCounter())
+	assert.Equal(t, errMergeType, err)
+}
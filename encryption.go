@@ -0,0 +1,143 @@
+package fscache
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// encryptedPrefix marks the encrypted values of a record
+const encryptedPrefix = "enc:"
+
+var (
+	// errInvalidCiphertext the encrypted value is invalid or was encrypted with another key
+	errInvalidCiphertext = errors.New("invalid encrypted value")
+)
+
+// fieldEncryption encrypts the fields of the records of a collection
+type fieldEncryption struct {
+	aead   cipher.AEAD
+	fields []string
+}
+
+// EncryptFields encrypts the given fields of the records of collection with key before they are stored, so they are
+// never held in memory nor persisted in plaintext. They are decrypted when the records are read with Filter(), and
+// filtering on them keeps working. key must be 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256.
+// Only the records inserted or updated afterwards are encrypted.
+func (n *Memgodb) EncryptFields(collection string, key []byte, fields ...string) (err error) {
+	defer recoverPanic(&err)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+
+	memgodbMu.Lock()
+	defer memgodbMu.Unlock()
+
+	// the collections already created keep using the encryptions they were created with
+	encryptions := make(map[string]*fieldEncryption, len(n.encryptions)+1)
+	for col, encryption := range n.encryptions {
+		encryptions[col] = encryption
+	}
+	encryptions[collectionName(collection)] = &fieldEncryption{
+		aead:   aead,
+		fields: fields,
+	}
+	n.encryptions = encryptions
+
+	return nil
+}
+
+// encrypted returns a copy of record with the fields of its collection encrypted
+func (c *Collection) encrypted(record map[string]interface{}) (map[string]interface{}, error) {
+	encryption, ok := c.encryptions[c.collectionName]
+	if !ok {
+		return record, nil
+	}
+
+	encrypted := make(map[string]interface{}, len(record))
+	for key, value := range record {
+		encrypted[key] = value
+	}
+
+	for _, field := range encryption.fields {
+		value, ok := record[field]
+		if !ok {
+			continue
+		}
+
+		plaintext, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+
+		nonce := make([]byte, encryption.aead.NonceSize())
+		if _, err := rand.Read(nonce); err != nil {
+			return nil, err
+		}
+
+		ciphertext := encryption.aead.Seal(nonce, nonce, plaintext, nil)
+		encrypted[field] = encryptedPrefix + base64.StdEncoding.EncodeToString(ciphertext)
+	}
+
+	return encrypted, nil
+}
+
+// decrypt decrypts in place the encrypted fields of records, whatever their collection.
+// Values which cannot be decrypted are left encrypted.
+func (c *Collection) decrypt(records []map[string]interface{}) {
+	for _, record := range records {
+		colName, _ := record["colName"].(string)
+		encryption, ok := c.encryptions[colName]
+		if !ok {
+			continue
+		}
+
+		for _, field := range encryption.fields {
+			value, err := encryption.decrypt(record[field])
+			if err != nil {
+				if debug {
+					c.logger.Error().Msgf("error decrypting field %s: %v", field, err)
+				}
+				continue
+			}
+			record[field] = value
+		}
+	}
+}
+
+// decrypt returns the plaintext of an encrypted value
+func (e *fieldEncryption) decrypt(value interface{}) (interface{}, error) {
+	encoded, ok := value.(string)
+	if !ok || !strings.HasPrefix(encoded, encryptedPrefix) {
+		return value, nil
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(encoded, encryptedPrefix))
+	if err != nil || len(ciphertext) < e.aead.NonceSize() {
+		return nil, errInvalidCiphertext
+	}
+
+	nonce, ciphertext := ciphertext[:e.aead.NonceSize()], ciphertext[e.aead.NonceSize():]
+	plaintext, err := e.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, errInvalidCiphertext
+	}
+
+	var decrypted interface{}
+	if err := json.Unmarshal(plaintext, &decrypted); err != nil {
+		return nil, err
+	}
+
+	return decrypted, nil
+}
@@ -0,0 +1,30 @@
+package fscache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_EncryptFields(t *testing.T) {
+	ch := Cache{}
+	assert.Error(t, ch.Memgodb().EncryptFields("secret", []byte("short"), "email"))
+	assert.NoError(t, ch.Memgodb().EncryptFields("secret", []byte("0123456789abcdef0123456789abcdef"), "email"))
+
+	res, err := ch.Memgodb().Collection("secret").Insert(map[string]interface{}{"name": "john", "email": "john@doe.com"}).One()
+	assert.NoError(t, err)
+	assert.Equal(t, "john@doe.com", res.(map[string]interface{})["email"])
+
+	// the field is never stored in plaintext
+	memgodbMu.RLock()
+	stored := MemgodbStorage[len(MemgodbStorage)-1].(map[string]interface{})
+	memgodbMu.RUnlock()
+	assert.Equal(t, "john", stored["name"])
+	assert.NotEqual(t, "john@doe.com", stored["email"])
+
+	// it is decrypted on read, and can be filtered on
+	record, err := ch.Memgodb().Collection("secret").Filter(map[string]interface{}{"email": "john@doe.com"}).First()
+	assert.NoError(t, err)
+	assert.Equal(t, "john", record["name"])
+	assert.Equal(t, "john@doe.com", record["email"])
+}
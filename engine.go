@@ -0,0 +1,35 @@
+package fscache
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// EngineGreedyDual is the default engine, evicting the datas with the lowest GreedyDual priority first
+	EngineGreedyDual = "greedydual"
+	// EngineTinyLFU is the experimental engine adding a TinyLFU admission policy, backed by a count-min sketch,
+	// in front of the GreedyDual eviction, same as WithAdmission()
+	EngineTinyLFU = "tinylfu"
+)
+
+var (
+	// errUnknownEngine the engine is not one of EngineGreedyDual and EngineTinyLFU
+	errUnknownEngine = errors.New("unknown engine")
+)
+
+// WithEngine picks the eviction engine of the WithMaxCost budget by name, EngineGreedyDual by default.
+// Compare them against your workload with the BenchmarkEngines benchmark, or with Simulate().
+// An unknown engine leaves the engine unchanged, and its error is returned by Err().
+func WithEngine(engine string) Option {
+	return func(c *Cache) {
+		switch engine {
+		case EngineGreedyDual:
+			c.MemdisInstance.admission = nil
+		case EngineTinyLFU:
+			WithAdmission()(c)
+		default:
+			c.fail(fmt.Errorf("%w %q", errUnknownEngine, engine))
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package fscache
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithEngine(t *testing.T) {
+	ch := Cache{}
+	WithEngine(EngineTinyLFU)(&ch)
+	assert.NotNil(t, ch.MemdisInstance.admission)
+
+	WithEngine(EngineGreedyDual)(&ch)
+	assert.Nil(t, ch.MemdisInstance.admission)
+
+	assert.NoError(t, ch.Err())
+
+	WithEngine(EngineTinyLFU)(&ch)
+	assert.NotPanics(t, func() { WithEngine("unknown")(&ch) })
+	assert.ErrorIs(t, ch.Err(), errUnknownEngine)
+	assert.NotNil(t, ch.MemdisInstance.admission)
+}
+
+// BenchmarkEngines compares the hit ratio and speed of the engines on a skewed workload polluted by scans,
+// reading the datas and setting them on misses like a cache-aside application
+func BenchmarkEngines(b *testing.B) {
+	for _, engine := range []string{EngineGreedyDual, EngineTinyLFU} {
+		b.Run(engine, func(b *testing.B) {
+			ch := Cache{}
+			WithMaxCost(1000)(&ch)
+			WithEngine(engine)(&ch)
+
+			r := rand.New(rand.NewSource(1))
+			zipf := rand.NewZipf(r, 1.1, 1, 100000)
+			keys := make([]string, b.N)
+			for i := range keys {
+				if i%10 == 0 {
+					// one-hit wonders of a scan
+					keys[i] = fmt.Sprintf("scan%d", i)
+				} else {
+					keys[i] = fmt.Sprintf("key%d", zipf.Uint64())
+				}
+			}
+
+			var hits int
+			b.ResetTimer()
+			for _, key := range keys {
+				if _, err := ch.Memdis().Get(key); err == nil {
+					hits++
+				} else {
+					ch.Memdis().Set(key, key)
+				}
+			}
+			b.ReportMetric(float64(hits)/float64(b.N), "hits/op")
+		})
+	}
+}
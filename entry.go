@@ -0,0 +1,51 @@
+package fscache
+
+import "time"
+
+// Entry object is an immutable copy of a data and its metadata
+type Entry struct {
+	// Key is the key of the data
+	Key string
+	// Value is the value of the data
+	Value interface{}
+	// ExpiresAt is the time the data expires at, the zero time means it never expires
+	ExpiresAt time.Time
+	// CreatedAt is the time the data was first set
+	CreatedAt time.Time
+	// Hits is the number of times the data was read
+	Hits int64
+}
+
+// GetEntry() retrieves a data along with its metadata from the in-memmory storage.
+// Reading the entry doesn't count as a hit.
+func (md *Memdis) GetEntry(key string) (_ Entry, err error) {
+	defer recoverPanic(&err)
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	// the hits of the reads not applied yet are counted
+	md.flushReads()
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
+	_, data, ok := md.lookup(key)
+	if !ok {
+		return Entry{}, errKeyNotFound
+	}
+
+	return md.entryOf(key, data), nil
+}
+
+// entryOf returns the entry of a data. The caller must hold md.state().mu.
+func (md *Memdis) entryOf(key string, data MemdisData) Entry {
+	return Entry{
+		Key:       md.originalKey(key),
+		Value:     data.Value,
+		ExpiresAt: data.Duration,
+		CreatedAt: data.createdAt,
+		Hits:      data.hits,
+	}
+}
@@ -0,0 +1,36 @@
+package fscache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetEntry(t *testing.T) {
+	ch := Cache{}
+
+	before := time.Now()
+	assert.NoError(t, ch.Memdis().Set("entry1", "value1", time.Minute))
+	for i := 0; i < 3; i++ {
+		_, err := ch.Memdis().Get("entry1")
+		assert.NoError(t, err)
+	}
+
+	entry, err := ch.Memdis().GetEntry("entry1")
+	assert.NoError(t, err)
+	assert.Equal(t, "entry1", entry.Key)
+	assert.EqualValues(t, "value1", entry.Value)
+	assert.EqualValues(t, 3, entry.Hits)
+	assert.WithinDuration(t, before, entry.CreatedAt, time.Second)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), entry.ExpiresAt, time.Second)
+
+	// overwriting the data keeps when it was created
+	assert.NoError(t, ch.Memdis().OverWrite("entry1", "value2"))
+	overwritten, err := ch.Memdis().GetEntry("entry1")
+	assert.NoError(t, err)
+	assert.Equal(t, entry.CreatedAt, overwritten.CreatedAt)
+
+	_, err = ch.Memdis().GetEntry("unknown")
+	assert.Equal(t, errKeyNotFound, err)
+}
@@ -0,0 +1,162 @@
+package fscache
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// eventQueueSize is the number of KeyEvents queued for a receiver of Events() which falls behind
+const eventQueueSize = 4096
+
+// KeyEventType is the type of a KeyEvent
+type KeyEventType int
+
+const (
+	// KeySet is sent when a key which was not set is set
+	KeySet KeyEventType = iota
+	// KeyOverwrite is sent when the data of a key is replaced, its value or its time to live
+	KeyOverwrite
+	// KeyDelete is sent when a key is deleted, by Del() and the other deletions but Clear()
+	KeyDelete
+	// KeyExpire is sent when an expired key is removed
+	KeyExpire
+	// KeyEvict is sent when a key is evicted to fit in the limits of Memdis or under memory pressure
+	KeyEvict
+	// KeyClear is sent once by Clear(), with an empty Key, for all the keys it deleted
+	KeyClear
+)
+
+type (
+	// KeyEvent object is a change of a key of Memdis
+	KeyEvent struct {
+		Type KeyEventType
+		Key  string
+		// Time is the time the change happened at
+		Time time.Time
+	}
+
+	// keyEvents queues the KeyEvents until they are delivered
+	keyEvents struct {
+		events chan KeyEvent
+		// queue holds the events not delivered yet, so writes never wait for the receiver
+		queue   []KeyEvent
+		queueMu sync.Mutex
+		wake    chan struct{}
+		// done stops the delivery
+		done chan struct{}
+		// dropped counts the events dropped because the queue was full
+		dropped *atomic.Uint64
+	}
+)
+
+// String returns the name of the event type
+func (t KeyEventType) String() string {
+	switch t {
+	case KeySet:
+		return "set"
+	case KeyOverwrite:
+		return "overwrite"
+	case KeyDelete:
+		return "delete"
+	case KeyExpire:
+		return "expire"
+	case KeyEvict:
+		return "evict"
+	case KeyClear:
+		return "clear"
+	}
+
+	return "unknown"
+}
+
+// Events() returns the channel the changes of the keys are sent on, in the order they happened, so other components
+// can react to them without polling. The events are only recorded once Events() has been called, and every call
+// returns the same channel. Up to 4096 events are queued until they are received, so writes never wait for the
+// receiver: the events happening while the queue is full are dropped and counted by Stats().DroppedEvents.
+// Clear() sends a single KeyClear event rather than one per key, and BulkLoad() sends no events. The channel is
+// closed by Cache.Close().
+func (md *Memdis) Events() <-chan KeyEvent {
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	if md.events == nil {
+		md.events = &keyEvents{
+			events:  make(chan KeyEvent),
+			wake:    make(chan struct{}, 1),
+			done:    make(chan struct{}),
+			dropped: &md.state().droppedEvents,
+		}
+		go md.events.deliver()
+	}
+
+	return md.events.events
+}
+
+// closeEvents stops delivering the events and closes the channel of Events(), a later call returning a new one
+func (md *Memdis) closeEvents() {
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	if md.events != nil {
+		close(md.events.done)
+		md.events = nil
+	}
+}
+
+// notify records an event of type t for key. It does nothing until Events() is called, nor while the events are
+// silenced. The caller must hold md.state().mu.
+func (md *Memdis) notify(t KeyEventType, key string) {
+	if md.events == nil || md.silenced {
+		return
+	}
+
+	md.events.push(KeyEvent{Type: t, Key: md.originalKey(key), Time: time.Now()})
+}
+
+// push queues an event to be delivered, or drops it if the queue is full
+func (e *keyEvents) push(event KeyEvent) {
+	e.queueMu.Lock()
+	if len(e.queue) >= eventQueueSize {
+		e.queueMu.Unlock()
+		e.dropped.Add(1)
+		return
+	}
+	e.queue = append(e.queue, event)
+	e.queueMu.Unlock()
+
+	select {
+	case e.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliver sends the queued events until the delivery is stopped, then closes the channel
+func (e *keyEvents) deliver() {
+	defer close(e.events)
+
+	for {
+		select {
+		case <-e.wake:
+		case <-e.done:
+			return
+		}
+
+		for {
+			e.queueMu.Lock()
+			if len(e.queue) == 0 {
+				e.queueMu.Unlock()
+				break
+			}
+			event := e.queue[0]
+			e.queue = e.queue[1:]
+			e.queueMu.Unlock()
+
+			select {
+			case e.events <- event:
+			case <-e.done:
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package fscache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvents(t *testing.T) {
+	ch := Cache{}
+	WithMaxEntries(2)(&ch)
+
+	// the events happening before Events() is called are not recorded
+	assert.NoError(t, ch.Memdis().Set("before", 0))
+	events := ch.Memdis().Events()
+	assert.Equal(t, events, ch.Memdis().Events())
+
+	assert.NoError(t, ch.Memdis().Set("a", 1))
+	assert.NoError(t, ch.Memdis().OverWrite("a", 2))
+	assert.NoError(t, ch.Memdis().Set("b", 1, time.Nanosecond))
+	time.Sleep(time.Millisecond)
+	ch.Memdis().expire()
+	assert.NoError(t, ch.Memdis().Set("c", 1))
+	assert.NoError(t, ch.Memdis().Set("d", 1))
+	assert.NoError(t, ch.Memdis().Del("c"))
+
+	expected := []KeyEvent{
+		{Type: KeySet, Key: "a"},
+		{Type: KeyOverwrite, Key: "a"},
+		{Type: KeySet, Key: "b"},
+		{Type: KeyEvict, Key: "before"},
+		{Type: KeyExpire, Key: "b"},
+		{Type: KeySet, Key: "c"},
+		{Type: KeySet, Key: "d"},
+		{Type: KeyEvict, Key: "a"},
+		{Type: KeyDelete, Key: "c"},
+	}
+	for _, want := range expected {
+		select {
+		case event := <-events:
+			assert.Equal(t, want.Type, event.Type, want.Key)
+			assert.Equal(t, want.Key, event.Key)
+			assert.False(t, event.Time.IsZero())
+		case <-time.After(time.Second):
+			t.Fatalf("no %s event for %s", want.Type, want.Key)
+		}
+	}
+
+	// Clear() sends a single event and BulkLoad() none
+	assert.NoError(t, ch.Memdis().Clear())
+	assert.NoError(t, ch.Memdis().BulkLoad(func(bl *BulkLoader) error {
+		return bl.Set("bulk", 1)
+	}))
+	assert.NoError(t, ch.Memdis().Set("after", 1))
+	for _, want := range []KeyEvent{{Type: KeyClear}, {Type: KeySet, Key: "after"}} {
+		select {
+		case event := <-events:
+			assert.Equal(t, want, KeyEvent{Type: event.Type, Key: event.Key})
+		case <-time.After(time.Second):
+			t.Fatalf("no %s event for %q", want.Type, want.Key)
+		}
+	}
+
+	// Close() stops the delivery
+	assert.NoError(t, ch.Close())
+	select {
+	case _, ok := <-events:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("events not closed")
+	}
+}
+
+func TestEventsQueue(t *testing.T) {
+	ch := Cache{}
+	events := ch.Memdis().Events()
+	defer ch.Close()
+
+	// the events of a receiver falling behind are dropped once the queue is full
+	for i := 0; i < 2*eventQueueSize; i++ {
+		assert.NoError(t, ch.Memdis().OverWriteOrSet("key", i))
+	}
+	dropped := ch.Memdis().Stats().DroppedEvents
+	assert.Greater(t, dropped, uint64(0))
+
+	received := 0
+	for received+int(dropped) < 2*eventQueueSize {
+		select {
+		case <-events:
+			received++
+		case <-time.After(time.Second):
+			t.Fatalf("received %d events, dropped %d", received, dropped)
+		}
+	}
+	assert.LessOrEqual(t, received, eventQueueSize+1)
+}
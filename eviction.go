@@ -0,0 +1,212 @@
+package fscache
+
+import "time"
+
+// WithMaxCost bounds the total cost of the datas held by Memdis. Datas set without SetWithCost() cost 1.
+// When the budget is exceeded, datas are evicted using the GreedyDual policy: the data with the lowest
+// cost is evicted first, and datas which are not accessed age out over time whatever their cost.
+func WithMaxCost(maxCost int64) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.maxCost = maxCost
+	}
+}
+
+// WithMaxEntries bounds the number of datas held by Memdis. When the limit is exceeded, the least recently used
+// datas, read or written the longest time ago, are evicted first. The datas of a mapped snapshot don't count.
+func WithMaxEntries(maxEntries int) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.maxEntries = maxEntries
+	}
+}
+
+// SetWithCost() adds a new data into the in-memmory storage with an explicit cost, e.g. its size in bytes
+// or the time it took to compute. Costly datas are retained longer when the WithMaxCost budget is exceeded.
+func (md *Memdis) SetWithCost(key string, value interface{}, cost int64, duration ...time.Duration) (err error) {
+	defer recoverPanic(&err)
+
+	if err := md.chaos.inject(); err != nil {
+		return err
+	}
+
+	key, digestOf, err := md.digestedKey(key)
+	if err != nil {
+		return err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	if _, _, ok := md.lookup(key); ok {
+		return errKeyExists
+	}
+
+	if ok, err := md.room(costOf(MemdisData{cost: cost})); !ok {
+		return err
+	}
+
+	ttl := md.ttlOf(duration)
+
+	md.insert(key, MemdisData{
+		Value:    value,
+		Duration: expiresAt(ttl),
+		TTL:      ttl,
+		cost:     cost,
+		digestOf: digestOf,
+	})
+
+	return nil
+}
+
+// costOf returns the cost of a data
+func costOf(data MemdisData) int64 {
+	if data.cost <= 0 {
+		return 1
+	}
+
+	return data.cost
+}
+
+// hit records an access to a data: it counts the hit and restores the priority of the data.
+// The caller must hold md.state().mu.
+func (md *Memdis) hit(index int, key string) {
+	// the datas of the mapped snapshot are read only
+	md.state().hits.Add(1)
+	if index == mappedIndex {
+		return
+	}
+
+	md.touch(key)
+}
+
+// touch records a read of the stored data of key: it updates its recency and restores its priority.
+// The caller must hold md.state().mu.
+func (md *Memdis) touch(key string) {
+	data := md.storage[key]
+	data.hits++
+	md.clock++
+	data.accessed = md.clock
+
+	if md.maxCost > 0 {
+		md.recordAccess(key)
+		data.priority = md.inflation + float64(costOf(data))
+	}
+
+	md.storage[key] = data
+	md.track(key, data)
+}
+
+// victim returns the data to be evicted next. The caller must hold md.state().mu.
+func (md *Memdis) victim() (int, string, MemdisData) {
+	// the order is built on the first eviction, and again if the storage was filled without it
+	if md.priorities == nil || md.priorities.Len() != len(md.storage) {
+		md.priorities = newPriorityOrder(md.storage)
+	}
+
+	key, ok := md.priorities.first()
+	if !ok {
+		return -1, "", MemdisData{}
+	}
+
+	return storedIndex, key, md.storage[key]
+}
+
+// evict takes datas off the storage until the total cost fits in the budget, the number of datas in the
+// WithMaxEntries limit, and their estimated size in the WithMaxMemory budget. The caller must hold md.state().mu.
+func (md *Memdis) evict() {
+	// the reads not applied yet count towards the recency of the datas
+	md.applyReads()
+
+	for md.maxCost > 0 && md.totalCost > md.maxCost && len(md.storage) > 0 {
+		victimIndex, victimKey, victim := md.victim()
+
+		// the datas left age relatively to the evicted one
+		md.inflation = victim.priority
+		md.evicted(victimIndex, victimKey)
+	}
+
+	for md.maxEntries > 0 && len(md.storage) > md.maxEntries {
+		md.evicted(md.leastRecentlyUsed())
+	}
+
+	for md.maxMemory > 0 && md.totalSize > md.maxMemory && len(md.storage) > 0 {
+		// the policy of WithMaxEntries is used when it is set, GreedyDual otherwise
+		if md.maxEntries > 0 {
+			md.evicted(md.leastRecentlyUsed())
+			continue
+		}
+
+		victimIndex, victimKey, victim := md.victim()
+		md.inflation = victim.priority
+		md.evicted(victimIndex, victimKey)
+	}
+}
+
+// evicted removes the evicted data of key stored at index. The caller must hold md.state().mu.
+func (md *Memdis) evicted(index int, key string) {
+	md.remove(index, key)
+	md.state().evictions.Add(1)
+	md.notify(KeyEvict, key)
+	md.forgetDigest(key)
+
+	if debug {
+		md.logger.Info().Msgf("data object [%v] got evicted", md.loggedKey(key))
+	}
+}
+
+// leastRecentlyUsed returns the data with the lowest rank which was read or written the longest time ago.
+// The caller must hold md.state().mu.
+func (md *Memdis) leastRecentlyUsed() (int, string) {
+	// the order is built on the first eviction, and again if the storage was filled without it
+	if md.recency == nil || len(md.recency.elements) != len(md.storage) {
+		md.recency = newRecencyOrder(md.storage)
+	}
+
+	key, _ := md.recency.oldest()
+
+	return storedIndex, key
+}
+
+// evictedBefore reports whether a must be evicted before b: lowest rank first, then lowest priority, then oldest
+func evictedBefore(a, b MemdisData) bool {
+	return priorityEntryOf("", a).before(priorityEntryOf("", b))
+}
+
+// WithAdmission enables a TinyLFU admission filter in front of the WithMaxCost budget: a new data which would
+// require an eviction is only admitted if it has been accessed more often than the data it would evict.
+// Datas which are not admitted are dropped silently, so one-hit wonders can't flush frequently used datas.
+func WithAdmission() Option {
+	return func(c *Cache) {
+		c.MemdisInstance.admission = newFrequencySketch(1024)
+	}
+}
+
+// recordAccess records an access to key in the admission filter. The caller must hold md.state().mu.
+func (md *Memdis) recordAccess(key string) {
+	if md.admission != nil {
+		md.admission.increment(key)
+	}
+}
+
+// admit reports whether a new data can be added to the storage. The caller must hold md.state().mu.
+func (md *Memdis) admit(key string, data MemdisData) bool {
+	if md.admission == nil || md.maxCost <= 0 {
+		return true
+	}
+
+	md.applyReads()
+	md.recordAccess(key)
+	if md.totalCost+costOf(data) <= md.maxCost || len(md.storage) == 0 {
+		return true
+	}
+
+	_, victimKey, _ := md.victim()
+	if md.admission.estimate(key) > md.admission.estimate(victimKey) {
+		return true
+	}
+
+	if debug {
+		md.logger.Info().Msgf("data object [%v] was not admitted", md.loggedKey(key))
+	}
+
+	return false
+}
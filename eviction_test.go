@@ -0,0 +1,50 @@
+package fscache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetWithCost(t *testing.T) {
+	ch := Cache{}
+	WithMaxCost(10)(&ch)
+
+	assert.NoError(t, ch.Memdis().SetWithCost("cheap", "value", 2))
+	assert.NoError(t, ch.Memdis().SetWithCost("costly", "value", 6))
+	// exceeds the budget, the cheapest data is evicted first
+	assert.NoError(t, ch.Memdis().SetWithCost("medium", "value", 4))
+
+	_, err := ch.Memdis().Get("cheap")
+	assert.Equal(t, errKeyNotFound, err)
+	assert.ElementsMatch(t, []string{"costly", "medium"}, ch.Memdis().Keys())
+	assert.EqualValues(t, 10, ch.MemdisInstance.totalCost)
+}
+
+func TestWithMaxEntries(t *testing.T) {
+	ch := Cache{}
+	WithMaxEntries(3)(&ch)
+
+	for _, key := range []string{"a", "b", "c"} {
+		assert.NoError(t, ch.Memdis().Set(key, key))
+	}
+
+	// reads and writes make the datas recently used
+	_, err := ch.Memdis().Get("a")
+	assert.NoError(t, err)
+	assert.NoError(t, ch.Memdis().OverWrite("b", "b2"))
+
+	assert.NoError(t, ch.Memdis().Set("d", "d"))
+	assert.ElementsMatch(t, []string{"a", "b", "d"}, ch.Memdis().Keys())
+
+	assert.NoError(t, ch.Memdis().Set("e", "e"))
+	assert.ElementsMatch(t, []string{"b", "d", "e"}, ch.Memdis().Keys())
+	assert.EqualValues(t, 2, ch.Stats().Memdis.Evictions)
+
+	rejecting := Cache{}
+	WithMaxEntries(1)(&rejecting)
+	WithFullPolicy(FullReject)(&rejecting)
+	assert.NoError(t, rejecting.Memdis().Set("a", "a"))
+	assert.ErrorIs(t, rejecting.Memdis().Set("b", "b"), ErrStoreFull)
+	assert.NoError(t, rejecting.Memdis().OverWrite("a", "a2"))
+}
@@ -0,0 +1,218 @@
+package fscache
+
+import (
+	"container/heap"
+	"container/list"
+	"sort"
+)
+
+type (
+	// recencyOrder lists the datas of each rank from the least to the most recently used, so the WithMaxEntries
+	// evictions find their victim without scanning the storage
+	recencyOrder struct {
+		ranks    map[int]*list.List
+		elements map[string]*list.Element
+	}
+
+	// recencyEntry is the element of a data in its recencyOrder list
+	recencyEntry struct {
+		key  string
+		rank int
+	}
+
+	// priorityOrder is a min-heap of the datas in the order evictedBefore() evicts them, so the GreedyDual evictions
+	// find their victim without scanning the storage
+	priorityOrder struct {
+		entries   []priorityEntry
+		positions map[string]int
+	}
+
+	// priorityEntry is the position of a data in a priorityOrder
+	priorityEntry struct {
+		key      string
+		rank     int
+		priority float64
+		sequence uint64
+	}
+)
+
+// newRecencyOrder returns the recencyOrder of the datas of storage
+func newRecencyOrder(storage map[string]MemdisData) *recencyOrder {
+	keys := make([]string, 0, len(storage))
+	for key := range storage {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return storage[keys[i]].accessed < storage[keys[j]].accessed
+	})
+
+	order := &recencyOrder{
+		ranks:    make(map[int]*list.List),
+		elements: make(map[string]*list.Element, len(storage)),
+	}
+	for _, key := range keys {
+		order.touch(key, storage[key].rank)
+	}
+
+	return order
+}
+
+// touch makes key of rank the most recently used data of its rank
+func (o *recencyOrder) touch(key string, rank int) {
+	if element, ok := o.elements[key]; ok {
+		if element.Value.(recencyEntry).rank == rank {
+			o.ranks[rank].MoveToBack(element)
+			return
+		}
+		o.remove(key)
+	}
+
+	datas, ok := o.ranks[rank]
+	if !ok {
+		datas = list.New()
+		o.ranks[rank] = datas
+	}
+	o.elements[key] = datas.PushBack(recencyEntry{key: key, rank: rank})
+}
+
+// remove takes key off the order
+func (o *recencyOrder) remove(key string) {
+	element, ok := o.elements[key]
+	if !ok {
+		return
+	}
+
+	rank := element.Value.(recencyEntry).rank
+	o.ranks[rank].Remove(element)
+	if o.ranks[rank].Len() == 0 {
+		delete(o.ranks, rank)
+	}
+	delete(o.elements, key)
+}
+
+// oldest returns the least recently used data of the lowest rank
+func (o *recencyOrder) oldest() (string, bool) {
+	var datas *list.List
+	lowest := 0
+	for rank, ranked := range o.ranks {
+		if datas == nil || rank < lowest {
+			datas, lowest = ranked, rank
+		}
+	}
+	if datas == nil {
+		return "", false
+	}
+
+	return datas.Front().Value.(recencyEntry).key, true
+}
+
+// newPriorityOrder returns the priorityOrder of the datas of storage
+func newPriorityOrder(storage map[string]MemdisData) *priorityOrder {
+	order := &priorityOrder{
+		entries:   make([]priorityEntry, 0, len(storage)),
+		positions: make(map[string]int, len(storage)),
+	}
+	for key, data := range storage {
+		order.positions[key] = len(order.entries)
+		order.entries = append(order.entries, priorityEntryOf(key, data))
+	}
+	heap.Init(order)
+
+	return order
+}
+
+// priorityEntryOf returns the priorityEntry of the data of key
+func priorityEntryOf(key string, data MemdisData) priorityEntry {
+	return priorityEntry{key: key, rank: data.rank, priority: data.priority, sequence: data.sequence}
+}
+
+// before reports whether e must be evicted before other: lowest rank first, then lowest priority, then oldest
+func (e priorityEntry) before(other priorityEntry) bool {
+	if e.rank != other.rank {
+		return e.rank < other.rank
+	}
+	if e.priority != other.priority {
+		return e.priority < other.priority
+	}
+	return e.sequence < other.sequence
+}
+
+// set adds the data of key to the order, or moves it to its new position
+func (o *priorityOrder) set(key string, data MemdisData) {
+	if position, ok := o.positions[key]; ok {
+		o.entries[position] = priorityEntryOf(key, data)
+		heap.Fix(o, position)
+		return
+	}
+
+	heap.Push(o, priorityEntryOf(key, data))
+}
+
+// remove takes key off the order
+func (o *priorityOrder) remove(key string) {
+	if position, ok := o.positions[key]; ok {
+		heap.Remove(o, position)
+	}
+}
+
+// first returns the data to be evicted first
+func (o *priorityOrder) first() (string, bool) {
+	if len(o.entries) == 0 {
+		return "", false
+	}
+
+	return o.entries[0].key, true
+}
+
+// Len implements heap.Interface
+func (o *priorityOrder) Len() int {
+	return len(o.entries)
+}
+
+// Less implements heap.Interface
+func (o *priorityOrder) Less(i, j int) bool {
+	return o.entries[i].before(o.entries[j])
+}
+
+// Swap implements heap.Interface
+func (o *priorityOrder) Swap(i, j int) {
+	o.entries[i], o.entries[j] = o.entries[j], o.entries[i]
+	o.positions[o.entries[i].key] = i
+	o.positions[o.entries[j].key] = j
+}
+
+// Push implements heap.Interface
+func (o *priorityOrder) Push(entry interface{}) {
+	o.positions[entry.(priorityEntry).key] = len(o.entries)
+	o.entries = append(o.entries, entry.(priorityEntry))
+}
+
+// Pop implements heap.Interface
+func (o *priorityOrder) Pop() interface{} {
+	last := o.entries[len(o.entries)-1]
+	o.entries = o.entries[:len(o.entries)-1]
+	delete(o.positions, last.key)
+
+	return last
+}
+
+// track records the new position of the data of key in the eviction orders which are in use.
+// The caller must hold md.state().mu.
+func (md *Memdis) track(key string, data MemdisData) {
+	if md.recency != nil {
+		md.recency.touch(key, data.rank)
+	}
+	if md.priorities != nil {
+		md.priorities.set(key, data)
+	}
+}
+
+// untrack takes key off the eviction orders which are in use. The caller must hold md.state().mu.
+func (md *Memdis) untrack(key string) {
+	if md.recency != nil {
+		md.recency.remove(key)
+	}
+	if md.priorities != nil {
+		md.priorities.remove(key)
+	}
+}
@@ -0,0 +1,76 @@
+package fscache
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvictionOrder(t *testing.T) {
+	ch := Cache{}
+	WithMaxCost(50)(&ch)
+	WithMaxEntries(40)(&ch)
+	md := ch.Memdis()
+
+	// the victims of the orders are the ones a scan of the storage finds
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		key := fmt.Sprintf("key%d", r.Intn(100))
+		switch r.Intn(4) {
+		case 0:
+			md.Get(key)
+		case 1:
+			md.Del(key)
+		case 2:
+			md.SetWithOptions(key, i, SetOptions{Priority: r.Intn(3)})
+		default:
+			md.OverWriteOrSet(key, i)
+		}
+
+		md.state().mu.Lock()
+		var lruKey, victimKey string
+		var lru, victim MemdisData
+		for key, value := range md.storage {
+			if lruKey == "" || value.rank < lru.rank || (value.rank == lru.rank && value.accessed < lru.accessed) {
+				lruKey, lru = key, value
+			}
+			if victimKey == "" || evictedBefore(value, victim) {
+				victimKey, victim = key, value
+			}
+		}
+		if len(md.storage) > 0 {
+			_, key := md.leastRecentlyUsed()
+			assert.Equal(t, lruKey, key)
+			_, key, _ = md.victim()
+			assert.Equal(t, victimKey, key)
+		}
+		md.state().mu.Unlock()
+	}
+}
+
+// BenchmarkEviction measures the writes of a Memdis at capacity, each of them evicting a data
+func BenchmarkEviction(b *testing.B) {
+	for _, policy := range []string{"greedydual", "lru"} {
+		b.Run(policy, func(b *testing.B) {
+			const capacity = 100000
+
+			ch := Cache{}
+			if policy == "lru" {
+				WithMaxEntries(capacity)(&ch)
+			} else {
+				WithMaxCost(capacity)(&ch)
+			}
+			// the last one builds the order of the evictions
+			for i := 0; i <= capacity; i++ {
+				ch.Memdis().Set(fmt.Sprintf("key%d", i), i)
+			}
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				ch.Memdis().Set(fmt.Sprintf("new%d", i), i)
+			}
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package fscache
+
+import (
+	"math"
+	"time"
+)
+
+const (
+	// NoExpiration is the ttl of datas which never expire. It is used when no duration is given.
+	NoExpiration time.Duration = 0
+	// DefaultExpiration is the ttl of datas which expire after the duration set with WithDefaultExpiration.
+	// It is the lowest duration, so it never collides with NeverExpires or a computed ttl.
+	DefaultExpiration time.Duration = math.MinInt64
+	// NeverExpires is the remaining lifetime TTL() returns for the datas which never expire. Like the other
+	// negative ttls, it makes the datas it is set with never expire.
+	NeverExpires time.Duration = -1
+)
+
+// WithDefaultExpiration sets the ttl of datas set with DefaultExpiration
+func WithDefaultExpiration(ttl time.Duration) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.defaultExpiration = ttl
+	}
+}
+
+// WithOverWriteResetsTTL makes OverWrite() and OverWriteWithKey() without a duration set datas
+// which never expire, instead of keeping their remaining time to live.
+func WithOverWriteResetsTTL() Option {
+	return func(c *Cache) {
+		c.MemdisInstance.overWriteResetsTTL = true
+	}
+}
+
+// ttlOf returns the ttl given as the optional duration param of an operation
+func (md *Memdis) ttlOf(duration []time.Duration) time.Duration {
+	if len(duration) == 0 {
+		return NoExpiration
+	}
+
+	return md.resolveTTL(duration[0])
+}
+
+// resolveTTL resolves DefaultExpiration into the default ttl of Memdis. The other negative ttls, NeverExpires
+// included, mean no expiration, so the ttl returned by TTL() can be set back.
+func (md *Memdis) resolveTTL(ttl time.Duration) time.Duration {
+	if ttl == DefaultExpiration {
+		ttl = md.defaultExpiration
+	}
+
+	if ttl < 0 {
+		return NoExpiration
+	}
+
+	return ttl
+}
+
+// expiresAt returns the expiration time of a data set with ttl. A zero time means the data never expires.
+func expiresAt(ttl time.Duration) time.Time {
+	if ttl <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(ttl)
+}
+
+// expired reports whether the data has expired at now
+func (d MemdisData) expired(now time.Time) bool {
+	return !d.Duration.IsZero() && !now.Before(d.Duration)
+}
+
+// TTL() returns the remaining lifetime of a data, or NeverExpires if it never expires, like the TTL command of Redis.
+// Reading the ttl doesn't count as a hit.
+func (md *Memdis) TTL(key string) (_ time.Duration, err error) {
+	defer recoverPanic(&err)
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return 0, err
+	}
+
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
+	_, data, ok := md.lookup(key)
+	if !ok {
+		return 0, errKeyNotFound
+	}
+
+	if data.Duration.IsZero() {
+		return NeverExpires, nil
+	}
+
+	return time.Until(data.Duration), nil
+}
+
+// Expire() sets the time to live of a data without overwriting its value. DefaultExpiration sets the duration set
+// with WithDefaultExpiration(), and NoExpiration makes the data never expire.
+func (md *Memdis) Expire(key string, duration time.Duration) (err error) {
+	defer recoverPanic(&err)
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	index, data, ok := md.lookup(key)
+	if !ok {
+		return errKeyNotFound
+	}
+
+	data.TTL = md.resolveTTL(duration)
+	data.Duration = expiresAt(data.TTL)
+	md.replace(index, key, data)
+
+	return nil
+}
+
+// Persist() makes a data never expire, like the PERSIST command of Redis, so datas set with a default ttl can be
+// pinned. It is a shorthand for Expire(key, NoExpiration).
+func (md *Memdis) Persist(key string) error {
+	return md.Expire(key, NoExpiration)
+}
+
+// Touch() resets the time to live of a data to the duration it was set with, for sliding expirations.
+// It does nothing to the datas which never expire.
+func (md *Memdis) Touch(key string) (err error) {
+	defer recoverPanic(&err)
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	index, data, ok := md.lookup(key)
+	if !ok {
+		return errKeyNotFound
+	}
+
+	if data.TTL > 0 {
+		data.Duration = expiresAt(data.TTL)
+		md.replace(index, key, data)
+	}
+
+	return nil
+}
+
+// OnExpired() registers fn to be called with the keys removed by each expiration sweep.
+// Keys expiring in the same sweep are delivered together in a single call.
+func (md *Memdis) OnExpired(fn func(keys []string)) {
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	md.onExpired = fn
+}
+
+// expire removes the expired datas from the storage and notifies the OnExpired() callback
+func (md *Memdis) expire() {
+	md.state().mu.Lock()
+	keys := md.deleteExpired(time.Now())
+	onExpired := md.onExpired
+	md.state().mu.Unlock()
+
+	if len(keys) > 0 && onExpired != nil {
+		onExpired(keys)
+	}
+}
+
+// dropExpired removes the data of key from the storage if it has expired, so reads don't wait for the next
+// expiration sweep to free it. The caller must hold md.state().mu for writing.
+func (md *Memdis) dropExpired(key string) {
+	value, ok := md.storage[key]
+	if !ok || !value.expired(time.Now()) {
+		return
+	}
+
+	if debug {
+		md.logger.Info().Msgf("data object [%v] got expired ", md.loggedKey(key))
+	}
+	md.remove(storedIndex, key)
+	md.state().expirations.Add(1)
+	md.notify(KeyExpire, key)
+	md.forgetDigest(key)
+}
+
+// deleteExpired removes the datas which have expired at now and returns their keys. The caller must hold md.state().mu.
+func (md *Memdis) deleteExpired(now time.Time) []string {
+	var keys []string
+	for key, value := range md.storage {
+		if !value.expired(now) {
+			continue
+		}
+
+		if debug {
+			md.logger.Info().Msgf("data object [%v] got expired ", md.loggedKey(key))
+		}
+		keys = append(keys, md.originalKey(key))
+		md.remove(storedIndex, key)
+		md.state().expirations.Add(1)
+		md.notify(KeyExpire, key)
+		md.forgetDigest(key)
+	}
+
+	return keys
+}
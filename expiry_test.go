@@ -0,0 +1,287 @@
+package fscache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOnExpired(t *testing.T) {
+	ch := Cache{}
+
+	var batches [][]string
+	ch.Memdis().OnExpired(func(keys []string) {
+		batches = append(batches, keys)
+	})
+
+	for _, key := range []string{"exp1", "exp2", "exp3"} {
+		if err := ch.Memdis().Set(key, key, time.Millisecond); err != nil {
+			assert.Error(t, err)
+		}
+	}
+	if err := ch.Memdis().Set("noExp", "value"); err != nil {
+		assert.Error(t, err)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	ch.Memdis().expire()
+
+	assert.Len(t, batches, 1)
+	assert.ElementsMatch(t, []string{"exp1", "exp2", "exp3"}, batches[0])
+	assert.EqualValues(t, []string{"noExp"}, ch.Memdis().Keys())
+
+	// the enumerations leave the expired datas out without removing them, so only the sweeps call OnExpired()
+	if err := ch.Memdis().Set("exp4", "exp4", time.Millisecond); err != nil {
+		assert.Error(t, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	assert.EqualValues(t, []string{"noExp"}, ch.Memdis().Keys())
+	assert.EqualValues(t, []interface{}{"value"}, ch.Memdis().Values())
+	keys, err := ch.Memdis().KeysMatching("*")
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"noExp"}, keys)
+	assert.Len(t, batches, 1)
+
+	ch.Memdis().expire()
+	assert.Len(t, batches, 2)
+	assert.EqualValues(t, []string{"exp4"}, batches[1])
+}
+
+func TestExpiration(t *testing.T) {
+	ch := Cache{}
+	WithDefaultExpiration(time.Minute)(&ch)
+
+	assert.NoError(t, ch.Memdis().Set("never", "value"))
+	assert.NoError(t, ch.Memdis().Set("noExpiration", "value", NoExpiration))
+	assert.NoError(t, ch.Memdis().Set("default", "value", DefaultExpiration))
+	_, err := ch.Memdis().SetMany([]map[string]MemdisData{
+		{"many": MemdisData{Value: "value", TTL: time.Hour}},
+	})
+	assert.NoError(t, err)
+
+	for key, value := range ch.MemdisInstance.storage {
+		switch key {
+		case "never", "noExpiration":
+			assert.True(t, value.Duration.IsZero(), key)
+		case "default":
+			assert.WithinDuration(t, time.Now().Add(time.Minute), value.Duration, time.Second)
+		case "many":
+			assert.WithinDuration(t, time.Now().Add(time.Hour), value.Duration, time.Second)
+		}
+	}
+
+	// datas without expiration survive the expiration sweep
+	ch.Memdis().expire()
+	assert.EqualValues(t, 4, ch.Memdis().Size())
+}
+
+func TestNonPositiveTTL(t *testing.T) {
+	ch := Cache{}
+	WithDefaultExpiration(time.Minute)(&ch)
+
+	// zero and negative ttls never expire, instead of expiring at once
+	assert.NoError(t, ch.Memdis().Set("zero", "value", 0))
+	assert.NoError(t, ch.Memdis().Set("negative", "value", -time.Second))
+	assert.NoError(t, ch.Memdis().Set("never", "value", NeverExpires))
+	for _, key := range []string{"zero", "negative", "never"} {
+		ttl, err := ch.Memdis().TTL(key)
+		assert.NoError(t, err)
+		assert.Equal(t, NeverExpires, ttl, key)
+	}
+
+	// the ttl returned by TTL() is set back as is, never as the default expiration
+	assert.NotEqual(t, DefaultExpiration, NeverExpires)
+	ttl, err := ch.Memdis().TTL("never")
+	assert.NoError(t, err)
+	assert.NoError(t, ch.Memdis().Set("copy", "value", ttl))
+	ttl, err = ch.Memdis().TTL("copy")
+	assert.NoError(t, err)
+	assert.Equal(t, NeverExpires, ttl)
+
+	assert.NoError(t, ch.Memdis().Set("session", "value", DefaultExpiration))
+	ttl, err = ch.Memdis().TTL("session")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+	time.Sleep(5 * time.Millisecond)
+	ch.Memdis().expire()
+	assert.EqualValues(t, 5, ch.Memdis().Size())
+}
+
+func TestOverWriteKeepsTTL(t *testing.T) {
+	ch := Cache{}
+
+	assert.NoError(t, ch.Memdis().Set("ttl1", "value1", time.Minute))
+	_, prev, _ := ch.MemdisInstance.lookup("ttl1")
+
+	assert.NoError(t, ch.Memdis().OverWrite("ttl1", "value2"))
+	_, data, _ := ch.MemdisInstance.lookup("ttl1")
+	assert.EqualValues(t, "value2", data.Value)
+	assert.Equal(t, prev.Duration, data.Duration)
+
+	assert.NoError(t, ch.Memdis().OverWrite("ttl1", "value3", time.Hour))
+	_, data, _ = ch.MemdisInstance.lookup("ttl1")
+	assert.WithinDuration(t, time.Now().Add(time.Hour), data.Duration, time.Second)
+
+	assert.NoError(t, ch.Memdis().OverWriteOrSet("ttl2", "value1"))
+	value, err := ch.Memdis().Get("ttl2")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "value1", value)
+
+	ch = Cache{}
+	WithOverWriteResetsTTL()(&ch)
+	assert.NoError(t, ch.Memdis().Set("ttl1", "value1", time.Minute))
+	assert.NoError(t, ch.Memdis().OverWrite("ttl1", "value2"))
+	_, data, _ = ch.MemdisInstance.lookup("ttl1")
+	assert.True(t, data.Duration.IsZero())
+}
+
+func TestLazyExpiration(t *testing.T) {
+	ch := Cache{}
+	assert.NoError(t, ch.Memdis().Set("get", "value", 10*time.Millisecond))
+	assert.NoError(t, ch.Memdis().Set("many", "value", 10*time.Millisecond))
+	assert.NoError(t, ch.Memdis().Set("keys", "value", 10*time.Millisecond))
+	assert.NoError(t, ch.Memdis().Set("live", "value"))
+	time.Sleep(20 * time.Millisecond)
+
+	// the expired datas are missing, and removed once the reads are applied, without any expiration sweep
+	_, err := ch.Memdis().Get("get")
+	assert.Equal(t, errKeyNotFound, err)
+	assert.Equal(t, []map[string]interface{}{{"live": "value"}}, ch.Memdis().GetMany([]string{"many", "live"}))
+	assert.Contains(t, ch.MemdisInstance.storage, "get")
+	assert.NoError(t, ch.Memdis().Set("other", "value"))
+	assert.NotContains(t, ch.MemdisInstance.storage, "get")
+	assert.NotContains(t, ch.MemdisInstance.storage, "many")
+	assert.NoError(t, ch.Memdis().Del("other"))
+
+	// the enumerations leave them out without side effects, the sweeps remove them
+	assert.Equal(t, []string{"live"}, ch.Memdis().Keys())
+	assert.Contains(t, ch.MemdisInstance.storage, "keys")
+	ch.Memdis().expire()
+	assert.Len(t, ch.MemdisInstance.storage, 1)
+}
+
+func TestTTL(t *testing.T) {
+	ch := Cache{}
+	assert.NoError(t, ch.Memdis().Set("session", "value", time.Minute))
+	assert.NoError(t, ch.Memdis().Set("config", "value"))
+
+	ttl, err := ch.Memdis().TTL("session")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+	ttl, err = ch.Memdis().TTL("config")
+	assert.NoError(t, err)
+	assert.Equal(t, NeverExpires, ttl)
+
+	_, err = ch.Memdis().TTL("missing")
+	assert.Equal(t, errKeyNotFound, err)
+}
+
+func TestExpire(t *testing.T) {
+	ch := Cache{}
+	WithDefaultExpiration(time.Hour)(&ch)
+	assert.NoError(t, ch.Memdis().Set("session", "value", time.Minute))
+
+	assert.NoError(t, ch.Memdis().Expire("session", 10*time.Millisecond))
+	ttl, err := ch.Memdis().TTL("session")
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, ttl, 10*time.Millisecond)
+
+	assert.NoError(t, ch.Memdis().Expire("session", DefaultExpiration))
+	ttl, err = ch.Memdis().TTL("session")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Hour, ttl, float64(time.Second))
+
+	assert.NoError(t, ch.Memdis().Expire("session", NoExpiration))
+	ttl, err = ch.Memdis().TTL("session")
+	assert.NoError(t, err)
+	assert.Equal(t, NeverExpires, ttl)
+
+	// the value is kept
+	value, err := ch.Memdis().Get("session")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "value", value)
+
+	assert.Equal(t, errKeyNotFound, ch.Memdis().Expire("missing", time.Minute))
+}
+
+func TestPersist(t *testing.T) {
+	ch := Cache{}
+	WithDefaultExpiration(time.Minute)(&ch)
+	assert.NoError(t, ch.Memdis().Set("config", "value", DefaultExpiration))
+
+	assert.NoError(t, ch.Memdis().Persist("config"))
+	ttl, err := ch.Memdis().TTL("config")
+	assert.NoError(t, err)
+	assert.Equal(t, NeverExpires, ttl)
+
+	persisted, err := ch.Redis().Persist(context.Background(), "config")
+	assert.NoError(t, err)
+	assert.False(t, persisted)
+
+	assert.Equal(t, errKeyNotFound, ch.Memdis().Persist("missing"))
+}
+
+func TestTouch(t *testing.T) {
+	ch := Cache{}
+	assert.NoError(t, ch.Memdis().Set("session", "value", 50*time.Millisecond))
+	assert.NoError(t, ch.Memdis().Set("config", "value"))
+
+	time.Sleep(30 * time.Millisecond)
+	assert.NoError(t, ch.Memdis().Touch("session"))
+	ttl, err := ch.Memdis().TTL("session")
+	assert.NoError(t, err)
+	assert.Greater(t, ttl, 40*time.Millisecond)
+
+	// the session slides past its original expiration
+	time.Sleep(30 * time.Millisecond)
+	_, err = ch.Memdis().Get("session")
+	assert.NoError(t, err)
+
+	assert.NoError(t, ch.Memdis().Touch("config"))
+	ttl, err = ch.Memdis().TTL("config")
+	assert.NoError(t, err)
+	assert.Equal(t, NeverExpires, ttl)
+
+	assert.Equal(t, errKeyNotFound, ch.Memdis().Touch("missing"))
+}
+
+func TestExpiryUntracks(t *testing.T) {
+	ch := Cache{}
+	WithMaxEntries(10)(&ch)
+	md := ch.Memdis()
+	for i := 0; i < 6; i++ {
+		ttl := time.Hour
+		if i%2 == 0 {
+			ttl = time.Millisecond
+		}
+		assert.NoError(t, md.Set(fmt.Sprintf("tracked%d", i), i, ttl))
+	}
+
+	md.state().mu.Lock()
+	md.leastRecentlyUsed()
+	recency := md.recency
+	md.state().mu.Unlock()
+	time.Sleep(2 * time.Millisecond)
+
+	// the expired datas leave the eviction order, which is kept rather than rebuilt
+	_, err := md.Get("tracked0")
+	assert.Equal(t, errKeyNotFound, err)
+	md.expire()
+	md.state().mu.Lock()
+	assert.Len(t, md.recency.elements, 3)
+	md.leastRecentlyUsed()
+	assert.Same(t, recency, md.recency)
+	md.state().mu.Unlock()
+
+	// a data replacing an expired one counts as an expiration
+	expirations := md.Stats().Expirations
+	assert.NoError(t, md.Set("replaced", 1, time.Millisecond))
+	time.Sleep(2 * time.Millisecond)
+	assert.NoError(t, md.Set("replaced", 2))
+	assert.Equal(t, expirations+1, md.Stats().Expirations)
+}
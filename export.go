@@ -0,0 +1,22 @@
+package fscache
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// SnapshotExport writes a point-in-time copy of all the records into w, in the json format of Persist() so it can be
+// loaded back with LoadDefault(), along with the records tracking the migrations. The records are never modified in place once stored, so copying the storage is a
+// matter of copying their references: writers are only blocked for that copy and not while the records are encoded
+// and written, and the export never captures a half applied write.
+func (n *Memgodb) SnapshotExport(w io.Writer) (err error) {
+	defer recoverPanic(&err)
+
+	memgodbMu.RLock()
+	records := make([]interface{}, len(MemgodbStorage))
+	copy(records, MemgodbStorage)
+	migrations := withMigrations(nil)
+	memgodbMu.RUnlock()
+
+	return json.NewEncoder(w).Encode(append(n.ordered(records), migrations...))
+}
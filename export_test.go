@@ -0,0 +1,25 @@
+package fscache
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SnapshotExport(t *testing.T) {
+	ch := Cache{}
+
+	_, err := ch.Memgodb().Collection("exported").Insert(map[string]interface{}{"name": "exported-john"}).One()
+	assert.NoError(t, err)
+
+	var export strings.Builder
+	assert.NoError(t, ch.Memgodb().SnapshotExport(&export))
+
+	// writes after the export are not part of it
+	_, err = ch.Memgodb().Collection("exported").Insert(map[string]interface{}{"name": "exported-jane"}).One()
+	assert.NoError(t, err)
+
+	assert.Contains(t, export.String(), `"name":"exported-john"`)
+	assert.NotContains(t, export.String(), `"name":"exported-jane"`)
+}
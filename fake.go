@@ -0,0 +1,189 @@
+package fscache
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// errInvalidPlaceholder the placeholder of a template is not valid
+	errInvalidPlaceholder = errors.New("invalid placeholder")
+)
+
+var (
+	// placeholderPattern matches the placeholders of a template like {{email}} or {{int:1:100}}
+	placeholderPattern = regexp.MustCompile(`\{\{([^{}]+)\}\}`)
+
+	fakeFirstNames = []string{"James", "Mary", "John", "Patricia", "Robert", "Jennifer", "Michael", "Linda", "David", "Elizabeth",
+		"Amara", "Chidi", "Ngozi", "Tunde", "Aisha", "Mateo", "Sofia", "Lucas", "Emma", "Hugo", "Yuki", "Wei", "Priya", "Omar"}
+	fakeLastNames = []string{"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Okafor", "Adeyemi",
+		"Martin", "Bernard", "Dubois", "Rossi", "Silva", "Tanaka", "Chen", "Patel", "Khan", "Nguyen"}
+	fakeCities = []string{"Lagos", "Paris", "London", "New York", "Tokyo", "Berlin", "Nairobi", "Madrid", "Toronto", "Sydney",
+		"Abuja", "Lyon", "Accra", "Mumbai", "Sao Paulo"}
+	fakeCountries = []string{"Nigeria", "France", "United Kingdom", "United States", "Japan", "Germany", "Kenya", "Spain",
+		"Canada", "Australia", "Ghana", "India", "Brazil"}
+	fakeWords = []string{"lorem", "ipsum", "dolor", "sit", "amet", "consectetur", "adipiscing", "elit", "sed", "do",
+		"eiusmod", "tempor", "incididunt", "ut", "labore", "et", "dolore", "magna", "aliqua"}
+)
+
+// fakePerson is the identity shared by the placeholders of a fake document, so its email matches its name
+type fakePerson struct {
+	firstName string
+	lastName  string
+}
+
+// SeedFake inserts n fake documents generated from template into the collection, so demos and load tests don't need
+// hand-written fixtures. The string values of template may hold placeholders, nested documents and slices included:
+//
+//	{{firstName}}, {{lastName}}, {{name}}, {{email}}, {{phone}}, {{city}}, {{country}}, {{uuid}}, {{bool}},
+//	{{word}}, {{sentence}}, {{date}} (within the last year), {{int:min:max}}, {{float:min:max}}, {{oneOf:a|b|c}}
+//
+// A value made of a single placeholder keeps the type of the placeholder, e.g. a number for {{int:1:100}}.
+// The name and email placeholders of a document belong to the same person.
+func (c *Collection) SeedFake(n int, template map[string]interface{}) (_ []interface{}, err error) {
+	defer recoverPanic(&err)
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+
+	docs := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		person := fakePerson{
+			firstName: fakeFirstNames[r.Intn(len(fakeFirstNames))],
+			lastName:  fakeLastNames[r.Intn(len(fakeLastNames))],
+		}
+
+		doc, err := fakeValue(r, person, template)
+		if err != nil {
+			return nil, err
+		}
+		docs = append(docs, doc)
+	}
+
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	return c.Insert(nil).Many(docs)
+}
+
+// fakeValue returns value with its placeholders replaced by fake values
+func fakeValue(r *rand.Rand, person fakePerson, value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		doc := make(map[string]interface{}, len(v))
+		for key, field := range v {
+			fake, err := fakeValue(r, person, field)
+			if err != nil {
+				return nil, err
+			}
+			doc[key] = fake
+		}
+		return doc, nil
+	case []interface{}:
+		values := make([]interface{}, len(v))
+		for i, element := range v {
+			fake, err := fakeValue(r, person, element)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = fake
+		}
+		return values, nil
+	case string:
+		if match := placeholderPattern.FindStringSubmatch(v); match != nil && match[0] == v {
+			return fakePlaceholder(r, person, match[1])
+		}
+
+		var err error
+		replaced := placeholderPattern.ReplaceAllStringFunc(v, func(placeholder string) string {
+			fake, fakeErr := fakePlaceholder(r, person, placeholder[2:len(placeholder)-2])
+			if fakeErr != nil {
+				err = fakeErr
+			}
+			return fmt.Sprint(fake)
+		})
+		return replaced, err
+	}
+
+	return value, nil
+}
+
+// fakePlaceholder returns a fake value for the placeholder
+func fakePlaceholder(r *rand.Rand, person fakePerson, placeholder string) (interface{}, error) {
+	name, args, _ := strings.Cut(strings.TrimSpace(placeholder), ":")
+
+	switch name {
+	case "firstName":
+		return person.firstName, nil
+	case "lastName":
+		return person.lastName, nil
+	case "name":
+		return person.firstName + " " + person.lastName, nil
+	case "email":
+		return strings.ToLower(person.firstName+"."+person.lastName) + "@example.com", nil
+	case "phone":
+		return fmt.Sprintf("+1 555 %03d %04d", r.Intn(1000), r.Intn(10000)), nil
+	case "city":
+		return fakeCities[r.Intn(len(fakeCities))], nil
+	case "country":
+		return fakeCountries[r.Intn(len(fakeCountries))], nil
+	case "uuid":
+		return uuid.NewString(), nil
+	case "bool":
+		return r.Intn(2) == 1, nil
+	case "word":
+		return fakeWords[r.Intn(len(fakeWords))], nil
+	case "sentence":
+		words := make([]string, 5+r.Intn(6))
+		for i := range words {
+			words[i] = fakeWords[r.Intn(len(fakeWords))]
+		}
+		sentence := strings.Join(words, " ")
+		return strings.ToUpper(sentence[:1]) + sentence[1:] + ".", nil
+	case "date":
+		return time.Now().Add(-time.Duration(r.Int63n(int64(365 * 24 * time.Hour)))).UTC().Truncate(time.Second), nil
+	case "int":
+		low, high, err := fakeRange(args)
+		if err != nil {
+			return nil, err
+		}
+		return int(low) + r.Intn(int(high-low)+1), nil
+	case "float":
+		low, high, err := fakeRange(args)
+		if err != nil {
+			return nil, err
+		}
+		return low + r.Float64()*(high-low), nil
+	case "oneOf":
+		choices := strings.Split(args, "|")
+		return choices[r.Intn(len(choices))], nil
+	}
+
+	return nil, fmt.Errorf("%w: %s", errInvalidPlaceholder, placeholder)
+}
+
+// fakeRange parses the "min:max" arguments of a placeholder
+func fakeRange(args string) (float64, float64, error) {
+	minArg, maxArg, ok := strings.Cut(args, ":")
+	if !ok {
+		return 0, 0, errInvalidPlaceholder
+	}
+
+	low, err := strconv.ParseFloat(minArg, 64)
+	if err != nil {
+		return 0, 0, errInvalidPlaceholder
+	}
+	high, err := strconv.ParseFloat(maxArg, 64)
+	if err != nil || high < low {
+		return 0, 0, errInvalidPlaceholder
+	}
+
+	return low, high, nil
+}
@@ -0,0 +1,39 @@
+package fscache
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_SeedFake(t *testing.T) {
+	ch := Cache{}
+
+	records, err := ch.Memgodb().Collection("fake").SeedFake(5, map[string]interface{}{
+		"name":    "{{name}}",
+		"email":   "{{email}}",
+		"age":     "{{int:18:65}}",
+		"plan":    "{{oneOf:free|pro}}",
+		"handle":  "user-{{int:1:9}}",
+		"address": map[string]interface{}{"city": "{{city}}"},
+		"active":  true,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, records, 5)
+
+	for _, record := range records {
+		doc := record.(map[string]interface{})
+		names := strings.Split(doc["name"].(string), " ")
+		assert.Equal(t, strings.ToLower(names[0]+"."+names[1])+"@example.com", doc["email"])
+		assert.GreaterOrEqual(t, doc["age"], 18.0)
+		assert.LessOrEqual(t, doc["age"], 65.0)
+		assert.Contains(t, []interface{}{"free", "pro"}, doc["plan"])
+		assert.Regexp(t, `^user-[1-9]$`, doc["handle"])
+		assert.NotEmpty(t, doc["address"].(map[string]interface{})["city"])
+		assert.Equal(t, true, doc["active"])
+	}
+
+	_, err = ch.Memgodb().Collection("fake").SeedFake(1, map[string]interface{}{"name": "{{unknown}}"})
+	assert.ErrorIs(t, err, errInvalidPlaceholder)
+}
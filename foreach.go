@@ -0,0 +1,78 @@
+package fscache
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+)
+
+// ForEachParallel() calls fn with every data of the in-memmory storage, using up to workers goroutines.
+// fn works on a snapshot of the storage taken when ForEachParallel() is called, so it can safely update
+// the cache, e.g. to revalidate or re-encrypt datas. The errors returned by fn are joined together, along with
+// ErrTimeout if the scan was aborted by WithOpTimeout().
+func (md *Memdis) ForEachParallel(fn func(entry Entry) error, workers int) (err error) {
+	defer recoverPanic(&err)
+
+	if workers < 1 {
+		workers = 1
+	}
+
+	md.state().mu.RLock()
+	var entries []Entry
+	md.snapshot(func(_ int, key string, value MemdisData) {
+		entries = append(entries, md.entryOf(key, value))
+	})
+	md.state().mu.RUnlock()
+
+	jobs := make(chan Entry)
+	var (
+		wg     sync.WaitGroup
+		errsMu sync.Mutex
+		errs   []error
+		// timedOut is set once the deadline passed, the entries left being skipped
+		timedOut atomic.Bool
+	)
+
+	deadline := deadlineOf(md.opTimeout)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for entry := range jobs {
+				// the deadline is checked before each call, so a slow fn can't overrun it
+				if timedOut.Load() || deadlinePassed(deadline) {
+					timedOut.Store(true)
+					continue
+				}
+				if err := callEach(fn, entry); err != nil {
+					errsMu.Lock()
+					errs = append(errs, err)
+					errsMu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, entry := range entries {
+		if timedOut.Load() || deadlinePassed(deadline) {
+			timedOut.Store(true)
+			break
+		}
+		jobs <- entry
+	}
+	close(jobs)
+	wg.Wait()
+
+	if timedOut.Load() {
+		errs = append(errs, ErrTimeout)
+	}
+
+	return errors.Join(errs...)
+}
+
+// callEach calls fn with entry, returning its panic as an error
+func callEach(fn func(entry Entry) error, entry Entry) (err error) {
+	defer recoverPanic(&err)
+
+	return fn(entry)
+}
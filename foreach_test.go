@@ -0,0 +1,60 @@
+package fscache
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForEachParallel(t *testing.T) {
+	ch := Cache{}
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, ch.Memdis().Set(fmt.Sprintf("each%d", i), i))
+	}
+
+	var (
+		mu   sync.Mutex
+		seen = make(map[string]bool)
+	)
+	err := ch.Memdis().ForEachParallel(func(entry Entry) error {
+		mu.Lock()
+		seen[entry.Key] = true
+		mu.Unlock()
+
+		// the cache can be updated while it is being iterated
+		return ch.Memdis().OverWrite(entry.Key, entry.Value.(int)*2)
+	}, 4)
+	assert.NoError(t, err)
+	assert.Len(t, seen, 100)
+
+	value, err := ch.Memdis().Get("each21")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 42, value)
+
+	err = ch.Memdis().ForEachParallel(func(entry Entry) error {
+		return errKeyNotFound
+	}, 4)
+	assert.ErrorIs(t, err, errKeyNotFound)
+}
+
+func TestForEachParallelSlowTimeout(t *testing.T) {
+	ch := Cache{}
+	WithOpTimeout(20 * time.Millisecond)(&ch)
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, ch.Memdis().Set(fmt.Sprintf("slow%d", i), i))
+	}
+
+	// the deadline is checked before each call of a slow fn, not every 256 datas
+	var calls atomic.Int64
+	err := ch.Memdis().ForEachParallel(func(entry Entry) error {
+		calls.Add(1)
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	}, 2)
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.Less(t, calls.Load(), int64(10))
+}
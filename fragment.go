@@ -0,0 +1,188 @@
+package fscache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// fragmentPrefix prefixes the Memdis keys of the rendered fragments
+	fragmentPrefix = "fragment:"
+)
+
+var (
+	// errUnknownFragment the fragment is not registered
+	errUnknownFragment = errors.New("unknown fragment")
+	// errFragmentCycle the fragment depends on itself
+	errFragmentCycle = errors.New("fragment depends on itself")
+)
+
+type (
+	// Fragment object is a named piece of a response, e.g. an HTML partial or a JSON document, rendered once and
+	// cached until its ttl elapses or one of its dependencies is invalidated
+	Fragment struct {
+		// Name identifies the fragment
+		Name string
+		// DependsOn are the names of the fragments embedded by the fragment, rendered before it
+		DependsOn []string
+		// TTL is the duration the fragment is cached for, like the durations of Set()
+		TTL time.Duration
+		// Render renders the fragment from its rendered dependencies, by name
+		Render func(ctx context.Context, deps map[string]template.HTML) (string, error)
+	}
+
+	// FragmentCache object stores rendered fragments in Memdis and assembles them for server-side rendering
+	FragmentCache struct {
+		memdis *Memdis
+
+		mu         sync.RWMutex
+		fragments  map[string]Fragment
+		dependents map[string][]string
+	}
+
+	// executor is a parsed template of text/template or html/template
+	executor interface {
+		Execute(w io.Writer, data interface{}) error
+	}
+)
+
+// NewFragmentCache returns a FragmentCache storing its fragments in the Memdis storage of c
+func NewFragmentCache(c *Cache) *FragmentCache {
+	return &FragmentCache{
+		memdis:     &c.MemdisInstance,
+		fragments:  make(map[string]Fragment),
+		dependents: make(map[string][]string),
+	}
+}
+
+// TemplateFragment returns a Fragment rendered by executing tmpl, a text/template or an html/template, with its
+// rendered dependencies by name as data, e.g. {{.header}}. The dependencies are not escaped again.
+func TemplateFragment(name string, tmpl executor, ttl time.Duration, dependsOn ...string) Fragment {
+	return Fragment{
+		Name:      name,
+		DependsOn: dependsOn,
+		TTL:       ttl,
+		Render: func(ctx context.Context, deps map[string]template.HTML) (string, error) {
+			var b strings.Builder
+			if err := tmpl.Execute(&b, deps); err != nil {
+				return "", err
+			}
+
+			return b.String(), nil
+		},
+	}
+}
+
+// Register() adds fragments, replacing the registered ones with the same names
+func (f *FragmentCache) Register(fragments ...Fragment) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, fragment := range fragments {
+		if prev, ok := f.fragments[fragment.Name]; ok {
+			for _, dep := range prev.DependsOn {
+				f.dependents[dep] = without(f.dependents[dep], prev.Name)
+			}
+		}
+
+		f.fragments[fragment.Name] = fragment
+		for _, dep := range fragment.DependsOn {
+			f.dependents[dep] = append(f.dependents[dep], fragment.Name)
+		}
+	}
+}
+
+// Assemble() returns the rendered fragment name, rendering it and the dependencies which are not cached.
+// A fragment is cached no longer than its dependencies, so it never embeds an expired one.
+func (f *FragmentCache) Assemble(ctx context.Context, name string) (string, error) {
+	return f.assemble(ctx, name, make(map[string]bool))
+}
+
+// assemble returns the rendered fragment name. rendering holds the fragments being rendered, to detect cycles.
+func (f *FragmentCache) assemble(ctx context.Context, name string, rendering map[string]bool) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	key := fragmentPrefix + name
+	if cached, err := f.memdis.Get(key); err == nil {
+		if rendered, ok := cached.(string); ok {
+			return rendered, nil
+		}
+	}
+
+	f.mu.RLock()
+	fragment, ok := f.fragments[name]
+	f.mu.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %s", errUnknownFragment, name)
+	}
+
+	if rendering[name] {
+		return "", fmt.Errorf("%w: %s", errFragmentCycle, name)
+	}
+	rendering[name] = true
+	defer delete(rendering, name)
+
+	ttl := f.memdis.resolveTTL(fragment.TTL)
+	deps := make(map[string]template.HTML, len(fragment.DependsOn))
+	for _, dep := range fragment.DependsOn {
+		rendered, err := f.assemble(ctx, dep, rendering)
+		if err != nil {
+			return "", err
+		}
+		deps[dep] = template.HTML(rendered)
+
+		if remaining, err := f.memdis.TTL(fragmentPrefix + dep); err == nil && remaining > 0 && (ttl <= 0 || remaining < ttl) {
+			ttl = remaining
+		}
+	}
+
+	rendered, err := fragment.Render(ctx, deps)
+	if err != nil {
+		return "", err
+	}
+
+	if err := f.memdis.OverWriteOrSet(key, rendered, ttl); err != nil {
+		return "", err
+	}
+
+	return rendered, nil
+}
+
+// Invalidate() drops the rendered fragments names and the ones depending on them, so they are rendered again
+func (f *FragmentCache) Invalidate(names ...string) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	invalidated := make(map[string]bool)
+	for len(names) > 0 {
+		name := names[0]
+		names = names[1:]
+		if invalidated[name] {
+			continue
+		}
+		invalidated[name] = true
+
+		f.memdis.Del(fragmentPrefix + name)
+		names = append(names, f.dependents[name]...)
+	}
+}
+
+// without returns names without name
+func without(names []string, name string) []string {
+	kept := names[:0]
+	for _, n := range names {
+		if n != name {
+			kept = append(kept, n)
+		}
+	}
+
+	return kept
+}
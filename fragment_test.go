@@ -0,0 +1,65 @@
+package fscache
+
+import (
+	"context"
+	"html/template"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFragmentCache(t *testing.T) {
+	ch := Cache{}
+	fragments := NewFragmentCache(&ch)
+	ctx := context.Background()
+
+	var renders atomic.Int32
+	user := "john"
+	fragments.Register(
+		Fragment{
+			Name: "header",
+			TTL:  time.Minute,
+			Render: func(ctx context.Context, deps map[string]template.HTML) (string, error) {
+				renders.Add(1)
+				return "<h1>" + user + "</h1>", nil
+			},
+		},
+		TemplateFragment("page", template.Must(template.New("page").Parse("{{.header}}<p>{{.body}}</p>")), time.Hour, "header", "body"),
+		Fragment{
+			Name: "body",
+			Render: func(ctx context.Context, deps map[string]template.HTML) (string, error) {
+				return "a < b", nil
+			},
+		},
+	)
+
+	page, err := fragments.Assemble(ctx, "page")
+	assert.NoError(t, err)
+	assert.Equal(t, "<h1>john</h1><p>a < b</p>", page)
+
+	// the page is cached no longer than its header
+	ttl, err := ch.Memdis().TTL(fragmentPrefix + "page")
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, ttl, time.Minute)
+
+	_, err = fragments.Assemble(ctx, "page")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, renders.Load())
+
+	// invalidating the header renders the page again
+	user = "jane"
+	fragments.Invalidate("header")
+	page, err = fragments.Assemble(ctx, "page")
+	assert.NoError(t, err)
+	assert.Equal(t, "<h1>jane</h1><p>a < b</p>", page)
+	assert.EqualValues(t, 2, renders.Load())
+
+	_, err = fragments.Assemble(ctx, "footer")
+	assert.ErrorIs(t, err, errUnknownFragment)
+
+	fragments.Register(TemplateFragment("loop", template.Must(template.New("loop").Parse("{{.loop}}")), 0, "loop"))
+	_, err = fragments.Assemble(ctx, "loop")
+	assert.ErrorIs(t, err, errFragmentCycle)
+}
@@ -0,0 +1,49 @@
+package fscache
+
+import "errors"
+
+var (
+	// ErrStoreFull is returned by the writes rejected by the FullReject policy
+	ErrStoreFull = errors.New("store is full")
+)
+
+// FullPolicy defines how Memdis handles new datas once the WithMaxCost or WithMaxMemory budget or the
+// WithMaxEntries limit is reached, or the heap exceeds the target set with WithMemoryPressure
+type FullPolicy int
+
+const (
+	// FullEvict evicts datas, lowest priority first, to make room for the new ones
+	FullEvict FullPolicy = iota
+	// FullReject rejects the new datas with ErrStoreFull
+	FullReject
+	// FullIgnore drops the new datas silently
+	FullIgnore
+)
+
+// WithFullPolicy sets how Memdis handles new datas once it is full, FullEvict by default.
+// Replacing the value of a data already set is never rejected.
+func WithFullPolicy(policy FullPolicy) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.fullPolicy = policy
+	}
+}
+
+// room reports whether new datas costing cost can be added, and the error to return when they can't.
+// The caller must hold md.state().mu.
+func (md *Memdis) room(cost int64) (bool, error) {
+	full := md.state().pressured.Load() || (md.maxCost > 0 && md.totalCost+cost > md.maxCost) ||
+		(md.maxEntries > 0 && len(md.storage) >= md.maxEntries) ||
+		(md.maxMemory > 0 && md.totalSize >= md.maxMemory)
+	if !full {
+		return true, nil
+	}
+
+	switch md.fullPolicy {
+	case FullReject:
+		return false, ErrStoreFull
+	case FullIgnore:
+		return false, nil
+	}
+
+	return true, nil
+}
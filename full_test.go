@@ -0,0 +1,41 @@
+package fscache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullPolicy(t *testing.T) {
+	ch := Cache{}
+	WithMaxCost(2)(&ch)
+	WithFullPolicy(FullReject)(&ch)
+
+	assert.NoError(t, ch.Memdis().Set("full1", 1))
+	assert.NoError(t, ch.Memdis().Set("full2", 2))
+	assert.Equal(t, ErrStoreFull, ch.Memdis().Set("full3", 3))
+	assert.Equal(t, ErrStoreFull, ch.Memdis().SetWithCost("full4", 4, 1))
+	// replacing a data already set is never rejected
+	assert.NoError(t, ch.Memdis().OverWrite("full1", 10))
+
+	report, err := ch.Memdis().SetManyWithReport([]map[string]MemdisData{{"full2": {Value: 20}, "full5": {Value: 5}}})
+	assert.Equal(t, ErrStoreFull, err)
+	assert.Equal(t, []string{"full2"}, report.Replaced)
+	assert.Equal(t, []string{"full5"}, report.Skipped)
+
+	_, err = ch.Memdis().Get("full1")
+	assert.NoError(t, err)
+	_, err = ch.Memdis().Get("full3")
+	assert.Error(t, err)
+
+	ignore := Cache{}
+	WithMaxCost(1)(&ignore)
+	WithFullPolicy(FullIgnore)(&ignore)
+
+	assert.NoError(t, ignore.Memdis().Set("ignored1", 1))
+	assert.NoError(t, ignore.Memdis().Set("ignored2", 2))
+	_, err = ignore.Memdis().Get("ignored1")
+	assert.NoError(t, err)
+	_, err = ignore.Memdis().Get("ignored2")
+	assert.Error(t, err)
+}
@@ -0,0 +1,187 @@
+package fscache
+
+import (
+	"errors"
+	"math"
+	"sort"
+)
+
+// earthRadius is the radius of the earth in meters used to compute distances, the same as Redis
+const earthRadius = 6372797.560856
+
+var (
+	// errInvalidCoordinates the coordinates are out of range
+	errInvalidCoordinates = errors.New("invalid coordinates")
+	// errMemberNotFound member not found
+	errMemberNotFound = errors.New("member not found")
+)
+
+type (
+	// GeoMember object is a member located at coordinates
+	GeoMember struct {
+		Name      string
+		Longitude float64
+		Latitude  float64
+		// Distance is the distance in meters from the center of a GeoRadius() query
+		Distance float64
+	}
+
+	// geoPoint holds the coordinates of a member
+	geoPoint struct {
+		longitude float64
+		latitude  float64
+	}
+)
+
+// GeoAdd() stores the coordinates of members under key, replacing the ones of the members already added.
+// Geo keys live next to the key value datas in their own keyspace.
+func (md *Memdis) GeoAdd(key string, members ...GeoMember) (err error) {
+	defer recoverPanic(&err)
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return err
+	}
+
+	for _, member := range members {
+		if math.Abs(member.Longitude) > 180 || math.Abs(member.Latitude) > 85.05112878 {
+			return errInvalidCoordinates
+		}
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	if md.geo == nil {
+		md.geo = make(map[string]map[string]geoPoint)
+	}
+	if md.geo[key] == nil {
+		md.geo[key] = make(map[string]geoPoint, len(members))
+	}
+	for _, member := range members {
+		md.geo[key][member.Name] = geoPoint{
+			longitude: member.Longitude,
+			latitude:  member.Latitude,
+		}
+	}
+
+	return nil
+}
+
+// GeoPos() returns the coordinates of a member
+func (md *Memdis) GeoPos(key, member string) (_ GeoMember, err error) {
+	defer recoverPanic(&err)
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return GeoMember{}, err
+	}
+
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
+	points, ok := md.geo[key]
+	if !ok {
+		return GeoMember{}, errKeyNotFound
+	}
+
+	point, ok := points[member]
+	if !ok {
+		return GeoMember{}, errMemberNotFound
+	}
+
+	return GeoMember{
+		Name:      member,
+		Longitude: point.longitude,
+		Latitude:  point.latitude,
+	}, nil
+}
+
+// GeoDist() returns the distance in meters between two members
+func (md *Memdis) GeoDist(key, member1, member2 string) (_ float64, err error) {
+	defer recoverPanic(&err)
+
+	from, err := md.GeoPos(key, member1)
+	if err != nil {
+		return 0, err
+	}
+
+	to, err := md.GeoPos(key, member2)
+	if err != nil {
+		return 0, err
+	}
+
+	return distance(from.Longitude, from.Latitude, to.Longitude, to.Latitude), nil
+}
+
+// GeoRadius() returns the members within radius meters of the given coordinates, nearest first
+func (md *Memdis) GeoRadius(key string, longitude, latitude, radius float64) (_ []GeoMember, err error) {
+	defer recoverPanic(&err)
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
+	points, ok := md.geo[key]
+	if !ok {
+		return nil, errKeyNotFound
+	}
+
+	var members []GeoMember
+	for name, point := range points {
+		if dist := distance(longitude, latitude, point.longitude, point.latitude); dist <= radius {
+			members = append(members, GeoMember{
+				Name:      name,
+				Longitude: point.longitude,
+				Latitude:  point.latitude,
+				Distance:  dist,
+			})
+		}
+	}
+
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Distance < members[j].Distance
+	})
+
+	return members, nil
+}
+
+// GeoRem() removes members from key, and key itself once it has no member left
+func (md *Memdis) GeoRem(key string, members ...string) (err error) {
+	defer recoverPanic(&err)
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	points, ok := md.geo[key]
+	if !ok {
+		return errKeyNotFound
+	}
+
+	for _, member := range members {
+		delete(points, member)
+	}
+	if len(points) == 0 {
+		delete(md.geo, key)
+	}
+
+	return nil
+}
+
+// distance returns the great-circle distance in meters between two coordinates using the haversine formula
+func distance(longitude1, latitude1, longitude2, latitude2 float64) float64 {
+	lat1, lat2 := latitude1*math.Pi/180, latitude2*math.Pi/180
+	u := math.Sin((lat2 - lat1) / 2)
+	v := math.Sin((longitude2 - longitude1) * math.Pi / 180 / 2)
+
+	return 2 * earthRadius * math.Asin(math.Sqrt(u*u+math.Cos(lat1)*math.Cos(lat2)*v*v))
+}
@@ -0,0 +1,35 @@
+package fscache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGeo(t *testing.T) {
+	ch := Cache{}
+
+	assert.Equal(t, errInvalidCoordinates, ch.Memdis().GeoAdd("stores", GeoMember{Name: "nowhere", Longitude: 200}))
+	assert.NoError(t, ch.Memdis().GeoAdd("stores",
+		GeoMember{Name: "Palermo", Longitude: 13.361389, Latitude: 38.115556},
+		GeoMember{Name: "Catania", Longitude: 15.087269, Latitude: 37.502669},
+	))
+
+	dist, err := ch.Memdis().GeoDist("stores", "Palermo", "Catania")
+	assert.NoError(t, err)
+	assert.InDelta(t, 166274.1516, dist, 1)
+
+	members, err := ch.Memdis().GeoRadius("stores", 15, 37, 200000)
+	assert.NoError(t, err)
+	assert.Len(t, members, 2)
+	assert.Equal(t, "Catania", members[0].Name)
+	assert.InDelta(t, 56441.2574, members[0].Distance, 1)
+
+	members, err = ch.Memdis().GeoRadius("stores", 15, 37, 100000)
+	assert.NoError(t, err)
+	assert.Len(t, members, 1)
+
+	assert.NoError(t, ch.Memdis().GeoRem("stores", "Catania"))
+	_, err = ch.Memdis().GeoPos("stores", "Catania")
+	assert.Equal(t, errMemberNotFound, err)
+}
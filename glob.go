@@ -0,0 +1,168 @@
+package fscache
+
+import (
+	"errors"
+	"unicode/utf8"
+)
+
+var (
+	// errBadPattern the glob pattern is malformed
+	errBadPattern = errors.New("syntax error in pattern")
+)
+
+// KeysMatching() returns the keys in the storage matching the glob-style pattern, leaving out the expired ones.
+// Like the KEYS command of Redis, * matches any sequence of characters, ? any single character, [abc] and [a-z]
+// one of the characters, [^abc] any other character, and \ escapes the character following it.
+func (md *Memdis) KeysMatching(pattern string) (_ []string, err error) {
+	defer recoverPanic(&err)
+
+	if _, err := globMatch(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
+	keys := []string{}
+	md.snapshot(func(_ int, key string, value MemdisData) {
+		original := md.originalKey(key)
+		if matched, _ := globMatch(pattern, original); matched {
+			keys = append(keys, original)
+		}
+	})
+
+	return keys, nil
+}
+
+// globMatch reports whether s matches the glob-style pattern. The whole pattern is validated, whatever s.
+func globMatch(pattern, s string) (bool, error) {
+	// backtrack to the last star when a match fails after it
+	starPattern, starS := -1, 0
+	p, i := 0, 0
+	for i < len(s) {
+		if p < len(pattern) {
+			switch pattern[p] {
+			case '*':
+				starPattern, starS = p, i
+				p++
+				continue
+			case '?':
+				_, size := utf8.DecodeRuneInString(s[i:])
+				p, i = p+1, i+size
+				continue
+			default:
+				r, size := utf8.DecodeRuneInString(s[i:])
+				matched, width, err := matchChar(pattern[p:], r)
+				if err != nil {
+					return false, err
+				}
+				if matched {
+					p, i = p+width, i+size
+					continue
+				}
+			}
+		}
+
+		if starPattern < 0 {
+			return false, validatePattern(pattern[p:])
+		}
+		_, size := utf8.DecodeRuneInString(s[starS:])
+		starS += size
+		p, i = starPattern+1, starS
+	}
+
+	for p < len(pattern) && pattern[p] == '*' {
+		p++
+	}
+
+	return p == len(pattern), validatePattern(pattern[p:])
+}
+
+// matchChar reports whether r matches the character or class at the start of pattern, along with its width
+func matchChar(pattern string, r rune) (bool, int, error) {
+	switch pattern[0] {
+	case '\\':
+		if len(pattern) < 2 {
+			return false, 0, errBadPattern
+		}
+		c, size := utf8.DecodeRuneInString(pattern[1:])
+		return c == r, 1 + size, nil
+	case '[':
+		return matchClass(pattern, r)
+	}
+
+	c, size := utf8.DecodeRuneInString(pattern)
+	return c == r, size, nil
+}
+
+// matchClass reports whether r matches the class at the start of pattern, along with its width
+func matchClass(pattern string, r rune) (bool, int, error) {
+	i := 1
+	negated := i < len(pattern) && (pattern[i] == '^' || pattern[i] == '!')
+	if negated {
+		i++
+	}
+
+	matched := false
+	for first := true; ; first = false {
+		if i >= len(pattern) {
+			return false, 0, errBadPattern
+		}
+		if pattern[i] == ']' && !first {
+			i++
+			break
+		}
+
+		lo, size, err := classChar(pattern[i:])
+		if err != nil {
+			return false, 0, err
+		}
+		i += size
+		hi := lo
+		if i+1 < len(pattern) && pattern[i] == '-' && pattern[i+1] != ']' {
+			hi, size, err = classChar(pattern[i+1:])
+			if err != nil {
+				return false, 0, err
+			}
+			i += 1 + size
+		}
+
+		if lo <= r && r <= hi {
+			matched = true
+		}
+	}
+
+	return matched != negated, i, nil
+}
+
+// classChar returns the possibly escaped character at the start of pattern, along with its width
+func classChar(pattern string) (rune, int, error) {
+	if pattern[0] == '\\' {
+		if len(pattern) < 2 {
+			return 0, 0, errBadPattern
+		}
+		c, size := utf8.DecodeRuneInString(pattern[1:])
+		return c, 1 + size, nil
+	}
+
+	c, size := utf8.DecodeRuneInString(pattern)
+	return c, size, nil
+}
+
+// validatePattern returns errBadPattern if the rest of a pattern is malformed
+func validatePattern(pattern string) error {
+	for p := 0; p < len(pattern); {
+		switch pattern[p] {
+		case '*', '?':
+			p++
+		default:
+			_, width, err := matchChar(pattern[p:], utf8.RuneError)
+			if err != nil {
+				return err
+			}
+			p += width
+		}
+	}
+
+	return nil
+}
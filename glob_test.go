@@ -0,0 +1,43 @@
+package fscache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeysMatching(t *testing.T) {
+	ch := Cache{}
+	for _, key := range []string{"user:1", "user:22", "user/admin", "session:ab", "session:abc", "s[1]"} {
+		assert.NoError(t, ch.Memdis().Set(key, key))
+	}
+	assert.NoError(t, ch.Memdis().Set("user:expired", "expired", time.Nanosecond))
+	time.Sleep(time.Millisecond)
+
+	tests := []struct {
+		pattern string
+		keys    []string
+	}{
+		{"user:*", []string{"user:1", "user:22"}},
+		{"user*", []string{"user:1", "user:22", "user/admin"}},
+		{"session:??", []string{"session:ab"}},
+		{"session:*c", []string{"session:abc"}},
+		{"user:[12]*", []string{"user:1", "user:22"}},
+		{"user:[^1]*", []string{"user:22"}},
+		{"user[!:]*", []string{"user/admin"}},
+		{"user:[a-z]*", []string{}},
+		{`s\[1\]`, []string{"s[1]"}},
+		{"*", []string{"user:1", "user:22", "user/admin", "session:ab", "session:abc", "s[1]"}},
+	}
+	for _, tt := range tests {
+		keys, err := ch.Memdis().KeysMatching(tt.pattern)
+		assert.NoError(t, err, tt.pattern)
+		assert.ElementsMatch(t, tt.keys, keys, tt.pattern)
+	}
+
+	_, err := ch.Memdis().KeysMatching("user:[12")
+	assert.ErrorIs(t, err, errBadPattern)
+	_, err = ch.Memdis().KeysMatching(`user:\`)
+	assert.ErrorIs(t, err, errBadPattern)
+}
@@ -7,6 +7,7 @@ require (
 	github.com/robfig/cron/v3 v3.0.0
 	github.com/rs/zerolog v1.32.0
 	github.com/stretchr/testify v1.9.0
+	golang.org/x/text v0.21.0
 )
 
 require (
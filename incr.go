@@ -0,0 +1,148 @@
+package fscache
+
+import (
+	"errors"
+	"math"
+	"reflect"
+)
+
+var (
+	// ErrNotNumeric is returned by Incr(), Decr() and IncrFloat() when the value of the key is not a number
+	ErrNotNumeric = errors.New("value is not a number")
+	// ErrNotInteger is returned by Incr() and Decr() when the value of the key is a float, use IncrFloat() instead
+	ErrNotInteger = errors.New("value is not an integer")
+	// errOverflow the new value doesn't fit in the type of the value
+	errOverflow = errors.New("increment would overflow")
+)
+
+// Incr() atomically adds delta to the integer value of key, and returns the new value. The value keeps its type,
+// e.g. an int stays an int. A key which doesn't exist is set to delta as an int, and the time to live of the key
+// is kept. It returns ErrNotInteger for float values and ErrNotNumeric for the other values.
+func (md *Memdis) Incr(key string, delta int64) (_ int64, err error) {
+	defer recoverPanic(&err)
+
+	var result int64
+	err = md.mutateNumber(key, 0, func(value reflect.Value) (reflect.Value, error) {
+		updated := reflect.New(value.Type()).Elem()
+
+		switch value.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			current := value.Int()
+			if (delta > 0 && current > math.MaxInt64-delta) || (delta < 0 && current < math.MinInt64-delta) {
+				return value, errOverflow
+			}
+			result = current + delta
+			if updated.OverflowInt(result) {
+				return value, errOverflow
+			}
+			updated.SetInt(result)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			current := value.Uint()
+			if current > math.MaxInt64 || (delta > 0 && int64(current) > math.MaxInt64-delta) || (delta < 0 && int64(current) < -delta) {
+				return value, errOverflow
+			}
+			result = int64(current) + delta
+			if result < 0 || updated.OverflowUint(uint64(result)) {
+				return value, errOverflow
+			}
+			updated.SetUint(uint64(result))
+		case reflect.Float32, reflect.Float64:
+			return value, ErrNotInteger
+		default:
+			return value, ErrNotNumeric
+		}
+
+		return updated, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
+// Decr() atomically subtracts delta from the integer value of key like Incr(), and returns the new value
+func (md *Memdis) Decr(key string, delta int64) (int64, error) {
+	if delta == math.MinInt64 {
+		return 0, errOverflow
+	}
+
+	return md.Incr(key, -delta)
+}
+
+// IncrFloat() atomically adds delta to the numeric value of key, and returns the new value. Float values keep their
+// type and integer values become float64. A key which doesn't exist is set to delta as a float64, and the time to
+// live of the key is kept. It returns ErrNotNumeric for the values which are not numbers.
+func (md *Memdis) IncrFloat(key string, delta float64) (_ float64, err error) {
+	defer recoverPanic(&err)
+
+	var result float64
+	err = md.mutateNumber(key, float64(0), func(value reflect.Value) (reflect.Value, error) {
+		switch value.Kind() {
+		case reflect.Float32, reflect.Float64:
+			result = value.Float() + delta
+			updated := reflect.New(value.Type()).Elem()
+			updated.SetFloat(result)
+			return updated, nil
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			result = float64(value.Int()) + delta
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+			result = float64(value.Uint()) + delta
+		default:
+			return value, ErrNotNumeric
+		}
+
+		return reflect.ValueOf(result), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
+// mutateNumber atomically replaces the value of key with the one mutate returns for it. A key which doesn't exist
+// is set to the one mutate returns for zero.
+func (md *Memdis) mutateNumber(key string, zero interface{}, mutate func(value reflect.Value) (reflect.Value, error)) (err error) {
+	if err := md.chaos.inject(); err != nil {
+		return err
+	}
+
+	key, digestOf, err := md.digestedKey(key)
+	if err != nil {
+		return err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	index, data, ok := md.lookup(key)
+	if !ok {
+		md.dropExpired(key)
+		if ok, err := md.room(1); !ok {
+			return err
+		}
+
+		created, err := mutate(reflect.ValueOf(zero))
+		if err != nil {
+			return err
+		}
+		md.insert(key, MemdisData{Value: created.Interface(), digestOf: digestOf})
+
+		return nil
+	}
+
+	if data.Value == nil {
+		return ErrNotNumeric
+	}
+
+	updated, err := mutate(reflect.ValueOf(data.Value))
+	if err != nil {
+		return err
+	}
+
+	data.Value = updated.Interface()
+	md.replace(index, key, data)
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package fscache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIncr(t *testing.T) {
+	ch := Cache{}
+
+	// a missing key is created at zero
+	n, err := ch.Memdis().Incr("hits", 2)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, n)
+	value, _ := ch.Memdis().Get("hits")
+	assert.Equal(t, 2, value)
+
+	n, err = ch.Memdis().Decr("hits", 5)
+	assert.NoError(t, err)
+	assert.EqualValues(t, -3, n)
+
+	// the value keeps its type and ttl
+	assert.NoError(t, ch.Memdis().Set("small", uint8(250), time.Minute))
+	n, err = ch.Memdis().Incr("small", 5)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 255, n)
+	value, _ = ch.Memdis().Get("small")
+	assert.Equal(t, uint8(255), value)
+	ttl, err := ch.Memdis().TTL("small")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+	_, err = ch.Memdis().Incr("small", 1)
+	assert.ErrorIs(t, err, errOverflow)
+	_, err = ch.Memdis().Decr("small", 256)
+	assert.ErrorIs(t, err, errOverflow)
+
+	assert.NoError(t, ch.Memdis().Set("name", "john"))
+	_, err = ch.Memdis().Incr("name", 1)
+	assert.ErrorIs(t, err, ErrNotNumeric)
+
+	assert.NoError(t, ch.Memdis().Set("ratio", 0.5))
+	_, err = ch.Memdis().Incr("ratio", 1)
+	assert.ErrorIs(t, err, ErrNotInteger)
+
+	f, err := ch.Memdis().IncrFloat("ratio", 0.25)
+	assert.NoError(t, err)
+	assert.Equal(t, 0.75, f)
+	f, err = ch.Memdis().IncrFloat("hits", 0.5)
+	assert.NoError(t, err)
+	assert.Equal(t, -2.5, f)
+
+	// concurrent increments are not lost
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := ch.Memdis().Incr("counter", 1)
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	value, _ = ch.Memdis().Get("counter")
+	assert.Equal(t, 50, value)
+}
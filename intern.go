@@ -0,0 +1,90 @@
+package fscache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// internedValue object is a value shared by all the datas holding the same content
+type internedValue struct {
+	value interface{}
+	refs  int
+}
+
+// WithValueInterning keeps a single copy in memory of identical string and []byte values of at least minSize bytes,
+// e.g. the same rendered fragment set under many keys. Interned []byte values are shared between the keys
+// holding them, so they must not be modified once set.
+func WithValueInterning(minSize int) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.internMinSize = minSize
+		c.MemdisInstance.interned = make(map[string]*internedValue)
+	}
+}
+
+// intern replaces the value of data with the shared copy of its content. The caller must hold md.state().mu.
+func (md *Memdis) intern(data MemdisData) MemdisData {
+	return internValue(md.interned, md.internMinSize, data)
+}
+
+// internValue replaces the value of data with the copy of its content shared in interned, nil when the values are
+// not interned, if it is at least minSize bytes
+func internValue(interned map[string]*internedValue, minSize int, data MemdisData) MemdisData {
+	data.internKey = ""
+	if interned == nil {
+		return data
+	}
+
+	var content []byte
+	switch value := data.Value.(type) {
+	case string:
+		if len(value) < minSize {
+			return data
+		}
+		content = []byte("s" + value)
+	case []byte:
+		if len(value) < minSize {
+			return data
+		}
+		content = append([]byte("b"), value...)
+	default:
+		return data
+	}
+
+	sum := sha256.Sum256(content)
+	data.internKey = hex.EncodeToString(sum[:])
+
+	shared, ok := interned[data.internKey]
+	if !ok {
+		shared = &internedValue{value: data.Value}
+		interned[data.internKey] = shared
+	}
+	shared.refs++
+	data.Value = shared.value
+
+	return data
+}
+
+// release drops the reference of data to its shared value. The caller must hold md.state().mu.
+func (md *Memdis) release(data MemdisData) {
+	if md.interned == nil || data.internKey == "" {
+		return
+	}
+
+	shared, ok := md.interned[data.internKey]
+	if !ok {
+		return
+	}
+
+	shared.refs--
+	if shared.refs <= 0 {
+		delete(md.interned, data.internKey)
+	}
+}
+
+// InternedValues() returns the number of distinct values shared by the datas of the in-memmory storage
+func (md *Memdis) InternedValues() int {
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
+	return len(md.interned)
+}
@@ -0,0 +1,31 @@
+package fscache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValueInterning(t *testing.T) {
+	ch := Cache{}
+	WithValueInterning(8)(&ch)
+
+	fragment := "<div>rendered fragment</div>"
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, ch.Memdis().Set(fmt.Sprintf("fragment%d", i), string([]byte(fragment))))
+	}
+	assert.NoError(t, ch.Memdis().Set("small", "tiny"))
+	assert.EqualValues(t, 1, ch.Memdis().InternedValues())
+
+	value, err := ch.Memdis().Get("fragment1")
+	assert.NoError(t, err)
+	assert.EqualValues(t, fragment, value)
+
+	assert.NoError(t, ch.Memdis().Del("fragment0"))
+	assert.NoError(t, ch.Memdis().OverWrite("fragment1", "something else entirely"))
+	assert.EqualValues(t, 2, ch.Memdis().InternedValues())
+
+	assert.NoError(t, ch.Memdis().Del("fragment2"))
+	assert.EqualValues(t, 1, ch.Memdis().InternedValues())
+}
@@ -0,0 +1,41 @@
+package fscache
+
+import (
+	"context"
+	"time"
+)
+
+// WithJanitorInterval makes a background janitor remove the expired datas every interval, instead of every minute
+// with the cronJob, so short lived datas don't hold memory until the next minute. It is started by Start(), or New(),
+// and stopped by Stop() or Close().
+func WithJanitorInterval(interval time.Duration) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.janitorInterval = interval
+	}
+}
+
+// janitor removes the expired datas every md.janitorInterval until ctx is done
+func (md *Memdis) janitor(ctx context.Context) {
+	defer logPanic(md.logger)
+
+	ticker := time.NewTicker(md.janitorInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			md.expire()
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Close() stops the background jobs of the cache, waiting for them to complete, and closes the channel of
+// Memdis.Events(). The cache stays usable, and Start() can start the jobs again.
+func (c *Cache) Close() error {
+	err := c.Stop(context.Background())
+	c.MemdisInstance.closeEvents()
+
+	return err
+}
@@ -0,0 +1,28 @@
+package fscache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJanitor(t *testing.T) {
+	ch := NewCache(WithJanitorInterval(5 * time.Millisecond))
+	assert.NoError(t, ch.Start(context.Background()))
+
+	assert.NoError(t, ch.Memdis().Set("short", "value", 10*time.Millisecond))
+	assert.NoError(t, ch.Memdis().Set("long", "value"))
+
+	assert.Eventually(t, func() bool {
+		ch.Memdis().state().mu.RLock()
+		defer ch.Memdis().state().mu.RUnlock()
+		_, ok := ch.Memdis().storage["short"]
+		return !ok
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, 1, ch.Memdis().Size())
+
+	assert.NoError(t, ch.Close())
+	assert.NoError(t, ch.Close())
+}
@@ -0,0 +1,195 @@
+package fscache
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// errInvalidPath path is not valid
+	errInvalidPath = errors.New("invalid path")
+	// errPathNotFound path not found
+	errPathNotFound = errors.New("path not found")
+)
+
+// pathStep is a field name or a slice index of a path
+type pathStep struct {
+	field string
+	index int
+	// isIndex is set when the step is a slice index
+	isIndex bool
+}
+
+// GetPath() retrieves a nested field of a structured value, e.g. "a.b[2].c" for the field c of the third
+// element of the slice b of the map a. Maps with string keys and slices are supported, and the whole value
+// is returned for an empty path.
+func (md *Memdis) GetPath(key, path string) (_ interface{}, err error) {
+	defer recoverPanic(&err)
+
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := md.Get(key)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, step := range steps {
+		v := reflect.ValueOf(value)
+		switch {
+		case step.isIndex && (v.Kind() == reflect.Slice || v.Kind() == reflect.Array):
+			if step.index >= v.Len() {
+				return nil, errPathNotFound
+			}
+			value = v.Index(step.index).Interface()
+		case !step.isIndex && v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+			field := v.MapIndex(reflect.ValueOf(step.field).Convert(v.Type().Key()))
+			if !field.IsValid() {
+				return nil, errPathNotFound
+			}
+			value = field.Interface()
+		default:
+			return nil, errPathNotFound
+		}
+	}
+
+	return value, nil
+}
+
+// SetPath() sets a nested field of a structured value, creating the maps missing along the path, e.g. "a.b[2].c"
+// for the field c of the third element of the slice b of the map a. Only the maps and slices along the path are
+// copied, so the values already returned by Get() are never modified. The value must be made of
+// map[string]interface{} and []interface{} like the values decoded from json.
+func (md *Memdis) SetPath(key, path string, value interface{}) (err error) {
+	defer recoverPanic(&err)
+
+	steps, err := parsePath(path)
+	if err != nil {
+		return err
+	}
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	index, data, ok := md.lookup(key)
+	if !ok {
+		return errKeyNotFound
+	}
+
+	data.Value, err = setPath(data.Value, steps, value)
+	if err != nil {
+		return err
+	}
+	md.replace(index, key, data)
+
+	return nil
+}
+
+// setPath returns a copy of container with the value at steps set to value
+func setPath(container interface{}, steps []pathStep, value interface{}) (interface{}, error) {
+	if len(steps) == 0 {
+		return value, nil
+	}
+
+	step := steps[0]
+	switch c := container.(type) {
+	case map[string]interface{}:
+		if step.isIndex {
+			return nil, errPathNotFound
+		}
+
+		field, err := setPath(c[step.field], steps[1:], value)
+		if err != nil {
+			return nil, err
+		}
+
+		copied := make(map[string]interface{}, len(c)+1)
+		for k, v := range c {
+			copied[k] = v
+		}
+		copied[step.field] = field
+
+		return copied, nil
+	case []interface{}:
+		if !step.isIndex || step.index >= len(c) {
+			return nil, errPathNotFound
+		}
+
+		element, err := setPath(c[step.index], steps[1:], value)
+		if err != nil {
+			return nil, err
+		}
+
+		copied := append([]interface{}(nil), c...)
+		copied[step.index] = element
+
+		return copied, nil
+	case nil:
+		// create the missing maps along the path
+		if step.isIndex {
+			return nil, errPathNotFound
+		}
+
+		return setPath(map[string]interface{}{}, steps, value)
+	}
+
+	return nil, errPathNotFound
+}
+
+// parsePath splits a path like "a.b[2].c" into its steps. A leading "$" for the root is allowed.
+func parsePath(path string) ([]pathStep, error) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+
+	var steps []pathStep
+	for path != "" {
+		if path[0] == '[' {
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, errInvalidPath
+			}
+
+			index, err := strconv.Atoi(path[1:end])
+			if err != nil || index < 0 {
+				return nil, errInvalidPath
+			}
+			steps = append(steps, pathStep{index: index, isIndex: true})
+
+			path = path[end+1:]
+			if strings.HasPrefix(path, ".") {
+				path = path[1:]
+				if path == "" {
+					return nil, errInvalidPath
+				}
+			}
+			continue
+		}
+
+		end := strings.IndexAny(path, ".[")
+		if end < 0 {
+			end = len(path)
+		}
+		if end == 0 {
+			return nil, errInvalidPath
+		}
+		steps = append(steps, pathStep{field: path[:end]})
+
+		path = path[end:]
+		if strings.HasPrefix(path, ".") {
+			path = path[1:]
+			if path == "" {
+				return nil, errInvalidPath
+			}
+		}
+	}
+
+	return steps, nil
+}
@@ -0,0 +1,44 @@
+package fscache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPathSetPath(t *testing.T) {
+	ch := Cache{}
+
+	doc := map[string]interface{}{
+		"user": map[string]interface{}{
+			"name": "john",
+			"addresses": []interface{}{
+				map[string]interface{}{"city": "Paris"},
+				map[string]interface{}{"city": "Lagos"},
+			},
+		},
+	}
+	assert.NoError(t, ch.Memdis().Set("doc", doc))
+
+	value, err := ch.Memdis().GetPath("doc", "user.addresses[1].city")
+	assert.NoError(t, err)
+	assert.Equal(t, "Lagos", value)
+
+	_, err = ch.Memdis().GetPath("doc", "user.addresses[5].city")
+	assert.Equal(t, errPathNotFound, err)
+	_, err = ch.Memdis().GetPath("doc", "user.addresses[x]")
+	assert.Equal(t, errInvalidPath, err)
+
+	assert.NoError(t, ch.Memdis().SetPath("doc", "$.user.addresses[1].city", "Abuja"))
+	assert.NoError(t, ch.Memdis().SetPath("doc", "user.settings.theme", "dark"))
+
+	value, err = ch.Memdis().GetPath("doc", "user.addresses[1].city")
+	assert.NoError(t, err)
+	assert.Equal(t, "Abuja", value)
+	value, err = ch.Memdis().GetPath("doc", "user.settings.theme")
+	assert.NoError(t, err)
+	assert.Equal(t, "dark", value)
+
+	// the value set before is left untouched
+	assert.Equal(t, "Lagos", doc["user"].(map[string]interface{})["addresses"].([]interface{})[1].(map[string]interface{})["city"])
+}
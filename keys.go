@@ -0,0 +1,219 @@
+package fscache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+var (
+	// errInvalidKey key is not valid
+	errInvalidKey = errors.New("invalid key")
+)
+
+// KeyTransform canonicalizes and validates a key before it is used by any Memdis operation
+type KeyTransform func(key string) (string, error)
+
+// WithKeyTransform applies transform to every key used on Memdis, so keys like "User:1" and "user:1"
+// can't end up as two different datas. Operations return the error of transform for rejected keys.
+func WithKeyTransform(transform KeyTransform) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.keyTransform = transform
+	}
+}
+
+// ChainKeyTransforms returns a KeyTransform running transforms in order
+func ChainKeyTransforms(transforms ...KeyTransform) KeyTransform {
+	return func(key string) (string, error) {
+		var err error
+		for _, transform := range transforms {
+			if key, err = transform(key); err != nil {
+				return "", err
+			}
+		}
+
+		return key, nil
+	}
+}
+
+// KeyLower lowercases keys
+func KeyLower(key string) (string, error) {
+	return strings.ToLower(key), nil
+}
+
+// KeyTrim trims leading and trailing white spaces from keys
+func KeyTrim(key string) (string, error) {
+	return strings.TrimSpace(key), nil
+}
+
+// KeyRejectControl rejects empty keys and keys containing control characters
+func KeyRejectControl(key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("%w: key cannot be empty", errInvalidKey)
+	}
+
+	for _, r := range key {
+		if unicode.IsControl(r) {
+			return "", fmt.Errorf("%w: key %q contains control characters", errInvalidKey, key)
+		}
+	}
+
+	return key, nil
+}
+
+// KeyMaxLength rejects keys longer than max bytes
+func KeyMaxLength(max int) KeyTransform {
+	return func(key string) (string, error) {
+		if len(key) > max {
+			return "", fmt.Errorf("%w: key is longer than %d bytes", errInvalidKey, max)
+		}
+
+		return key, nil
+	}
+}
+
+// WithKeyDigest stores keys longer than threshold bytes as their SHA-256 digest, so full URLs or SQL text
+// can be used as keys without bloating memory. Keys() still returns the original keys.
+func WithKeyDigest(threshold int) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.keyDigestThreshold = threshold
+	}
+}
+
+// canonicalKey applies the key transform to key, and digests it if it is too long
+func (md *Memdis) canonicalKey(key string) (string, error) {
+	canonical, _, err := md.digestedKey(key)
+	return canonical, err
+}
+
+// digestedKey is canonicalKey for the writes: it also returns the key the canonical key is the digest of, which
+// the write sets as the digestOf of its data, or "" when the key is not digested
+func (md *Memdis) digestedKey(key string) (canonical, original string, err error) {
+	if md.keyTransform != nil {
+		if key, err = md.keyTransform(key); err != nil {
+			return "", "", err
+		}
+	}
+
+	if md.keyDigestThreshold <= 0 || len(key) <= md.keyDigestThreshold {
+		return key, "", nil
+	}
+
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:]), key, nil
+}
+
+// rememberDigest records original as the key digest was computed from, once its data is stored.
+// The caller must hold md.state().mu.
+func (md *Memdis) rememberDigest(digest, original string) {
+	md.state().digestsMu.Lock()
+	defer md.state().digestsMu.Unlock()
+
+	if md.digests == nil {
+		md.digests = make(map[string]string)
+	}
+	md.digests[digest] = original
+}
+
+// forgetDigest forgets the original key of key once its data is removed, unless the mapped snapshot still serves
+// it. It is called after the removal is notified, so the events carry the original key. The caller must hold
+// md.state().mu.
+func (md *Memdis) forgetDigest(key string) {
+	if md.keyDigestThreshold <= 0 {
+		return
+	}
+	if _, _, ok := md.lookup(key); ok {
+		return
+	}
+
+	md.state().digestsMu.Lock()
+	defer md.state().digestsMu.Unlock()
+
+	delete(md.digests, key)
+}
+
+// originalKey returns the key a stored key was digested from
+func (md *Memdis) originalKey(key string) string {
+	if md.keyDigestThreshold <= 0 {
+		return key
+	}
+
+	md.state().digestsMu.Lock()
+	defer md.state().digestsMu.Unlock()
+
+	if original, ok := md.digests[key]; ok {
+		return original
+	}
+
+	return key
+}
+
+// pruneDigests forgets the original keys of digests which are no longer in the storage, once the mapped snapshot
+// is released. The caller must hold md.state().mu.
+func (md *Memdis) pruneDigests() {
+	md.state().digestsMu.Lock()
+	defer md.state().digestsMu.Unlock()
+
+	for digest := range md.digests {
+		if _, ok := md.storage[digest]; !ok {
+			delete(md.digests, digest)
+		}
+	}
+}
+
+// canonicalKeys applies the key transform to keys, leaving out the rejected ones
+func (md *Memdis) canonicalKeys(keys []string) []string {
+	if md.keyTransform == nil && md.keyDigestThreshold <= 0 {
+		return keys
+	}
+
+	canonical := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if key, err := md.canonicalKey(key); err == nil {
+			canonical = append(canonical, key)
+		}
+	}
+
+	return canonical
+}
+
+// canonicalData applies the key transform to the keys of data
+func (md *Memdis) canonicalData(data []map[string]MemdisData) ([]map[string]MemdisData, error) {
+	if md.keyTransform == nil && md.keyDigestThreshold <= 0 {
+		return data, nil
+	}
+
+	var failed []*ItemError
+	canonical := make([]map[string]MemdisData, 0, len(data))
+	for _, cache := range data {
+		fs := make(map[string]MemdisData, len(cache))
+		for key, value := range cache {
+			canonicalKey, original, err := md.digestedKey(key)
+			if err != nil {
+				failed = append(failed, &ItemError{Index: -1, Key: key, Err: err})
+				continue
+			}
+			value.digestOf = original
+			fs[canonicalKey] = value
+		}
+		canonical = append(canonical, fs)
+	}
+
+	if err := bulkError(failed); err != nil {
+		return nil, err
+	}
+
+	return canonical, nil
+}
+
+// namespaceOf returns the namespace of key, the part before its first ":"
+func namespaceOf(key string) string {
+	if i := strings.Index(key, ":"); i >= 0 {
+		return key[:i]
+	}
+
+	return ""
+}
@@ -0,0 +1,77 @@
+package fscache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyTransform(t *testing.T) {
+	ch := Cache{}
+	WithKeyTransform(ChainKeyTransforms(KeyTrim, KeyLower, KeyRejectControl, KeyMaxLength(10)))(&ch)
+
+	if err := ch.Memdis().Set(" User:1 ", "value1"); err != nil {
+		assert.Error(t, err)
+	}
+
+	value, err := ch.Memdis().Get("user:1")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "value1", value)
+
+	err = ch.Memdis().Set("USER:1", "value2")
+	assert.Equal(t, errKeyExists, err)
+
+	err = ch.Memdis().Set("user\n2", "value2")
+	assert.ErrorIs(t, err, errInvalidKey)
+
+	err = ch.Memdis().Set("user:12345678", "value2")
+	assert.ErrorIs(t, err, errInvalidKey)
+
+	assert.EqualValues(t, []string{"user:1"}, ch.Memdis().Keys())
+}
+
+func TestKeyDigest(t *testing.T) {
+	ch := Cache{}
+	WithKeyDigest(16)(&ch)
+
+	longKey := "https://example.com/users?page=1&limit=100"
+	if err := ch.Memdis().Set(longKey, "page1"); err != nil {
+		assert.Error(t, err)
+	}
+
+	value, err := ch.Memdis().Get(longKey)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "page1", value)
+
+	// the key is stored as its digest, but Keys() still returns the original
+	for key := range ch.MemdisInstance.storage {
+		assert.Len(t, key, len("sha256:")+64)
+	}
+	assert.EqualValues(t, []string{longKey}, ch.Memdis().Keys())
+
+	// only the stored keys keep their original key: misses don't, and removals forget it
+	for i := 0; i < 100; i++ {
+		_, err := ch.Memdis().Get(fmt.Sprintf("https://example.com/missing?page=%d", i))
+		assert.Equal(t, errKeyNotFound, err)
+	}
+	assert.Len(t, ch.MemdisInstance.digests, 1)
+
+	assert.NoError(t, ch.Memdis().Del(longKey))
+	assert.Empty(t, ch.MemdisInstance.digests)
+
+	WithMaxEntries(1)(&ch)
+	events := ch.Memdis().Events()
+	assert.NoError(t, ch.Memdis().Set(longKey, "page1"))
+	assert.NoError(t, ch.Memdis().Set(longKey+"&sort=name", "page1"))
+	assert.Len(t, ch.MemdisInstance.digests, 1)
+	// the events still carry the original keys of the removed datas
+	for _, want := range []KeyEventType{KeySet, KeySet, KeyEvict} {
+		event := <-events
+		assert.Equal(t, want, event.Type)
+		assert.Contains(t, event.Key, "https://example.com/")
+	}
+
+	assert.NoError(t, ch.Memdis().Clear())
+	assert.Empty(t, ch.MemdisInstance.digests)
+}
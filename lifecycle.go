@@ -0,0 +1,79 @@
+package fscache
+
+import (
+	"context"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Start() starts the background jobs of the cache: the cronJob expiring and persisting the datas every minute,
+// the janitor of WithJanitorInterval() and the memory watcher of WithMemoryPressure(). It does nothing if they are already started. Its signature
+// matches the start hooks of dependency injection frameworks, e.g. fx.Hook{OnStart: cache.Start, OnStop: cache.Stop}.
+func (c *Cache) Start(ctx context.Context) error {
+	c.lifecycleMu.Lock()
+	defer c.lifecycleMu.Unlock()
+
+	if c.jobs != nil {
+		return nil
+	}
+
+	jobsCtx, cancel := context.WithCancel(context.Background())
+	c.stopJobs = cancel
+
+	if c.MemdisInstance.memoryPressure != nil {
+		c.background.Add(1)
+		go func() {
+			defer c.background.Done()
+			c.MemdisInstance.watchMemory(jobsCtx)
+		}()
+	}
+
+	if c.MemdisInstance.janitorInterval > 0 {
+		c.background.Add(1)
+		go func() {
+			defer c.background.Done()
+			c.MemdisInstance.janitor(jobsCtx)
+		}()
+	}
+
+	c.jobs = cron.New()
+
+	// cron job set to run every 1 minute
+	c.jobs.AddFunc("*/1 * * * *", c.runJobs)
+
+	c.jobs.Start()
+	if debug {
+		c.MemdisInstance.logger.Info().Msgf("cron job entries ::: %v", c.jobs.Entries())
+	}
+
+	return nil
+}
+
+// Stop() stops the background jobs of the cache, waiting for them to complete unless ctx is done first.
+// The cache stays usable, and Start() can start the jobs again.
+func (c *Cache) Stop(ctx context.Context) error {
+	c.lifecycleMu.Lock()
+	defer c.lifecycleMu.Unlock()
+
+	if c.jobs == nil {
+		return nil
+	}
+
+	c.stopJobs()
+	cronDone := c.jobs.Stop()
+	c.jobs = nil
+
+	done := make(chan struct{})
+	go func() {
+		<-cronDone.Done()
+		c.background.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,30 @@
+package fscache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLifecycle(t *testing.T) {
+	ch := NewCache(WithMemoryPressure(MemoryPressure{Interval: time.Millisecond}))
+	assert.Nil(t, ch.jobs)
+
+	ctx := context.Background()
+	assert.NoError(t, ch.Start(ctx))
+	jobs := ch.jobs
+	assert.NotNil(t, jobs)
+
+	// starting again keeps the running jobs
+	assert.NoError(t, ch.Start(ctx))
+	assert.Same(t, jobs, ch.jobs)
+
+	assert.NoError(t, ch.Stop(ctx))
+	assert.Nil(t, ch.jobs)
+	assert.NoError(t, ch.Stop(ctx))
+
+	// the cache stays usable once stopped
+	assert.NoError(t, ch.Memdis().Set("lifecycle", "value"))
+}
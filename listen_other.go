@@ -0,0 +1,14 @@
+//go:build !unix
+
+package fscache
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+// ListenUnix is not supported on this platform, the file permissions of unix sockets are not enforced
+func ListenUnix(path string, perm os.FileMode) (net.Listener, error) {
+	return nil, errors.New("unix sockets with file permissions are not supported on this platform")
+}
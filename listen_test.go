@@ -0,0 +1,53 @@
+package fscache
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListenUnix(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets with file permissions are not supported")
+	}
+
+	ch := Cache{}
+	assert.NoError(t, ch.Memdis().Set("key", "value"))
+
+	path := filepath.Join(t.TempDir(), "stats.sock")
+	listener, err := ListenUnix(path, 0o600)
+	assert.NoError(t, err)
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	go http.Serve(listener, ch.StatsHandler())
+
+	client := http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+	resp, err := client.Get("http://fscache/stats")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	var stats Stats
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&stats))
+	assert.Equal(t, 1, stats.Memdis.Datas)
+
+	// the socket file left by a previous run is replaced
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	assert.NoError(t, listener.Close())
+	listener, err = ListenUnix(path, 0o660)
+	assert.NoError(t, err)
+	assert.NoError(t, listener.Close())
+}
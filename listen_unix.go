@@ -0,0 +1,32 @@
+//go:build unix
+
+package fscache
+
+import (
+	"net"
+	"os"
+)
+
+// ListenUnix listens on the unix domain socket at path, so StatsHandler() can be served to a sidecar without
+// opening a TCP port, e.g. http.Serve(listener, cache.StatsHandler()). The socket file gets perm, so only the
+// users and groups it grants can connect. A socket file left at path by a previous run is removed first, and
+// the socket file is removed once the listener is closed.
+func ListenUnix(path string, perm os.FileMode) (net.Listener, error) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Chmod(path, perm); err != nil {
+		listener.Close()
+		return nil, err
+	}
+
+	return listener, nil
+}
@@ -0,0 +1,158 @@
+package fscache
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"runtime"
+	"sync"
+)
+
+const (
+	// loadBatchSize is the number of values decoded and inserted at once while loading a file
+	loadBatchSize = 1024
+)
+
+var (
+	// errInvalidJsonFile the file is not made of json values
+	errInvalidJsonFile = errors.New("invalid json file")
+)
+
+// WithLoadWorkers sets the number of goroutines decoding the values of the files loaded by LoadDefault(),
+// LoadFiles() and LoadSnapshot(), runtime.GOMAXPROCS by default.
+func WithLoadWorkers(workers int) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.loadWorkers = workers
+		c.MemgodbInstance.loadWorkers = workers
+	}
+}
+
+// loadWorkersOf returns the number of goroutines decoding the values of a file
+func loadWorkersOf(workers int) int {
+	if workers > 0 {
+		return workers
+	}
+
+	return runtime.GOMAXPROCS(0)
+}
+
+// decodeParallel decodes the values of f, either a json array or json values following each other like NDJSON,
+// and calls insert with each batch of values in the order of f. A goroutine splits the next batch while workers
+// goroutines decode the current one, so large files are neither read nor decoded as a whole before being inserted.
+func decodeParallel[T any](f io.Reader, workers int, insert func(values []T) error) error {
+	r := bufio.NewReaderSize(f, 1<<20)
+	first, err := firstByte(r)
+	if err == io.EOF {
+		return errInvalidJsonFile
+	} else if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(r)
+	inArray := first == '['
+	if inArray {
+		if _, err := dec.Token(); err != nil {
+			return errInvalidJsonFile
+		}
+	}
+
+	batches := make(chan []json.RawMessage, 1)
+	splitErr := make(chan error, 1)
+	go func() {
+		defer close(batches)
+
+		batch := make([]json.RawMessage, 0, loadBatchSize)
+		for {
+			if inArray && !dec.More() {
+				break
+			}
+
+			var raw json.RawMessage
+			if err := dec.Decode(&raw); err == io.EOF {
+				break
+			} else if err != nil {
+				splitErr <- errInvalidJsonFile
+				return
+			}
+
+			batch = append(batch, raw)
+			if len(batch) == loadBatchSize {
+				batches <- batch
+				batch = make([]json.RawMessage, 0, loadBatchSize)
+			}
+		}
+
+		if len(batch) > 0 {
+			batches <- batch
+		}
+		splitErr <- nil
+	}()
+
+	for batch := range batches {
+		if err != nil {
+			// drain the batches so the splitting goroutine can return
+			continue
+		}
+
+		values := make([]T, len(batch))
+		if err = decodeBatch(batch, values, workers); err == nil {
+			err = insert(values)
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+	return <-splitErr
+}
+
+// decodeBatch decodes the raw values of batch into values with workers goroutines
+func decodeBatch[T any](batch []json.RawMessage, values []T, workers int) error {
+	if workers > len(batch) {
+		workers = len(batch)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		errOnce sync.Once
+		err     error
+	)
+	chunk := (len(batch) + workers - 1) / workers
+	for start := 0; start < len(batch); start += chunk {
+		end := min(start+chunk, len(batch))
+
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+
+			for i := start; i < end; i++ {
+				if decodeErr := json.Unmarshal(batch[i], &values[i]); decodeErr != nil {
+					errOnce.Do(func() {
+						err = errInvalidJsonFile
+					})
+					return
+				}
+			}
+		}(start, end)
+	}
+	wg.Wait()
+
+	return err
+}
+
+// firstByte returns the first byte of r which is not a white space, without consuming it
+func firstByte(r *bufio.Reader) (byte, error) {
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b, r.UnreadByte()
+	}
+}
@@ -0,0 +1,68 @@
+package fscache
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadSnapshotNDJSON(t *testing.T) {
+	ch := Cache{}
+	WithLoadWorkers(2)(&ch)
+
+	fsys := fstest.MapFS{
+		"memdisstorage.json": {Data: []byte("{\"key\": \"ndjson1\", \"value\": \"one\"}\n{\"key\": \"ndjson2\", \"value\": \"two\"}\n")},
+	}
+	assert.NoError(t, ch.Memdis().LoadSnapshotFS(fsys))
+
+	value, err := ch.Memdis().Get("ndjson2")
+	assert.NoError(t, err)
+	assert.Equal(t, "two", value)
+}
+
+func Test_ParallelLoad(t *testing.T) {
+	ch := Cache{}
+	WithLoadWorkers(3)(&ch)
+
+	var ndjson strings.Builder
+	for i := 0; i < 3000; i++ {
+		fmt.Fprintf(&ndjson, "{\"colName\": \"ndjsonloads\", \"n\": %d}\n", i)
+	}
+	fsys := fstest.MapFS{
+		"memgodbstorage.json": {Data: []byte(ndjson.String())},
+	}
+	assert.NoError(t, ch.Memgodb().LoadDefaultFS(fsys))
+
+	records, err := ch.Memgodb().Collection("ndjsonload").Filter(map[string]interface{}{"colName": "ndjsonloads"}).All()
+	assert.NoError(t, err)
+	assert.Len(t, records, 3000)
+	// the records are inserted in the order of the file
+	assert.Equal(t, float64(0), records[0]["n"])
+	assert.Equal(t, float64(2999), records[2999]["n"])
+
+	invalid := fstest.MapFS{
+		"memgodbstorage.json": {Data: []byte("{\"colName\": \"ndjsonloads\"}\n{invalid")},
+	}
+	assert.Equal(t, errInvalidJsonFile, ch.Memgodb().LoadDefaultFS(invalid))
+}
+
+func Test_LoadFiles(t *testing.T) {
+	dir := t.TempDir()
+	shards := []string{dir + "/shard1.json", dir + "/shard2.ndjson"}
+	assert.NoError(t, os.WriteFile(shards[0], []byte(`[{"colName": "shardeds", "shard": 1}]`), 0644))
+	assert.NoError(t, os.WriteFile(shards[1], []byte("{\"colName\": \"shardeds\", \"shard\": 2}\n{\"colName\": \"shardeds\", \"shard\": 2}\n"), 0644))
+
+	ch := Cache{}
+	assert.NoError(t, ch.Memgodb().LoadFiles(shards...))
+
+	records, err := ch.Memgodb().Collection("sharded").Filter(map[string]interface{}{"colName": "shardeds"}).All()
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+
+	assert.Equal(t, errors.New("error finding file"), ch.Memgodb().LoadFiles(dir+"/missing.json"))
+}
@@ -0,0 +1,306 @@
+package fscache
+
+import (
+	"context"
+	"time"
+)
+
+// Loader loads the value of a key from the backing source when it is not in the cache
+type Loader func(key string) (interface{}, error)
+
+// LoaderContext loads the value of a key from the backing source like Loader, with the context of the caller
+type LoaderContext func(ctx context.Context, key string) (interface{}, error)
+
+// pendingLoad is a load in flight and its outcome once done is closed
+type pendingLoad struct {
+	done  chan struct{}
+	value interface{}
+	err   error
+}
+
+// GetOrLoad() retrieves a data from the in-memmory storage, or loads and sets it using loader if it is not found.
+// The concurrent misses of a key call loader once, and all return its outcome.
+// The loader is kept with the data so it can be refreshed ahead of its expiration (see WithRefreshAhead).
+func (md *Memdis) GetOrLoad(key string, loader Loader, duration ...time.Duration) (_ interface{}, err error) {
+	defer recoverPanic(&err)
+
+	return md.GetOrLoadContext(context.Background(), key, func(_ context.Context, key string) (interface{}, error) {
+		return loader(key)
+	}, duration...)
+}
+
+// GetOrLoadContext() retrieves a data from the in-memmory storage like GetOrLoad(), and passes ctx to loader so it can
+// respect the deadline of the caller and read its request metadata (trace id, tenant id...). The concurrent misses of
+// a key share the load of the first one, with its ctx. The refreshes ahead of the expiration run in the background
+// with the values of ctx, but neither its deadline nor its cancellation.
+func (md *Memdis) GetOrLoadContext(ctx context.Context, key string, loader LoaderContext, duration ...time.Duration) (_ interface{}, err error) {
+	defer recoverPanic(&err)
+
+	var hit bool
+	original := key
+	if md.recorder != nil {
+		start := time.Now()
+		defer func() {
+			trace := traceOf(TraceGet, original, 0, start, err)
+			trace.Hit = hit
+			md.recorder.record(trace)
+		}()
+	}
+
+	if err := md.chaos.inject(); err != nil {
+		return nil, err
+	}
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	defer md.flushReadsIfFull()
+	md.state().mu.RLock()
+	if index, val, ok := md.lookup(key); ok {
+		hit = true
+		md.read(index, key)
+		md.refreshAheadIfNeeded(ctx, key, val)
+		md.state().mu.RUnlock()
+		return val.Value, nil
+	}
+	md.readMissed(key)
+	md.state().mu.RUnlock()
+
+	return md.loadOnce(ctx, key, func() (interface{}, error) {
+		release, err := md.loaderLimits.acquire(ctx, original)
+		if err != nil {
+			return nil, err
+		}
+		defer release()
+
+		start := time.Now()
+		value, err := loader(ctx, original)
+		if err != nil {
+			return nil, err
+		}
+		loadTime := time.Since(start)
+
+		ttl := md.ttlOf(duration)
+
+		md.state().mu.Lock()
+		defer md.state().mu.Unlock()
+
+		md.storeLoaded(key, original, value, loader, ttl, loadTime)
+
+		return value, nil
+	})
+}
+
+// loadOnce calls load for key unless a load of key is already in flight, in which case it waits for its outcome
+// instead, or for ctx to be done
+func (md *Memdis) loadOnce(ctx context.Context, key string, load func() (interface{}, error)) (interface{}, error) {
+	md.state().loadingMu.Lock()
+	if pending, ok := md.loading[key]; ok {
+		md.state().loadingMu.Unlock()
+
+		select {
+		case <-pending.done:
+			return pending.value, pending.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	if md.loading == nil {
+		md.loading = make(map[string]*pendingLoad)
+	}
+	// the waiters get ErrPanic if load panics
+	pending := &pendingLoad{done: make(chan struct{}), err: ErrPanic}
+	md.loading[key] = pending
+	md.state().loadingMu.Unlock()
+
+	defer func() {
+		md.state().loadingMu.Lock()
+		delete(md.loading, key)
+		md.state().loadingMu.Unlock()
+		close(pending.done)
+	}()
+
+	pending.value, pending.err = load()
+
+	return pending.value, pending.err
+}
+
+// ManyLoader loads the values of the missing keys from the backing source in one call
+type ManyLoader func(missing []string) (map[string]interface{}, error)
+
+// ManyLoaderContext loads the values of the missing keys like ManyLoader, with the context of the caller
+type ManyLoaderContext func(ctx context.Context, missing []string) (map[string]interface{}, error)
+
+// GetOrLoadMany() retrieves datas with matching keys from the in-memmory storage, and loads the missing ones
+// with a single call to loader. The loaded datas are set with duration. Keys the loader doesn't return
+// a value for are left out of the result.
+func (md *Memdis) GetOrLoadMany(keys []string, loader ManyLoader, duration ...time.Duration) (_ map[string]interface{}, err error) {
+	defer recoverPanic(&err)
+
+	return md.GetOrLoadManyContext(context.Background(), keys, func(_ context.Context, missing []string) (map[string]interface{}, error) {
+		return loader(missing)
+	}, duration...)
+}
+
+// GetOrLoadManyContext() retrieves datas with matching keys like GetOrLoadMany(), and passes ctx to loader
+func (md *Memdis) GetOrLoadManyContext(ctx context.Context, keys []string, loader ManyLoaderContext, duration ...time.Duration) (_ map[string]interface{}, err error) {
+	defer recoverPanic(&err)
+
+	if err := md.chaos.inject(); err != nil {
+		return nil, err
+	}
+
+	canonical := make(map[string]string, len(keys))
+	for _, key := range keys {
+		canonicalKey, err := md.canonicalKey(key)
+		if err != nil {
+			return nil, err
+		}
+		canonical[key] = canonicalKey
+	}
+
+	result := make(map[string]interface{}, len(keys))
+	var missing []string
+
+	defer md.flushReadsIfFull()
+	md.state().mu.RLock()
+	for _, key := range keys {
+		if _, ok := result[key]; ok {
+			continue
+		}
+
+		index, val, ok := md.lookup(canonical[key])
+		if !ok {
+			md.readMissed(canonical[key])
+			missing = append(missing, key)
+			continue
+		}
+
+		md.read(index, canonical[key])
+		result[key] = val.Value
+	}
+	md.state().mu.RUnlock()
+
+	if len(missing) == 0 {
+		return result, nil
+	}
+
+	release, err := md.loaderLimits.acquire(ctx, missing...)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	loaded, err := loader(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := md.ttlOf(duration)
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	for _, key := range missing {
+		value, ok := loaded[key]
+		if !ok {
+			continue
+		}
+
+		md.storeLoaded(canonical[key], key, value, nil, ttl, 0)
+		result[key] = value
+	}
+
+	return result, nil
+}
+
+// storeLoaded sets or replaces the data of key returned by loader for loadedKey, the key given by the caller, in
+// loadTime. Without loader, the data keeps the loader it was set with, if any. The caller must hold md.state().mu.
+func (md *Memdis) storeLoaded(key, loadedKey string, value interface{}, loader LoaderContext, ttl, loadTime time.Duration) {
+	// the stored data may have expired, its loader is still the one of the key
+	if prev, ok := md.storage[key]; ok && loader == nil && prev.loader != nil {
+		loader, loadedKey = prev.loader, prev.loadedKey
+	}
+
+	data := MemdisData{
+		Value:     value,
+		Duration:  expiresAt(ttl),
+		loader:    loader,
+		loadedKey: loadedKey,
+		TTL:       ttl,
+		loadTime:  loadTime,
+	}
+	// the original key of a digested key is recorded once it is stored
+	_, data.digestOf, _ = md.digestedKey(loadedKey)
+
+	if index, _, ok := md.lookup(key); ok {
+		md.replace(index, key, data)
+		return
+	}
+
+	// the loaded value is still returned when there is no room to keep it
+	if ok, _ := md.room(costOf(data)); ok {
+		md.insert(key, data)
+	}
+}
+
+// refreshAheadIfNeeded reloads a data in the background if it is close to its expiration, with the values of ctx
+func (md *Memdis) refreshAheadIfNeeded(ctx context.Context, key string, data MemdisData) {
+	if data.loader == nil || data.Duration.IsZero() {
+		return
+	}
+
+	remaining := time.Until(data.Duration)
+	if (md.refreshAhead <= 0 || remaining > md.refreshAhead) && !md.expiresEarly(data, remaining) {
+		return
+	}
+
+	md.state().refreshingMu.Lock()
+	if md.refreshing == nil {
+		md.refreshing = make(map[string]bool)
+	}
+	if md.refreshing[key] {
+		md.state().refreshingMu.Unlock()
+		return
+	}
+	md.refreshing[key] = true
+	md.state().refreshingMu.Unlock()
+
+	// the refresh outlives the call which triggered it
+	ctx = context.WithoutCancel(ctx)
+
+	go func() {
+		defer logPanic(md.logger)
+		defer func() {
+			md.state().refreshingMu.Lock()
+			delete(md.refreshing, key)
+			md.state().refreshingMu.Unlock()
+		}()
+
+		release, err := md.loaderLimits.acquire(ctx, data.loadedKey)
+		if err != nil {
+			return
+		}
+		defer release()
+
+		start := time.Now()
+		value, err := data.loader(ctx, data.loadedKey)
+		if err != nil {
+			if debug {
+				md.logger.Info().Msgf("refresh ahead of [%s] failed: %v", md.loggedKey(key), err)
+			}
+			return
+		}
+
+		md.state().mu.Lock()
+		defer md.state().mu.Unlock()
+
+		// the data may have been deleted or written while it was being refreshed
+		if _, current, ok := md.lookup(key); ok && current.version == data.version {
+			md.storeLoaded(key, data.loadedKey, value, data.loader, data.TTL, time.Since(start))
+		}
+	}()
+}
@@ -0,0 +1,237 @@
+package fscache
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetOrLoad(t *testing.T) {
+	ch := Cache{}
+
+	var calls int
+	loader := func(key string) (interface{}, error) {
+		calls++
+		return "loaded_" + key, nil
+	}
+
+	value, err := ch.Memdis().GetOrLoad("load1", loader, time.Minute)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "loaded_load1", value)
+
+	value, err = ch.Memdis().GetOrLoad("load1", loader, time.Minute)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "loaded_load1", value)
+	assert.EqualValues(t, 1, calls)
+}
+
+func TestGetOrLoadSingleFlight(t *testing.T) {
+	ch := Cache{}
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	loader := func(key string) (interface{}, error) {
+		calls.Add(1)
+		<-release
+		return "loaded_" + key, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := ch.Memdis().GetOrLoad("flight1", loader, time.Minute)
+			assert.NoError(t, err)
+			assert.Equal(t, "loaded_flight1", value)
+		}()
+	}
+
+	// the misses wait for the load in flight
+	assert.Eventually(t, func() bool {
+		ch.MemdisInstance.state().loadingMu.Lock()
+		defer ch.MemdisInstance.state().loadingMu.Unlock()
+		return ch.MemdisInstance.loading["flight1"] != nil
+	}, time.Second, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestRefreshAheadKeepsWrites(t *testing.T) {
+	ch := Cache{}
+	WithRefreshAhead(time.Hour)(&ch)
+
+	refreshing := make(chan struct{})
+	release := make(chan struct{})
+	var calls atomic.Int32
+	loader := func(key string) (interface{}, error) {
+		if calls.Add(1) > 1 {
+			close(refreshing)
+			<-release
+			return "refreshed", nil
+		}
+		return "loaded", nil
+	}
+
+	_, err := ch.Memdis().GetOrLoad("refresh2", loader, time.Minute)
+	assert.NoError(t, err)
+	_, err = ch.Memdis().Get("refresh2")
+	assert.NoError(t, err)
+
+	// the data is written while it is being refreshed, the refresh must not replace it
+	<-refreshing
+	assert.NoError(t, ch.Memdis().OverWrite("refresh2", "written"))
+	close(release)
+
+	assert.Eventually(t, func() bool {
+		ch.MemdisInstance.state().refreshingMu.Lock()
+		defer ch.MemdisInstance.state().refreshingMu.Unlock()
+		return !ch.MemdisInstance.refreshing["refresh2"]
+	}, time.Second, time.Millisecond)
+	value, err := ch.Memdis().Get("refresh2")
+	assert.NoError(t, err)
+	assert.Equal(t, "written", value)
+}
+
+func TestRefreshAhead(t *testing.T) {
+	ch := Cache{}
+	WithRefreshAhead(time.Hour)(&ch)
+
+	refreshed := make(chan struct{}, 1)
+	var calls int
+	loader := func(key string) (interface{}, error) {
+		calls++
+		if calls > 1 {
+			select {
+			case refreshed <- struct{}{}:
+			default:
+			}
+		}
+		return calls, nil
+	}
+
+	value, err := ch.Memdis().GetOrLoad("refresh1", loader, time.Minute)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, value)
+
+	// the data expires in less than the threshold, so reading it triggers a refresh
+	value, err = ch.Memdis().Get("refresh1")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, value)
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("data was not refreshed")
+	}
+
+	assert.Eventually(t, func() bool {
+		value, _ := ch.Memdis().Get("refresh1")
+		return value == 2
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestKeyDigestLoader(t *testing.T) {
+	ch := Cache{}
+	WithKeyDigest(16)(&ch)
+	WithRefreshAhead(time.Hour)(&ch)
+
+	longKey := "https://example.com/users?page=1&limit=100"
+	loaded := make(chan string, 4)
+	loader := func(key string) (interface{}, error) {
+		loaded <- key
+		return "page1", nil
+	}
+
+	// the loaders get the key of the caller, not its digest
+	_, err := ch.Memdis().GetOrLoad(longKey, loader, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, longKey, <-loaded)
+
+	// the refresh ahead of the expiration too
+	_, err = ch.Memdis().GetOrLoad(longKey, loader, time.Minute)
+	assert.NoError(t, err)
+	select {
+	case key := <-loaded:
+		assert.Equal(t, longKey, key)
+	case <-time.After(time.Second):
+		t.Fatal("the data was not refreshed")
+	}
+
+	_, err = ch.Memdis().GetOrLoadMany([]string{longKey + "&sort=name"}, func(missing []string) (map[string]interface{}, error) {
+		assert.Equal(t, []string{longKey + "&sort=name"}, missing)
+		return map[string]interface{}{missing[0]: "page1"}, nil
+	})
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{longKey, longKey + "&sort=name"}, ch.Memdis().Keys())
+}
+
+func TestGetOrLoadMany(t *testing.T) {
+	ch := Cache{}
+
+	if err := ch.Memdis().Set("many1", "cached1"); err != nil {
+		assert.Error(t, err)
+	}
+
+	var requested []string
+	loader := func(missing []string) (map[string]interface{}, error) {
+		requested = missing
+		return map[string]interface{}{"many2": "loaded2"}, nil
+	}
+
+	result, err := ch.Memdis().GetOrLoadMany([]string{"many1", "many2", "many3"}, loader, time.Minute)
+	assert.NoError(t, err)
+	assert.EqualValues(t, []string{"many2", "many3"}, requested)
+	assert.EqualValues(t, map[string]interface{}{"many1": "cached1", "many2": "loaded2"}, result)
+
+	value, err := ch.Memdis().Get("many2")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "loaded2", value)
+
+	// the data reloaded keeps the loader it was set with
+	_, err = ch.Memdis().GetOrLoad("many4", func(key string) (interface{}, error) { return key, nil }, time.Millisecond)
+	assert.NoError(t, err)
+	time.Sleep(5 * time.Millisecond)
+	result, err = ch.Memdis().GetOrLoadMany([]string{"many4"}, func(missing []string) (map[string]interface{}, error) {
+		return map[string]interface{}{"many4": "loaded4"}, nil
+	}, time.Minute)
+	assert.NoError(t, err)
+	assert.EqualValues(t, map[string]interface{}{"many4": "loaded4"}, result)
+	assert.NotNil(t, ch.MemdisInstance.storage["many4"].loader)
+}
+
+type tenantKey struct{}
+
+func TestGetOrLoadContext(t *testing.T) {
+	ch := Cache{}
+
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	loader := func(ctx context.Context, key string) (interface{}, error) {
+		return fmt.Sprintf("%v:%s", ctx.Value(tenantKey{}), key), nil
+	}
+
+	value, err := ch.Memdis().GetOrLoadContext(ctx, "ctxload1", loader, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, "acme:ctxload1", value)
+
+	manyLoader := func(ctx context.Context, missing []string) (map[string]interface{}, error) {
+		return map[string]interface{}{missing[0]: ctx.Value(tenantKey{})}, nil
+	}
+	result, err := ch.Memdis().GetOrLoadManyContext(ctx, []string{"ctxload2"}, manyLoader, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"ctxload2": "acme"}, result)
+
+	// the loader is not called once the context is done
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = ch.Memdis().GetOrLoadContext(canceled, "ctxload3", loader, time.Minute)
+	assert.Equal(t, context.Canceled, err)
+}
@@ -0,0 +1,98 @@
+package fscache
+
+import (
+	"context"
+	"sort"
+	"strings"
+)
+
+// loaderLimits holds the semaphores bounding the loaders running at once
+type loaderLimits struct {
+	// global bounds all the loaders, unbounded when nil
+	global chan struct{}
+	// prefixes bound the loaders of the keys starting with each prefix
+	prefixes map[string]chan struct{}
+}
+
+// WithLoaderConcurrency limits to n the loaders of GetOrLoad(), GetOrLoadMany() and their refreshes running at once,
+// so a burst of distinct misses can't overwhelm the backing source. The other loaders wait for a slot, or for the
+// context of their caller to be done.
+func WithLoaderConcurrency(n int) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.loaderLimits.global = make(chan struct{}, max(n, 1))
+	}
+}
+
+// WithPrefixLoaderConcurrency limits to n the loaders of the keys starting with prefix running at once, on top of
+// WithLoaderConcurrency. A key is bound by the longest prefix it starts with.
+func WithPrefixLoaderConcurrency(prefix string, n int) Option {
+	return func(c *Cache) {
+		limits := &c.MemdisInstance.loaderLimits
+		if limits.prefixes == nil {
+			limits.prefixes = make(map[string]chan struct{})
+		}
+		limits.prefixes[prefix] = make(chan struct{}, max(n, 1))
+	}
+}
+
+// acquire waits for a slot for a loader of keys unless ctx is done, and returns the function releasing it
+func (l *loaderLimits) acquire(ctx context.Context, keys ...string) (release func(), err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if l.global == nil && len(l.prefixes) == 0 {
+		return func() {}, nil
+	}
+
+	// the semaphores are always taken in the same order so the loaders of several keys never deadlock
+	var prefixes []string
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		if prefix, ok := l.prefixOf(key); ok && !seen[prefix] {
+			seen[prefix] = true
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	sort.Strings(prefixes)
+
+	var held []chan struct{}
+	release = func() {
+		for _, sem := range held {
+			<-sem
+		}
+	}
+
+	sems := make([]chan struct{}, 0, len(prefixes)+1)
+	for _, prefix := range prefixes {
+		sems = append(sems, l.prefixes[prefix])
+	}
+	if l.global != nil {
+		sems = append(sems, l.global)
+	}
+
+	for _, sem := range sems {
+		select {
+		case sem <- struct{}{}:
+			held = append(held, sem)
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+
+	return release, nil
+}
+
+// prefixOf returns the longest prefix with a limit key starts with
+func (l *loaderLimits) prefixOf(key string) (string, bool) {
+	var longest string
+	var found bool
+	for prefix := range l.prefixes {
+		if strings.HasPrefix(key, prefix) && (!found || len(prefix) > len(longest)) {
+			longest, found = prefix, true
+		}
+	}
+
+	return longest, found
+}
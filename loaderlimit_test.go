@@ -0,0 +1,65 @@
+package fscache
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoaderConcurrency(t *testing.T) {
+	ch := Cache{}
+	WithLoaderConcurrency(3)(&ch)
+	WithPrefixLoaderConcurrency("user:", 1)(&ch)
+
+	var running, peak, userRunning, userPeak atomic.Int32
+	loader := func(key string) (interface{}, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+		}
+
+		if strings.HasPrefix(key, "user:") {
+			n := userRunning.Add(1)
+			defer userRunning.Add(-1)
+			for p := userPeak.Load(); n > p && !userPeak.CompareAndSwap(p, n); p = userPeak.Load() {
+			}
+		}
+
+		time.Sleep(5 * time.Millisecond)
+		return key, nil
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		for _, prefix := range []string{"user:", "item:"} {
+			wg.Add(1)
+			go func(key string) {
+				defer wg.Done()
+				value, err := ch.Memdis().GetOrLoad(key, loader)
+				assert.NoError(t, err)
+				assert.Equal(t, key, value)
+			}(fmt.Sprintf("%s%d", prefix, i))
+		}
+	}
+	wg.Wait()
+
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+	assert.EqualValues(t, 1, userPeak.Load())
+
+	// the callers waiting for a slot give up once their context is done
+	release, err := ch.MemdisInstance.loaderLimits.acquire(context.Background(), "user:blocking")
+	assert.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = ch.Memdis().GetOrLoadContext(ctx, "user:waiting", func(ctx context.Context, key string) (interface{}, error) {
+		return key, nil
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	release()
+}
@@ -0,0 +1,138 @@
+package fscache
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// errDocumentLocked the document is locked by another lease
+	errDocumentLocked = errors.New("document is locked")
+	// errVersionConflict the document was updated since it was read
+	errVersionConflict = errors.New("document version conflict")
+	// errInvalidLease the lease token does not hold the lock of the document
+	errInvalidLease = errors.New("invalid lease token")
+)
+
+// versionField is the field of the records holding their version, prefixed so it never collides with a field of the
+// documents
+const versionField = "_version"
+
+var (
+	// memgodbLeases are the locks of the documents by id, guarded by memgodbMu
+	memgodbLeases map[string]lease
+)
+
+// lease is the lock of a document
+type lease struct {
+	token     string
+	expiresAt time.Time
+}
+
+// LockDocument locks the document with the given id for ttl and returns the lease token holding the lock.
+// Until the lease is released or expires, the document can only be updated, patched or deleted through
+// WithLease(token), so two workers can't silently clobber the same document.
+func (c *Collection) LockDocument(id string, ttl time.Duration) (_ string, err error) {
+	defer recoverPanic(&err)
+
+	memgodbMu.Lock()
+	defer memgodbMu.Unlock()
+
+	now := c.now()
+	if l, ok := memgodbLeases[id]; ok && now.Before(l.expiresAt) {
+		return "", errDocumentLocked
+	}
+
+	if memgodbLeases == nil {
+		memgodbLeases = make(map[string]lease)
+	}
+	token := uuid.NewString()
+	memgodbLeases[id] = lease{
+		token:     token,
+		expiresAt: now.Add(ttl),
+	}
+
+	return token, nil
+}
+
+// UnlockDocument releases the lock of the document with the given id held by the lease token
+func (c *Collection) UnlockDocument(id, token string) (err error) {
+	defer recoverPanic(&err)
+
+	memgodbMu.Lock()
+	defer memgodbMu.Unlock()
+
+	l, ok := memgodbLeases[id]
+	if !ok || l.token != token || !c.now().Before(l.expiresAt) {
+		return errInvalidLease
+	}
+	delete(memgodbLeases, id)
+
+	return nil
+}
+
+// WithLease returns the collection using the lease token returned by LockDocument() to update, patch or delete
+// the documents it locked
+func (c *Collection) WithLease(token string) *Collection {
+	leased := *c
+	leased.leaseToken = token
+
+	return &leased
+}
+
+// IfVersion returns the collection only updating, patching or deleting the documents still at version, failing
+// otherwise. The version of a document starts at 1 and is incremented by each update or patch.
+func (c *Collection) IfVersion(version int) *Collection {
+	checked := *c
+	checked.ifVersion = version
+
+	return &checked
+}
+
+// checkWrite returns an error if one of the records matching the filter is locked by another lease,
+// or is not at the version required by IfVersion(). The caller must hold memgodbMu.
+func (c *Collection) checkWrite(objMaps []map[string]interface{}, filter map[string]interface{}) error {
+	return c.checkWriteWhere(objMaps, func(item map[string]interface{}) bool {
+		return c.matches(item, filter)
+	})
+}
+
+// checkWriteWhere is checkWrite for the records match reports. The caller must hold memgodbMu.
+func (c *Collection) checkWriteWhere(objMaps []map[string]interface{}, match func(item map[string]interface{}) bool) error {
+	now := c.now()
+	for _, item := range objMaps {
+		if !match(item) {
+			continue
+		}
+
+		if l, ok := memgodbLeases[fmt.Sprint(item["id"])]; ok && now.Before(l.expiresAt) && l.token != c.leaseToken {
+			return errDocumentLocked
+		}
+
+		if c.ifVersion > 0 && versionOf(item) != c.ifVersion {
+			return errVersionConflict
+		}
+	}
+
+	return nil
+}
+
+// versionOf returns the version of a record, 0 for the records inserted before versions were tracked
+func versionOf(item map[string]interface{}) int {
+	return intOf(item[versionField])
+}
+
+// intOf returns value as an int, 0 if it is not a number
+func intOf(value interface{}) int {
+	switch number := value.(type) {
+	case int:
+		return number
+	case float64:
+		return int(number)
+	}
+
+	return 0
+}
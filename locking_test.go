@@ -0,0 +1,93 @@
+package fscache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_LockDocument(t *testing.T) {
+	ch := Cache{}
+
+	res, err := ch.Memgodb().Collection("locked").Insert(map[string]interface{}{"name": "john"}).One()
+	assert.NoError(t, err)
+	id := fmt.Sprint(res.(map[string]interface{})["id"])
+	filter := map[string]interface{}{"name": "john"}
+
+	token, err := ch.Memgodb().Collection("locked").LockDocument(id, time.Minute)
+	assert.NoError(t, err)
+	_, err = ch.Memgodb().Collection("locked").LockDocument(id, time.Minute)
+	assert.Equal(t, errDocumentLocked, err)
+
+	// the document can only be written with the lease
+	patch := map[string]interface{}{"age": 30}
+	assert.Equal(t, errDocumentLocked, ch.Memgodb().Collection("locked").Patch(filter, patch))
+	assert.NoError(t, ch.Memgodb().Collection("locked").WithLease(token).Patch(filter, patch))
+
+	assert.Equal(t, errInvalidLease, ch.Memgodb().Collection("locked").UnlockDocument(id, "other"))
+	assert.NoError(t, ch.Memgodb().Collection("locked").UnlockDocument(id, token))
+
+	// the version was incremented by the patch
+	assert.Equal(t, errVersionConflict, ch.Memgodb().Collection("locked").IfVersion(1).Patch(filter, patch))
+	assert.NoError(t, ch.Memgodb().Collection("locked").IfVersion(2).Patch(filter, patch))
+
+	record, err := ch.Memgodb().Collection("locked").Filter(filter).First()
+	assert.NoError(t, err)
+	assert.Equal(t, 3.0, record[versionField])
+}
+
+func Test_IfVersionConcurrent(t *testing.T) {
+	ch := Cache{}
+
+	_, err := ch.Memgodb().Collection("versioned").Insert(map[string]interface{}{"name": "john"}).One()
+	assert.NoError(t, err)
+	filter := map[string]interface{}{"name": "john"}
+
+	// the writes are prepared before any is applied, only one of the updates must win
+	del := ch.Memgodb().Collection("versioned").IfVersion(1).Delete(filter)
+	updates := []*Update{
+		ch.Memgodb().Collection("versioned").IfVersion(1).Update(filter, map[string]interface{}{"name": "john"}),
+		ch.Memgodb().Collection("versioned").IfVersion(1).Update(filter, map[string]interface{}{"name": "john"}),
+	}
+	errs := make(chan error, len(updates))
+	for _, update := range updates {
+		go func(update *Update) {
+			errs <- update.One()
+		}(update)
+	}
+
+	var conflicts int
+	for range updates {
+		if err := <-errs; err != nil {
+			assert.Equal(t, errVersionConflict, err)
+			conflicts++
+		}
+	}
+	assert.Equal(t, 1, conflicts)
+
+	// the delete checks the version the update left
+	assert.Equal(t, errVersionConflict, del.One())
+	assert.NoError(t, ch.Memgodb().Collection("versioned").IfVersion(2).Delete(filter).All())
+}
+
+func Test_VersionField(t *testing.T) {
+	ch := Cache{}
+
+	// the version field of the documents is not the version of the records
+	_, err := ch.Memgodb().Collection("released").Insert(map[string]interface{}{"name": "john", "version": "v2"}).One()
+	assert.NoError(t, err)
+
+	filter := map[string]interface{}{"name": "john"}
+	record, err := ch.Memgodb().Collection("released").Filter(filter).First()
+	assert.NoError(t, err)
+	assert.Equal(t, "v2", record["version"])
+	assert.Equal(t, 1.0, record[versionField])
+
+	assert.NoError(t, ch.Memgodb().Collection("released").Patch(filter, map[string]interface{}{"version": "v3"}))
+	record, err = ch.Memgodb().Collection("released").Filter(filter).First()
+	assert.NoError(t, err)
+	assert.Equal(t, "v3", record["version"])
+	assert.Equal(t, 2.0, record[versionField])
+}
@@ -0,0 +1,106 @@
+package fscache
+
+import "context"
+
+type (
+	// LookupRequest object is a batch of Memdis gets and Memgodb finds resolved together by Lookup()
+	LookupRequest struct {
+		// Keys are the keys of the Memdis datas to get
+		Keys []string
+		// Finds are the Memgodb documents to find
+		Finds []LookupFind
+	}
+
+	// LookupFind object finds the documents of a Memgodb collection
+	LookupFind struct {
+		// Collection is the collection, a [string] or an [object] like the ones given to Memgodb().Collection()
+		Collection interface{}
+		// Filter matches the documents having all its fields, like the filters of MongoCollection.
+		// A nil filter matches all the documents.
+		Filter interface{}
+	}
+
+	// LookupResult object holds what Lookup() resolved
+	LookupResult struct {
+		// Values are the values of the keys found, by key
+		Values map[string]interface{}
+		// Missing are the keys not found, in the order of the request
+		Missing []string
+		// Documents are the documents found by each find, in the order of the request
+		Documents [][]map[string]interface{}
+	}
+)
+
+// Lookup() resolves a mixed batch of Memdis gets and Memgodb finds in one pass, holding the locks of both storages
+// so the datas and documents returned are consistent with each other, for request handlers needing several cached
+// pieces to render a page
+func (c *Cache) Lookup(ctx context.Context, req LookupRequest) (_ LookupResult, err error) {
+	defer recoverPanic(&err)
+
+	if err := ctx.Err(); err != nil {
+		return LookupResult{}, err
+	}
+
+	md := &c.MemdisInstance
+	if err := md.chaos.inject(); err != nil {
+		return LookupResult{}, err
+	}
+
+	keys := make([]string, len(req.Keys))
+	for i, key := range req.Keys {
+		if keys[i], err = md.canonicalKey(key); err != nil {
+			return LookupResult{}, err
+		}
+	}
+
+	collections := make([]*Collection, len(req.Finds))
+	queries := make([]map[string]interface{}, len(req.Finds))
+	for i, find := range req.Finds {
+		collections[i] = c.Memgodb().Collection(find.Collection)
+		if queries[i], err = mongoQuery(collections[i], find.Filter); err != nil {
+			return LookupResult{}, err
+		}
+	}
+
+	result := LookupResult{
+		Values:    make(map[string]interface{}),
+		Documents: make([][]map[string]interface{}, len(req.Finds)),
+	}
+
+	defer md.flushReadsIfFull()
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+	memgodbMu.RLock()
+	defer memgodbMu.RUnlock()
+
+	for i, key := range keys {
+		index, data, ok := md.lookup(key)
+		if !ok {
+			md.readMissed(key)
+			result.Missing = append(result.Missing, req.Keys[i])
+			continue
+		}
+
+		md.read(index, key)
+		md.refreshAheadIfNeeded(ctx, key, data)
+		result.Values[req.Keys[i]] = data.Value
+	}
+
+	for i, col := range collections {
+		objMaps, err := col.decodeStorage(MemgodbStorage, deadlineOf(col.timeout))
+		if err != nil {
+			return LookupResult{}, err
+		}
+		col.decrypt(objMaps)
+
+		documents := []map[string]interface{}{}
+		for _, item := range objMaps {
+			if col.mongoMatches(item, queries[i]) {
+				documents = append(documents, item)
+			}
+		}
+		result.Documents[i] = col.ordered(documents)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,40 @@
+package fscache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLookup(t *testing.T) {
+	ch := Cache{}
+	ctx := context.Background()
+
+	assert.NoError(t, ch.Memdis().Set("lookup:title", "Home"))
+	_, err := ch.Memgodb().Collection("lookupitem").Mongo().InsertMany(ctx, []interface{}{
+		M{"name": "lookup-a", "page": "home"},
+		M{"name": "lookup-b", "page": "about"},
+	})
+	assert.NoError(t, err)
+
+	result, err := ch.Lookup(ctx, LookupRequest{
+		Keys: []string{"lookup:title", "lookup:missing"},
+		Finds: []LookupFind{
+			{Collection: "lookupitem", Filter: M{"page": "home"}},
+			{Collection: "lookupitem", Filter: M{"page": "contact"}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"lookup:title": "Home"}, result.Values)
+	assert.Equal(t, []string{"lookup:missing"}, result.Missing)
+	assert.Len(t, result.Documents, 2)
+	assert.Len(t, result.Documents[0], 1)
+	assert.Equal(t, "lookup-a", result.Documents[0][0]["name"])
+	assert.Empty(t, result.Documents[1])
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = ch.Lookup(canceled, LookupRequest{Keys: []string{"lookup:title"}})
+	assert.ErrorIs(t, err, context.Canceled)
+}
@@ -0,0 +1,222 @@
+package fscache
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	runtimedebug "runtime/debug"
+	"time"
+)
+
+// mappedIndex is the index lookup() returns for the datas served from the mapped snapshot
+const mappedIndex = -1
+
+type (
+	// mappedSnapshot serves the datas of a snapshot file mapped into memory. Only the position of
+	// each data in the file is kept in memory, its value is decoded from the mapping when it is read.
+	mappedSnapshot struct {
+		data  []byte
+		index map[string]mappedData
+		// deleted are the keys removed or overwritten since the file was mapped
+		deleted map[string]bool
+	}
+
+	// mappedData is the position of a data in the mapped file
+	mappedData struct {
+		offset    int
+		length    int
+		expiresAt time.Time
+	}
+)
+
+// expired reports whether the mapped data is expired at now
+func (p mappedData) expired(now time.Time) bool {
+	return !p.expiresAt.IsZero() && !now.Before(p.expiresAt)
+}
+
+// MapSnapshot() serves the datas of a snapshot file written by SaveSnapshot() directly from a read-only memory
+// mapping of the file, instead of loading them into memory like LoadSnapshot() does. Only the keys are kept in
+// memory and values are decoded when they are read, which keeps the resident memory low for mostly cold datas.
+// Writes go to the in-memory storage which overlays the mapped datas. Mapping a new file replaces the previous one.
+func (md *Memdis) MapSnapshot(name string) (err error) {
+	defer recoverPanic(&err)
+
+	data, err := mmapFile(name)
+	if err != nil {
+		return err
+	}
+
+	mapped, digests, err := md.indexMapped(data)
+	if err != nil {
+		munmap(data)
+		return err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	// the in-memory storage overlays the mapped datas
+	for key := range md.storage {
+		mapped.deleted[key] = true
+	}
+
+	md.unmap()
+	md.mapped = mapped
+	md.state().dirty.Store(true)
+	for digest, original := range digests {
+		md.rememberDigest(digest, original)
+	}
+
+	return nil
+}
+
+// UnmapSnapshot() stops serving the datas of the snapshot file mapped with MapSnapshot()
+func (md *Memdis) UnmapSnapshot() (err error) {
+	defer recoverPanic(&err)
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	return md.unmap()
+}
+
+// unmap releases the mapped snapshot if any. The caller must hold md.state().mu.
+func (md *Memdis) unmap() error {
+	if md.mapped == nil {
+		return nil
+	}
+
+	data := md.mapped.data
+	md.mapped = nil
+	md.state().dirty.Store(true)
+	md.pruneDigests()
+
+	return munmap(data)
+}
+
+// indexMapped records the position of every data of a mapped snapshot file, and returns the original keys of the
+// digested ones. A file truncated while it is indexed returns an error instead of crashing the process.
+func (md *Memdis) indexMapped(data []byte) (_ *mappedSnapshot, _ map[string]string, err error) {
+	defer runtimedebug.SetPanicOnFault(runtimedebug.SetPanicOnFault(true))
+	defer func() {
+		if recover() != nil {
+			err = errors.New("invalid json file")
+		}
+	}()
+
+	mapped := &mappedSnapshot{
+		data:    data,
+		index:   make(map[string]mappedData),
+		deleted: make(map[string]bool),
+	}
+	digests := make(map[string]string)
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if token, err := dec.Token(); err != nil || token != json.Delim('[') {
+		return nil, nil, errors.New("invalid json file")
+	}
+
+	for dec.More() {
+		offset := int(dec.InputOffset())
+
+		var value struct {
+			Key       string     `json:"key"`
+			ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+		}
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, errors.New("invalid json file")
+		}
+
+		key, original, err := md.digestedKey(value.Key)
+		if err != nil {
+			return nil, nil, err
+		}
+		if original != "" {
+			digests[key] = original
+		}
+
+		position := mappedData{
+			offset: offset,
+			length: int(dec.InputOffset()) - offset,
+		}
+		if value.ExpiresAt != nil {
+			position.expiresAt = *value.ExpiresAt
+		}
+		mapped.index[key] = position
+	}
+
+	return mapped, digests, nil
+}
+
+// get decodes the data of key from the mapping, leaving out the removed and expired ones
+func (m *mappedSnapshot) get(key string, now time.Time) (MemdisData, bool) {
+	position, ok := m.index[key]
+	if !ok || m.deleted[key] || position.expired(now) {
+		return MemdisData{}, false
+	}
+
+	raw, ok := m.read(position)
+	if !ok {
+		return MemdisData{}, false
+	}
+
+	var data snapshotData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return MemdisData{}, false
+	}
+
+	return MemdisData{
+		Value:    data.Value,
+		Duration: position.expiresAt,
+		TTL:      data.TTL,
+	}, true
+}
+
+// read copies the bytes of a data out of the mapping, checking its position first. The reads past the end of a file
+// truncated after it was mapped fault, which is reported as a missing data instead of crashing the process.
+func (m *mappedSnapshot) read(position mappedData) (raw []byte, ok bool) {
+	if position.offset < 0 || position.length <= 0 || position.offset+position.length > len(m.data) {
+		return nil, false
+	}
+
+	defer runtimedebug.SetPanicOnFault(runtimedebug.SetPanicOnFault(true))
+	defer func() {
+		if recover() != nil {
+			raw, ok = nil, false
+		}
+	}()
+
+	// the position of a data may start with the comma separating it from the previous one
+	raw = bytes.TrimLeft(m.data[position.offset:position.offset+position.length], ", \t\r\n")
+
+	return bytes.Clone(raw), true
+}
+
+// each calls fn with every data of the mapping which has not been removed nor expired
+func (m *mappedSnapshot) each(now time.Time, fn func(key string, value MemdisData)) {
+	for key := range m.index {
+		if value, ok := m.get(key, now); ok {
+			fn(key, value)
+		}
+	}
+}
+
+// mmapFile maps name into memory, the returned bytes must be released with munmap()
+func mmapFile(name string) ([]byte, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, errors.New("error finding file")
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() == 0 || int64(int(info.Size())) != info.Size() {
+		return nil, errors.New("invalid json file")
+	}
+
+	return mmap(f, int(info.Size()))
+}
@@ -0,0 +1,23 @@
+//go:build !unix
+
+package fscache
+
+import (
+	"io"
+	"os"
+)
+
+// mmap reads f into memory on the platforms without memory mapping
+func mmap(f *os.File, size int) ([]byte, error) {
+	data := make([]byte, size)
+	if _, err := io.ReadFull(f, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// munmap releases the memory read by mmap()
+func munmap(data []byte) error {
+	return nil
+}
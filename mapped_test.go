@@ -0,0 +1,57 @@
+package fscache
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMapSnapshot(t *testing.T) {
+	withTempStorage(t)
+
+	saved := Cache{}
+	assert.NoError(t, saved.Memdis().Set("cold1", "value1"))
+	assert.NoError(t, saved.Memdis().Set("cold2", 2.0, time.Hour))
+	assert.NoError(t, saved.Memdis().Set("cold3", "value3"))
+	assert.NoError(t, saved.Memdis().SaveSnapshot())
+
+	ch := Cache{}
+	assert.NoError(t, ch.Memdis().Set("hot", "in memory"))
+	assert.NoError(t, ch.Memdis().MapSnapshot(memdisStorageFile))
+	defer ch.Memdis().UnmapSnapshot()
+
+	// gets are served from the mapped file
+	value, err := ch.Memdis().Get("cold2")
+	assert.NoError(t, err)
+	assert.Equal(t, 2.0, value)
+	assert.Equal(t, 4, ch.Memdis().Size())
+	assert.Equal(t, errKeyExists, ch.Memdis().Set("cold1", "again"))
+
+	// writes go to the in-memory overlay
+	assert.NoError(t, ch.Memdis().OverWrite("cold1", "overwritten"))
+	value, err = ch.Memdis().Get("cold1")
+	assert.NoError(t, err)
+	assert.Equal(t, "overwritten", value)
+
+	assert.NoError(t, ch.Memdis().Del("cold3"))
+	_, err = ch.Memdis().Get("cold3")
+	assert.Equal(t, errKeyNotFound, err)
+	assert.ElementsMatch(t, []string{"hot", "cold1", "cold2"}, ch.Memdis().Keys())
+
+	assert.NoError(t, ch.Memdis().UnmapSnapshot())
+	assert.ElementsMatch(t, []string{"hot", "cold1"}, ch.Memdis().Keys())
+
+	assert.Error(t, ch.Memdis().MapSnapshot("./testJsonFiles/missing.json"))
+
+	// a file truncated after it was mapped doesn't crash the reads
+	truncated := Cache{}
+	assert.NoError(t, truncated.Memdis().MapSnapshot(memdisStorageFile))
+	defer truncated.Memdis().UnmapSnapshot()
+	assert.NoError(t, os.Truncate(memdisStorageFile, 0))
+	assert.NotPanics(t, func() {
+		_, err = truncated.Memdis().Get("cold2")
+	})
+	assert.Equal(t, errKeyNotFound, err)
+}
@@ -0,0 +1,19 @@
+//go:build unix
+
+package fscache
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmap maps size bytes of f into read-only private memory, so the writes to the file made after it was mapped are
+// not seen through the mapping
+func mmap(f *os.File, size int) ([]byte, error) {
+	return syscall.Mmap(int(f.Fd()), 0, size, syscall.PROT_READ, syscall.MAP_PRIVATE)
+}
+
+// munmap releases the memory mapped by mmap()
+func munmap(data []byte) error {
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,17 @@
+package fscache
+
+// WithMaxMemory bounds the estimated size in bytes of the datas held by Memdis, e.g. 256 << 20 for 256MB. The size
+// of a data is estimated when it is set from its key and its value, the length of the strings and bytes and of the
+// json encoding of the other values. When the budget is exceeded, datas are evicted using the least recently used
+// policy of WithMaxEntries if it is set, and the GreedyDual policy of WithMaxCost otherwise. The evictions are
+// reported by Cache.Stats().
+func WithMaxMemory(maxMemory int64) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.maxMemory = maxMemory
+	}
+}
+
+// entrySize estimates the size in bytes of the data of key holding value
+func entrySize(key string, value interface{}) int64 {
+	return entryOverhead + int64(len(key)+valueSize(value))
+}
@@ -0,0 +1,44 @@
+package fscache
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithMaxMemory(t *testing.T) {
+	ch := Cache{}
+	value := strings.Repeat("x", 1000)
+	WithMaxMemory(3 * entrySize("key0", value))(&ch)
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, ch.Memdis().Set(fmt.Sprintf("key%d", i), value))
+	}
+
+	stats := ch.Stats()
+	assert.Equal(t, 3, stats.Memdis.Datas)
+	assert.EqualValues(t, 2, stats.Memdis.Evictions)
+	assert.LessOrEqual(t, stats.Memdis.Memory, stats.Config.MaxMemory)
+
+	// a data growing evicts others
+	assert.NoError(t, ch.Memdis().OverWrite("key4", strings.Repeat("x", 2000)))
+	assert.Equal(t, 2, ch.Memdis().Size())
+	assert.LessOrEqual(t, ch.Stats().Memdis.Memory, stats.Config.MaxMemory)
+
+	assert.NoError(t, ch.Memdis().Clear())
+	assert.EqualValues(t, 0, ch.Stats().Memdis.Memory)
+
+	// with WithMaxEntries, the least recently used datas are evicted first
+	lru := Cache{}
+	WithMaxMemory(3 * entrySize("key0", value))(&lru)
+	WithMaxEntries(100)(&lru)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, lru.Memdis().Set(fmt.Sprintf("key%d", i), value))
+	}
+	_, err := lru.Memdis().Get("key0")
+	assert.NoError(t, err)
+	assert.NoError(t, lru.Memdis().Set("key3", value))
+	assert.ElementsMatch(t, []string{"key0", "key2", "key3"}, lru.Memdis().Keys())
+}
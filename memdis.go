@@ -1,8 +1,9 @@
 package fscache
 
 import (
+	"context"
 	"errors"
-	"reflect"
+	"sort"
 	"time"
 )
 
@@ -14,212 +15,635 @@ var (
 )
 
 // Set() adds a new data into the in-memmory storage
-func (md *Memdis) Set(key string, value interface{}, duration ...time.Duration) error {
-	for _, cache := range md.storage {
-		if _, ok := cache[key]; ok {
-			return errKeyExists
-		}
+func (md *Memdis) Set(key string, value interface{}, duration ...time.Duration) (err error) {
+	defer recoverPanic(&err)
+
+	if md.recorder != nil {
+		start, original := time.Now(), key
+		defer func() {
+			trace := traceOf(TraceSet, original, md.ttlOf(duration), start, err)
+			trace.Size = valueSize(value)
+			md.recorder.record(trace)
+		}()
 	}
 
-	var ttl time.Duration
-	for i, v := range duration {
-		if i == 0 {
-			ttl = v
-			break
-		}
+	if err := md.chaos.inject(); err != nil {
+		return err
 	}
 
-	fs := make(map[string]MemdisData)
-	fs[key] = MemdisData{
-		Value:    value,
-		Duration: time.Now().Add(ttl),
+	key, digestOf, err := md.digestedKey(key)
+	if err != nil {
+		return err
 	}
 
-	md.storage = append(md.storage, fs)
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	if _, _, ok := md.lookup(key); ok {
+		return errKeyExists
+	}
+
+	if ok, err := md.room(1); !ok {
+		return err
+	}
+
+	ttl := md.ttlOf(duration)
+
+	md.insert(key, MemdisData{
+		Value:    value,
+		Duration: expiresAt(ttl),
+		TTL:      ttl,
+		digestOf: digestOf,
+	})
 
 	return nil
 }
 
-// SetMany() sets many data objects into memory for later access
-func (md *Memdis) SetMany(data []map[string]MemdisData) ([]map[string]interface{}, error) {
-	md.storage = append(md.storage, data...)
-	KeyValuePairs := md.KeyValuePairs()
+// SetNX() adds a new data into the in-memmory storage only if its key is absent, and reports whether it was set.
+// The check and the set are atomic, so it can be used as a building block for locks.
+func (md *Memdis) SetNX(key string, value interface{}, duration ...time.Duration) (_ bool, err error) {
+	defer recoverPanic(&err)
 
-	return KeyValuePairs, nil
+	err = md.Set(key, value, duration...)
+	if errors.Is(err, errKeyExists) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// SetMany() sets many data objects into memory for later access.
+// Keys set more than once or already set are handled according to WithDuplicatePolicy.
+func (md *Memdis) SetMany(data []map[string]MemdisData) (_ []map[string]interface{}, err error) {
+	defer recoverPanic(&err)
+
+	if err := md.chaos.inject(); err != nil {
+		return nil, err
+	}
+
+	if _, err := md.SetManyWithReport(data); err != nil {
+		return nil, err
+	}
+
+	return md.KeyValuePairs(), nil
 }
 
 // Get() retrieves a data from the in-memmory storage
-func (md *Memdis) Get(key string) (interface{}, error) {
-	for _, cache := range md.storage {
-		if val, ok := cache[key]; ok {
-			return val.Value, nil
-		}
+func (md *Memdis) Get(key string) (_ interface{}, err error) {
+	defer recoverPanic(&err)
+
+	data, err := md.get(key)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, errKeyNotFound
+	return data.Value, nil
 }
 
-// GetMany() retrieves datas with matching keys from the in-memmory storage
+// GetWithExpiration() retrieves a data from the in-memmory storage like Get(), along with the time it expires at,
+// the zero time meaning it never expires, so callers can make staleness decisions without a second lookup
+func (md *Memdis) GetWithExpiration(key string) (_ interface{}, _ time.Time, err error) {
+	defer recoverPanic(&err)
+
+	data, err := md.get(key)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	return data.Value, data.Duration, nil
+}
+
+// get retrieves the data of key, counting the read as a hit
+func (md *Memdis) get(key string) (_ MemdisData, err error) {
+	if md.recorder != nil {
+		start, original := time.Now(), key
+		defer func() {
+			trace := traceOf(TraceGet, original, 0, start, err)
+			trace.Hit = err == nil
+			md.recorder.record(trace)
+		}()
+	}
+
+	if err := md.chaos.inject(); err != nil {
+		return MemdisData{}, err
+	}
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return MemdisData{}, err
+	}
+
+	// the recency of the data and the removal of an expired one are applied later, so the reads run concurrently
+	defer md.flushReadsIfFull()
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
+	index, val, ok := md.lookup(key)
+	if !ok {
+		md.readMissed(key)
+		return MemdisData{}, errKeyNotFound
+	}
+
+	md.read(index, key)
+	md.refreshAheadIfNeeded(context.Background(), key, val)
+
+	return val, nil
+}
+
+// GetMany() retrieves datas with matching keys from the in-memmory storage, leaving out the expired ones
 func (md *Memdis) GetMany(keys []string) []map[string]interface{} {
+	keys = md.canonicalKeys(keys)
+
+	defer md.flushReadsIfFull()
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
 	var keyValuePairs = []map[string]interface{}{}
 
-	for _, cache := range md.storage {
-		data := make(map[string]interface{})
-		for _, key := range keys {
-			if val, ok := cache[key]; ok {
-				data[key] = val.Value
-				keyValuePairs = append(keyValuePairs, data)
+	for _, key := range keys {
+		_, val, ok := md.lookup(key)
+		if !ok {
+			if _, ok := md.storage[key]; ok {
+				md.bufferRead(bufferedRead{key: key, expired: true})
 			}
+			continue
 		}
+
+		keyValuePairs = append(keyValuePairs, map[string]interface{}{md.originalKey(key): val.Value})
 	}
 
 	return keyValuePairs
 }
 
-// Del() deletes a data from the in-memmory storage
-func (md *Memdis) Del(key string) error {
-	var isFound bool
-	for index, cache := range md.storage {
-		if _, ok := cache[key]; ok {
-			isFound = true
-			md.storage = append(md.storage[:index], md.storage[index+1:]...)
-			return nil
+// GetManyStrict() retrieves datas with matching keys from the in-memmory storage, and returns the keys
+// which were not found, have expired or failed to be read separately, once each in the order they were given.
+// Each key is read like Get() does, counting hits and misses and removing the expired datas. The keys naming the
+// same data once transformed by WithKeyTransform() are read once, under the first of them.
+func (md *Memdis) GetManyStrict(keys []string) (map[string]interface{}, []string) {
+	found := make(map[string]interface{}, len(keys))
+	var missing []string
+
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		canonical, err := md.canonicalKey(key)
+		if err != nil {
+			canonical = key
 		}
+		if seen[canonical] {
+			continue
+		}
+		seen[canonical] = true
+
+		value, err := md.Get(key)
+		if err != nil {
+			missing = append(missing, key)
+			continue
+		}
+
+		found[key] = value
+	}
+
+	return found, missing
+}
+
+// Del() deletes a data from the in-memmory storage
+func (md *Memdis) Del(key string) (err error) {
+	defer recoverPanic(&err)
+
+	if md.recorder != nil {
+		start, original := time.Now(), key
+		defer func() {
+			md.recorder.record(traceOf(TraceDel, original, 0, start, err))
+		}()
+	}
+
+	if err := md.chaos.inject(); err != nil {
+		return err
 	}
 
-	if !isFound {
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	index, _, ok := md.lookup(key)
+	if !ok {
 		return errKeyNotFound
 	}
 
-	return errKeyNotFound
+	md.deleteKey(index, key)
+
+	return nil
 }
 
-// Clear() deletes all datas from the in-memmory storage
-func (md *Memdis) Clear() error {
-	md.storage = md.storage[:0]
+// GetDel() retrieves a data from the in-memmory storage and deletes it. The read and the deletion are atomic,
+// so one-shot tokens and work items are returned to a single caller.
+func (md *Memdis) GetDel(key string) (_ interface{}, err error) {
+	defer recoverPanic(&err)
+
+	if err := md.chaos.inject(); err != nil {
+		return nil, err
+	}
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	index, data, ok := md.lookup(key)
+	if !ok {
+		md.dropExpired(key)
+		md.state().misses.Add(1)
+		return nil, errKeyNotFound
+	}
+
+	md.state().hits.Add(1)
+	md.deleteKey(index, key)
+
+	return data.Value, nil
+}
+
+// DelMany() deletes many datas from the in-memmory storage. The keys which can't be deleted don't stop the others
+// from being deleted, and are reported with a BulkError.
+func (md *Memdis) DelMany(keys ...string) (err error) {
+	defer recoverPanic(&err)
+
+	if err := md.chaos.inject(); err != nil {
+		return err
+	}
+
+	var failed []*ItemError
+	canonical := make([]string, 0, len(keys))
+	for _, key := range keys {
+		canonicalKey, err := md.canonicalKey(key)
+		if err != nil {
+			failed = append(failed, &ItemError{Index: -1, Key: key, Err: err})
+			continue
+		}
+		canonical = append(canonical, canonicalKey)
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	for _, key := range canonical {
+		index, _, ok := md.lookup(key)
+		if !ok {
+			failed = append(failed, &ItemError{Index: -1, Key: md.originalKey(key), Err: errKeyNotFound})
+			continue
+		}
+
+		md.deleteKey(index, key)
+	}
+
+	return bulkError(failed)
+}
+
+// Clear() deletes all datas from the in-memmory storage at once, sending a single KeyClear event rather than a
+// KeyEvent for each of them
+func (md *Memdis) Clear() (err error) {
+	defer recoverPanic(&err)
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	md.reset()
+	md.notify(KeyClear, "")
 
 	return nil
 }
 
-// Size() retrieves the total data objects in the in-memmory storage
+// Size() retrieves the total keys in the in-memmory storage, leaving out the expired ones
 func (md *Memdis) Size() int {
-	return len(md.storage)
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
+	var size int
+	md.snapshot(func(_ int, _ string, _ MemdisData) {
+		size++
+	})
+
+	return size
 }
 
-// OverWrite() updates an already set value using it key
-func (md *Memdis) OverWrite(key string, value interface{}, duration ...time.Duration) error {
-	var isFound bool
-	for index, cache := range md.storage {
-		if _, ok := cache[key]; ok {
-			isFound = true
-			md.storage = append(md.storage[:index], md.storage[index+1:]...)
+// EntryCount() retrieves the total keys of a namespace in the in-memmory storage, leaving out the expired ones.
+// The namespace of a key is the part before its first ":", e.g. "user" for "user:1".
+// Keys without a ":" belong to the empty namespace.
+func (md *Memdis) EntryCount(namespace string) int {
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
+	var count int
+	md.snapshot(func(_ int, key string, _ MemdisData) {
+		if namespaceOf(md.originalKey(key)) == namespace {
+			count++
 		}
+	})
+
+	return count
+}
+
+// OverWrite() updates an already set value using it key.
+// The data keeps its remaining time to live unless a new duration is given (see WithOverWriteResetsTTL).
+func (md *Memdis) OverWrite(key string, value interface{}, duration ...time.Duration) (err error) {
+	defer recoverPanic(&err)
+
+	if md.recorder != nil {
+		start, original := time.Now(), key
+		defer func() {
+			trace := traceOf(TraceSet, original, md.ttlOf(duration), start, err)
+			trace.Size = valueSize(value)
+			md.recorder.record(trace)
+		}()
 	}
 
-	if !isFound {
+	if err := md.chaos.inject(); err != nil {
+		return err
+	}
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	index, prev, ok := md.lookup(key)
+	if !ok {
 		return errKeyNotFound
 	}
+	md.replace(index, key, md.overwritten(prev, value, duration))
 
-	var ttl time.Duration
-	for i, v := range duration {
-		if i == 0 {
-			ttl = v
-			break
-		}
+	return nil
+}
+
+// GetSet() replaces the value of an already set data using it key, and returns the previous value.
+// The swap is atomic, so counters and tokens can be rotated without a race between Get() and OverWrite().
+// The data keeps its remaining time to live unless WithOverWriteResetsTTL is used.
+func (md *Memdis) GetSet(key string, newValue interface{}) (oldValue interface{}, err error) {
+	defer recoverPanic(&err)
+
+	if err := md.chaos.inject(); err != nil {
+		return nil, err
 	}
 
-	fs := make(map[string]MemdisData)
-	fs[key] = MemdisData{
-		Value:    value,
-		Duration: time.Now().Add(ttl),
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return nil, err
 	}
 
-	md.storage = append(md.storage, fs)
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
 
-	return nil
+	index, prev, ok := md.lookup(key)
+	if !ok {
+		md.dropExpired(key)
+		return nil, errKeyNotFound
+	}
+
+	md.replace(index, key, md.overwritten(prev, newValue, nil))
+
+	return prev.Value, nil
 }
 
-// OverWriteWithKey() updates an already set value and key using the previously set key
-func (md *Memdis) OverWriteWithKey(prevkey, newKey string, value interface{}, duration ...time.Duration) error {
-	var isFound bool
-	for index, cache := range md.storage {
-		if _, ok := cache[prevkey]; ok {
-			isFound = true
-			md.storage = append(md.storage[:index], md.storage[index+1:]...)
-		}
+// OverWriteOrSet() updates an already set value using it key like OverWrite(), or sets it if it is not found
+func (md *Memdis) OverWriteOrSet(key string, value interface{}, duration ...time.Duration) (err error) {
+	defer recoverPanic(&err)
+
+	return md.overWriteOrSet(key, duration, false, func(MemdisData, bool) (interface{}, error) {
+		return value, nil
+	})
+}
+
+// overWriteOrSet sets the value of key computed by update from its current data, if found. A data which is found
+// keeps its remaining time to live when keepTTL is set, and is updated like OverWrite() otherwise.
+func (md *Memdis) overWriteOrSet(key string, duration []time.Duration, keepTTL bool, update func(prev MemdisData, found bool) (interface{}, error)) (err error) {
+	var value interface{}
+	if md.recorder != nil {
+		start, original := time.Now(), key
+		defer func() {
+			trace := traceOf(TraceSet, original, md.ttlOf(duration), start, err)
+			trace.Size = valueSize(value)
+			md.recorder.record(trace)
+		}()
 	}
 
-	if !isFound {
-		return errKeyNotFound
+	if err := md.chaos.inject(); err != nil {
+		return err
 	}
 
-	var ttl time.Duration
-	for i, v := range duration {
-		if i == 0 {
-			ttl = v
-			break
+	key, digestOf, err := md.digestedKey(key)
+	if err != nil {
+		return err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	index, prev, ok := md.lookup(key)
+	if value, err = update(prev, ok); err != nil {
+		return err
+	}
+
+	if !ok {
+		if ok, err := md.room(1); !ok {
+			return err
 		}
+
+		ttl := md.ttlOf(duration)
+		md.insert(key, MemdisData{
+			Value:    value,
+			Duration: expiresAt(ttl),
+			TTL:      ttl,
+			digestOf: digestOf,
+		})
+
+		return nil
 	}
 
-	fs := make(map[string]MemdisData)
-	fs[newKey] = MemdisData{
-		Value:    value,
-		Duration: time.Now().Add(ttl),
+	if keepTTL {
+		prev.Value = value
+		md.replace(index, key, prev)
+		return nil
+	}
+	md.replace(index, key, md.overwritten(prev, value, duration))
+
+	return nil
+}
+
+// OverWriteWithKey() updates an already set value and key using the previously set key.
+// The data keeps its remaining time to live unless a new duration is given (see WithOverWriteResetsTTL).
+func (md *Memdis) OverWriteWithKey(prevkey, newKey string, value interface{}, duration ...time.Duration) (err error) {
+	defer recoverPanic(&err)
+
+	if err := md.chaos.inject(); err != nil {
+		return err
 	}
 
-	md.storage = append(md.storage, fs)
+	prevkey, err = md.canonicalKey(prevkey)
+	if err != nil {
+		return err
+	}
+	newKey, digestOf, err := md.digestedKey(newKey)
+	if err != nil {
+		return err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	index, prev, ok := md.lookup(prevkey)
+	if !ok {
+		return errKeyNotFound
+	}
+	md.deleteKey(index, prevkey)
+
+	data := md.overwritten(prev, value, duration)
+	data.digestOf = digestOf
+	md.insert(newKey, data)
 
 	return nil
 }
 
-// Keys() returns all the keys in the storage
+// overwritten returns the data prev replaced with value. Without duration, the data keeps
+// its expiration unless WithOverWriteResetsTTL is used.
+func (md *Memdis) overwritten(prev MemdisData, value interface{}, duration []time.Duration) MemdisData {
+	data := prev
+	data.Value = value
+
+	if len(duration) > 0 || md.overWriteResetsTTL {
+		data.TTL = md.ttlOf(duration)
+		data.Duration = expiresAt(data.TTL)
+	}
+
+	return data
+}
+
+// Keys() returns all the keys in the storage.
+// The keys are a consistent snapshot of the storage which never contains expired or duplicated keys.
 func (md *Memdis) Keys() []string {
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
 	var keys []string
-	for _, cache := range md.storage {
-		for key := range cache {
-			keys = append(keys, key)
-		}
-	}
+	md.snapshot(func(_ int, key string, value MemdisData) {
+		keys = append(keys, md.originalKey(key))
+	})
 
 	return keys
 }
 
-// Values() returns all the values in the storage
+// Values() returns all the values in the storage.
+// The values are a consistent snapshot of the storage which never contains expired or duplicated datas.
 func (md *Memdis) Values() []interface{} {
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
 	var values []interface{}
-	for _, cache := range md.storage {
-		for _, v := range cache {
-			values = append(values, v.Value)
-		}
-	}
+	md.snapshot(func(_ int, key string, value MemdisData) {
+		values = append(values, value.Value)
+	})
 
 	return values
 }
 
-// TypeOf() returns the data type of a value
-func (md *Memdis) TypeOf(key string) (string, error) {
-	for _, cache := range md.storage {
-		value, ok := cache[key]
-		if ok {
-			return reflect.TypeOf(value.Value).String(), nil
-		}
+// TypeOf() returns the data type of a value, or the name registered for it with RegisterTypeName()
+func (md *Memdis) TypeOf(key string) (_ string, err error) {
+	defer recoverPanic(&err)
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
+	_, value, ok := md.lookup(key)
+	if !ok {
+		return "", errKeyNotFound
 	}
 
-	return "", errKeyNotFound
+	return md.typeName(value.Value), nil
 }
 
-// KeyValuePairs() returns an array of key value pairs of all the datas in the storage
+// KeyValuePairs() returns an array of key value pairs of all the datas in the storage, one map per data object:
+// the datas set together by SetMany() share a map, and every other data has a map of its own. The pairs are a consistent snapshot of the storage which never contains expired or duplicated datas.
 func (md *Memdis) KeyValuePairs() []map[string]interface{} {
-	var keyValuePairs = []map[string]interface{}{}
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
+	return md.keyValuePairs()
+}
+
+// keyValuePairs builds the key value pairs of the storage, one map per data object in the order they were set,
+// the datas of the mapped snapshot last. The caller must hold md.state().mu.
+func (md *Memdis) keyValuePairs() []map[string]interface{} {
+	type object struct {
+		id     uint64
+		mapped bool
+	}
 
-	for _, v := range md.storage {
-		data := make(map[string]interface{})
-		for key, value := range v {
-			data[key] = value.Value
+	objects := make(map[object]map[string]interface{})
+	md.snapshot(func(index int, key string, value MemdisData) {
+		id := object{id: value.object, mapped: index == mappedIndex}
+		if objects[id] == nil {
+			objects[id] = make(map[string]interface{})
 		}
+		objects[id][md.originalKey(key)] = value.Value
+	})
 
-		keyValuePairs = append(keyValuePairs, data)
+	ids := make([]object, 0, len(objects))
+	for id := range objects {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if ids[i].mapped != ids[j].mapped {
+			return !ids[i].mapped
+		}
+		return ids[i].id < ids[j].id
+	})
+
+	var keyValuePairs = []map[string]interface{}{}
+	for _, id := range ids {
+		keyValuePairs = append(keyValuePairs, objects[id])
 	}
 
 	return keyValuePairs
 }
+
+// snapshot calls fn with every data of the storage which has not expired along with storedIndex, then with
+// the datas of the mapped snapshot, in the order set with WithIterationOrder(). The caller must hold md.state().mu.
+func (md *Memdis) snapshot(fn func(index int, key string, value MemdisData)) {
+	if md.iterationOrder != OrderUnspecified {
+		md.orderedSnapshot(fn)
+		return
+	}
+
+	md.unorderedSnapshot(fn)
+}
+
+// unorderedSnapshot calls fn like snapshot(), in no particular order. The caller must hold md.state().mu.
+func (md *Memdis) unorderedSnapshot(fn func(index int, key string, value MemdisData)) {
+	now := time.Now()
+	for key, value := range md.storage {
+		if !value.expired(now) {
+			fn(storedIndex, key, value)
+		}
+	}
+
+	if md.mapped != nil {
+		md.mapped.each(now, func(key string, value MemdisData) {
+			if _, ok := md.storage[key]; !ok {
+				fn(mappedIndex, key, value)
+			}
+		})
+	}
+}
@@ -1,6 +1,10 @@
 package fscache
 
 import (
+	"fmt"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -29,9 +33,34 @@ var memdisTestCases = []map[string]MemdisData{
 	},
 }
 
+// memdisTestStorage returns a copy of the memdis test cases, so tests can't affect each other
+func memdisTestStorage() map[string]MemdisData {
+	storage := make(map[string]MemdisData)
+	for _, cache := range memdisTestCases {
+		for key, value := range cache {
+			storage[key] = value
+		}
+	}
+
+	return storage
+}
+
+// withTempStorage makes the snapshot and persistence files of the test be written into a temporary directory
+func withTempStorage(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	memdisFile, memgodbFile := memdisStorageFile, memgodbStorageFile
+	memdisStorageFile = filepath.Join(dir, memdisStorageName)
+	memgodbStorageFile = filepath.Join(dir, memgodbStorageName)
+	t.Cleanup(func() {
+		memdisStorageFile, memgodbStorageFile = memdisFile, memgodbFile
+	})
+}
+
 func TestSet(t *testing.T) {
 	md := Memdis{
-		storage: memdisTestCases,
+		storage: memdisTestStorage(),
 	}
 	ch := Cache{
 		MemdisInstance: md,
@@ -46,7 +75,7 @@ func TestSet(t *testing.T) {
 
 func TestGet(t *testing.T) {
 	md := Memdis{
-		storage: memdisTestCases,
+		storage: memdisTestStorage(),
 	}
 	ch := Cache{
 		MemdisInstance: md,
@@ -62,7 +91,7 @@ func TestGet(t *testing.T) {
 
 func TestDel(t *testing.T) {
 	md := Memdis{
-		storage: memdisTestCases,
+		storage: memdisTestStorage(),
 	}
 	ch := Cache{
 		MemdisInstance: md,
@@ -77,7 +106,7 @@ func TestDel(t *testing.T) {
 
 func TestClear(t *testing.T) {
 	md := Memdis{
-		storage: memdisTestCases,
+		storage: memdisTestStorage(),
 	}
 	ch := Cache{
 		MemdisInstance: md,
@@ -92,7 +121,7 @@ func TestClear(t *testing.T) {
 
 func TestSize(t *testing.T) {
 	md := Memdis{
-		storage: memdisTestCases,
+		storage: memdisTestStorage(),
 	}
 	ch := Cache{
 		MemdisInstance: md,
@@ -100,11 +129,35 @@ func TestSize(t *testing.T) {
 
 	value := ch.Memdis().Size()
 	assert.EqualValues(t, 3, value)
+
+	// objects holding many keys count each key
+	_, err := ch.Memdis().SetMany([]map[string]MemdisData{
+		{"user:1": {Value: "user1"}, "user:2": {Value: "user2"}},
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, ch.Memdis().Size())
+}
+
+func TestEntryCount(t *testing.T) {
+	md := Memdis{
+		storage: memdisTestStorage(),
+	}
+	ch := Cache{
+		MemdisInstance: md,
+	}
+
+	assert.NoError(t, ch.Memdis().Set("user:1", "user1"))
+	assert.NoError(t, ch.Memdis().Set("user:2", "user2"))
+	assert.NoError(t, ch.Memdis().Set("session:1", "session1"))
+
+	assert.EqualValues(t, 2, ch.Memdis().EntryCount("user"))
+	assert.EqualValues(t, 1, ch.Memdis().EntryCount("session"))
+	assert.EqualValues(t, 3, ch.Memdis().EntryCount(""))
 }
 
 func TestDebug(t *testing.T) {
 	md := Memdis{
-		storage: memdisTestCases,
+		storage: memdisTestStorage(),
 	}
 	ch := Cache{
 		MemdisInstance: md,
@@ -116,7 +169,7 @@ func TestDebug(t *testing.T) {
 
 func TestOverWrite(t *testing.T) {
 	md := Memdis{
-		storage: memdisTestCases,
+		storage: memdisTestStorage(),
 	}
 	ch := Cache{
 		MemdisInstance: md,
@@ -131,7 +184,7 @@ func TestOverWrite(t *testing.T) {
 
 func TestOverWriteWithKey(t *testing.T) {
 	md := Memdis{
-		storage: memdisTestCases,
+		storage: memdisTestStorage(),
 	}
 	ch := Cache{
 		MemdisInstance: md,
@@ -146,7 +199,7 @@ func TestOverWriteWithKey(t *testing.T) {
 
 func TestTypeOf(t *testing.T) {
 	md := Memdis{
-		storage: memdisTestCases,
+		storage: memdisTestStorage(),
 	}
 	ch := Cache{
 		MemdisInstance: md,
@@ -162,7 +215,7 @@ func TestTypeOf(t *testing.T) {
 
 func TestKeyValuePairs(t *testing.T) {
 	md := Memdis{
-		storage: memdisTestCases,
+		storage: memdisTestStorage(),
 	}
 	ch := Cache{
 		MemdisInstance: md,
@@ -170,11 +223,26 @@ func TestKeyValuePairs(t *testing.T) {
 
 	datas := ch.Memdis().KeyValuePairs()
 	assert.NotNil(t, datas)
+
+	// the datas of an object stay together, also once overwritten
+	objects := Cache{}
+	assert.NoError(t, objects.Memdis().Set("single", 1))
+	_, err := objects.Memdis().SetMany([]map[string]MemdisData{
+		{"first": {Value: 2}, "second": {Value: 3}},
+		{"third": {Value: 4}},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, objects.Memdis().OverWrite("second", 5))
+	assert.NoError(t, objects.Memdis().Del("single"))
+	assert.Equal(t, []map[string]interface{}{
+		{"first": 2, "second": 5},
+		{"third": 4},
+	}, objects.Memdis().KeyValuePairs())
 }
 
 func TestSetMany(t *testing.T) {
 	md := Memdis{
-		storage: memdisTestCases,
+		storage: memdisTestStorage(),
 	}
 	ch := Cache{
 		MemdisInstance: md,
@@ -198,11 +266,12 @@ func TestSetMany(t *testing.T) {
 	}
 
 	assert.NotNil(t, datas)
+	assert.Contains(t, datas, map[string]interface{}{"key4": "value4", "key5": false})
 }
 
 func TestGetMany(t *testing.T) {
 	md := Memdis{
-		storage: memdisTestCases,
+		storage: memdisTestStorage(),
 	}
 	ch := Cache{
 		MemdisInstance: md,
@@ -216,7 +285,7 @@ func TestGetMany(t *testing.T) {
 
 func TestKeys(t *testing.T) {
 	md := Memdis{
-		storage: memdisTestCases,
+		storage: memdisTestStorage(),
 	}
 	ch := Cache{
 		MemdisInstance: md,
@@ -228,7 +297,7 @@ func TestKeys(t *testing.T) {
 
 func TestValues(t *testing.T) {
 	md := Memdis{
-		storage: memdisTestCases,
+		storage: memdisTestStorage(),
 	}
 	ch := Cache{
 		MemdisInstance: md,
@@ -237,3 +306,189 @@ func TestValues(t *testing.T) {
 	values := ch.Memdis().Values()
 	assert.NotNil(t, values)
 }
+
+func TestGetManyStrict(t *testing.T) {
+	ch := Cache{}
+
+	assert.NoError(t, ch.Memdis().Set("strict1", "value1"))
+	assert.NoError(t, ch.Memdis().Set("strict2", "value2", time.Millisecond))
+	time.Sleep(2 * time.Millisecond)
+
+	found, missing := ch.Memdis().GetManyStrict([]string{"strict1", "strict2", "strict3", "strict3", "strict1"})
+	assert.EqualValues(t, map[string]interface{}{"strict1": "value1"}, found)
+	assert.EqualValues(t, []string{"strict2", "strict3"}, missing)
+
+	// the keys are read like Get() does
+	ch.MemdisInstance.flushReads()
+	assert.NotContains(t, ch.MemdisInstance.storage, "strict2")
+	assert.EqualValues(t, 1, ch.Memdis().Stats().Hits)
+	assert.EqualValues(t, 2, ch.Memdis().Stats().Misses)
+
+	WithChaos(Chaos{ErrorRate: 1})(&ch)
+	found, missing = ch.Memdis().GetManyStrict([]string{"strict1"})
+	assert.Empty(t, found)
+	assert.EqualValues(t, []string{"strict1"}, missing)
+}
+
+func TestGetManyStrictKeyTransform(t *testing.T) {
+	ch := Cache{}
+	WithKeyTransform(KeyLower)(&ch)
+	assert.NoError(t, ch.Memdis().Set("User:1", "john"))
+
+	// the keys naming the same data are read once
+	found, missing := ch.Memdis().GetManyStrict([]string{"User:1", "user:1", "User:2", "USER:2"})
+	assert.EqualValues(t, map[string]interface{}{"User:1": "john"}, found)
+	assert.EqualValues(t, []string{"User:2"}, missing)
+}
+
+func TestEnumerationSnapshot(t *testing.T) {
+	ch := Cache{}
+
+	assert.NoError(t, ch.Memdis().Set("snap1", "value1"))
+	assert.NoError(t, ch.Memdis().Set("snapExpired", "value", time.Millisecond))
+	time.Sleep(2 * time.Millisecond)
+
+	assert.EqualValues(t, []string{"snap1"}, ch.Memdis().Keys())
+	assert.EqualValues(t, []interface{}{"value1"}, ch.Memdis().Values())
+	assert.EqualValues(t, []map[string]interface{}{{"snap1": "value1"}}, ch.Memdis().KeyValuePairs())
+
+	// enumerating while the storage is mutated never panics nor returns duplicated keys
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			key := fmt.Sprintf("concurrent%d", i%10)
+			_ = ch.Memdis().Set(key, i)
+			_ = ch.Memdis().OverWrite(key, i)
+			_ = ch.Memdis().Del(key)
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		seen := make(map[string]bool)
+		for _, key := range ch.Memdis().Keys() {
+			assert.False(t, seen[key], key)
+			seen[key] = true
+		}
+		_ = ch.Memdis().Values()
+		_ = ch.Memdis().KeyValuePairs()
+	}
+	<-done
+}
+
+func TestGetWithExpiration(t *testing.T) {
+	ch := Cache{}
+	assert.NoError(t, ch.Memdis().Set("session", "value", time.Minute))
+	assert.NoError(t, ch.Memdis().Set("config", "value"))
+
+	value, expiresAt, err := ch.Memdis().GetWithExpiration("session")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "value", value)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), expiresAt, time.Second)
+
+	_, expiresAt, err = ch.Memdis().GetWithExpiration("config")
+	assert.NoError(t, err)
+	assert.True(t, expiresAt.IsZero())
+
+	_, _, err = ch.Memdis().GetWithExpiration("missing")
+	assert.Equal(t, errKeyNotFound, err)
+}
+
+func TestSetNX(t *testing.T) {
+	ch := Cache{}
+
+	var wg sync.WaitGroup
+	var won atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			set, err := ch.Memdis().SetNX("lock", i, time.Minute)
+			assert.NoError(t, err)
+			if set {
+				won.Add(1)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 1, won.Load())
+	ttl, err := ch.Memdis().TTL("lock")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+}
+
+func TestGetSet(t *testing.T) {
+	ch := Cache{}
+
+	_, err := ch.Memdis().GetSet("token", "t1")
+	assert.ErrorIs(t, err, errKeyNotFound)
+
+	err = ch.Memdis().Set("token", "t0", time.Minute)
+	assert.NoError(t, err)
+
+	old, err := ch.Memdis().GetSet("token", "t1")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "t0", old)
+
+	value, err := ch.Memdis().Get("token")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "t1", value)
+
+	// the data keeps its ttl
+	ttl, err := ch.Memdis().TTL("token")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+	// concurrent swaps see every previous value once
+	err = ch.Memdis().Set("counter", 0)
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	seen := make(chan interface{}, 10)
+	for i := 1; i <= 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			old, err := ch.Memdis().GetSet("counter", i)
+			assert.NoError(t, err)
+			seen <- old
+		}(i)
+	}
+	wg.Wait()
+	close(seen)
+
+	olds := map[interface{}]bool{}
+	for old := range seen {
+		olds[old] = true
+	}
+	assert.Len(t, olds, 10)
+}
+
+func TestGetDel(t *testing.T) {
+	ch := Cache{}
+
+	_, err := ch.Memdis().GetDel("job")
+	assert.ErrorIs(t, err, errKeyNotFound)
+
+	err = ch.Memdis().Set("job", "payload")
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	var taken atomic.Int32
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if value, err := ch.Memdis().GetDel("job"); err == nil {
+				assert.EqualValues(t, "payload", value)
+				taken.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.EqualValues(t, 1, taken.Load())
+	_, err = ch.Memdis().Get("job")
+	assert.ErrorIs(t, err, errKeyNotFound)
+}
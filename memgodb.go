@@ -5,9 +5,12 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,7 +21,18 @@ var (
 	// MemgodbStorage storage instance
 	MemgodbStorage []interface{}
 	// persistMemgodbData to enable persistence of Memgodb data
-	persistMemgodbData bool
+	persistMemgodbData atomic.Bool
+	// memgodbDirty is set when MemgodbStorage changed since it was last persisted
+	memgodbDirty atomic.Bool
+	// memgodbMu guards MemgodbStorage
+	memgodbMu sync.RWMutex
+	// memgodbStorageFile is the path of the file Persist() writes the Memgodb datas into
+	memgodbStorageFile = "./" + memgodbStorageName
+)
+
+const (
+	// memgodbStorageName is the name of the file Persist() writes the Memgodb datas into
+	memgodbStorageName = "memgodbstorage.json"
 )
 
 type (
@@ -26,6 +40,21 @@ type (
 	Collection struct {
 		logger         zerolog.Logger
 		collectionName string
+		idGenerator    func() string
+		clock          func() time.Time
+		encryptions    map[string]*fieldEncryption
+		// leaseToken is the lease used to write the documents locked with LockDocument()
+		leaseToken string
+		// collation defines how the strings of the collection are compared
+		collation Collation
+		// ifVersion is the version the documents must be at to be written, any version when 0
+		ifVersion int
+		// timeout is the time after which the queries are aborted, never when 0
+		timeout time.Duration
+		// iterationOrder is the order the queries return the records in
+		iterationOrder IterationOrder
+		// chaos injects latency and failures into the operations, nil unless WithChaos is used
+		chaos *chaosMonkey
 	}
 
 	// Insert object implementes One() and Many() to insert new records
@@ -39,6 +68,13 @@ type (
 		objMaps    []map[string]interface{}
 		filter     map[string]interface{}
 		collection Collection
+		// sortField is the field All() orders the records by, see Sort()
+		sortField      string
+		sortDescending bool
+		// deadline is the time after which the query is aborted, never when zero
+		deadline time.Time
+		// err is the error which aborted Filter()
+		err error
 	}
 
 	// Delete object implementes One() and All()
@@ -83,18 +119,26 @@ func (ns *Memgodb) Collection(col interface{}) *Collection {
 
 	var colName string
 	if t.Kind() == reflect.Struct {
-		colName = strings.ToLower(t.Name())
+		colName = collectionName(t.Name())
 	} else {
-		colName = strings.ToLower(col.(string))
+		colName = collectionName(col.(string))
 	}
 
-	if len(colName) > 0 && string(colName[len(colName)-1]) != "s" {
-		colName = fmt.Sprintf("%ss", colName)
-	}
+	memgodbMu.RLock()
+	encryptions := ns.encryptions
+	collation := ns.collations[colName]
+	memgodbMu.RUnlock()
 
 	return &Collection{
 		logger:         ns.logger,
 		collectionName: colName,
+		idGenerator:    ns.idGenerator,
+		clock:          ns.clock,
+		encryptions:    encryptions,
+		collation:      collation,
+		timeout:        ns.opTimeout,
+		iterationOrder: ns.iterationOrder,
+		chaos:          ns.chaos,
 	}
 }
 
@@ -107,7 +151,13 @@ func (c *Collection) Insert(obj interface{}) *Insert {
 }
 
 // One is a method available in Insert(). It adds a new record into the storage with collection name
-func (i *Insert) One() (interface{}, error) {
+func (i *Insert) One() (_ interface{}, err error) {
+	defer recoverPanic(&err)
+
+	if err := i.collection.chaos.inject(); err != nil {
+		return nil, err
+	}
+
 	if i.obj == nil {
 		return nil, errors.New("One() params cannot be nil")
 	}
@@ -124,16 +174,30 @@ func (i *Insert) One() (interface{}, error) {
 	}
 
 	objMap["colName"] = i.collection.collectionName
-	objMap["id"] = uuid.New()
-	objMap["createdAt"] = time.Now()
+	objMap["id"] = i.collection.newID()
+	objMap["createdAt"] = i.collection.now()
 	objMap["updatedAt"] = nil
+	objMap[versionField] = 1
+
+	encrypted, err := i.collection.encrypted(objMap)
+	if err != nil {
+		return nil, err
+	}
+
+	memgodbMu.Lock()
+	MemgodbStorage = append(MemgodbStorage, encrypted)
+	memgodbRecordChanged(nil, objMap)
+	memgodbChanged()
+	memgodbMu.Unlock()
 
-	MemgodbStorage = append(MemgodbStorage, objMap)
 	return objMap, nil
 }
 
-// Many is a method available in Insert(). It adds many records into the storage at once
-func (i *Insert) Many(arr interface{}) ([]interface{}, error) {
+// Many is a method available in Insert(). It adds many records into the storage at once.
+// The records which can't be inserted don't stop the others from being inserted, and are reported with a BulkError.
+func (i *Insert) Many(arr interface{}) (_ []interface{}, err error) {
+	defer recoverPanic(&err)
+
 	if i.obj != nil {
 		return nil, errors.New("Many() params must be nil to insert Many")
 	}
@@ -144,26 +208,32 @@ func (i *Insert) Many(arr interface{}) ([]interface{}, error) {
 		return nil, errors.New("function param must be a [slice]")
 	}
 
-	arrObjs, err := i.collection.decodeMany(arr)
-	if err != nil {
-		return nil, err
-	}
-
+	var failed []*ItemError
 	var savedData []interface{}
-	for _, obj := range arrObjs {
+	objs := reflect.ValueOf(arr)
+	for index := 0; index < objs.Len(); index++ {
+		obj, err := i.collection.decode(objs.Index(index).Interface())
+		if err != nil {
+			failed = append(failed, &ItemError{Index: index, Err: err})
+			continue
+		}
+
 		saved, err := i.collection.Insert(obj).One()
 		if err != nil {
-			return nil, err
+			failed = append(failed, &ItemError{Index: index, Err: err})
+			continue
 		}
 
 		savedData = append(savedData, saved)
 	}
 
-	return savedData, nil
+	return savedData, bulkError(failed)
 }
 
 // FromJsonFile is a method available in Insert(). It adds records into the storage from a json file
-func (i *Insert) FromJsonFile(fileLocation string) error {
+func (i *Insert) FromJsonFile(fileLocation string) (err error) {
+	defer recoverPanic(&err)
+
 	if i.obj != nil {
 		return errors.New("FromFile() params must be nil to insert from file")
 	}
@@ -174,6 +244,29 @@ func (i *Insert) FromJsonFile(fileLocation string) error {
 	}
 	defer f.Close()
 
+	return i.fromJson(f)
+}
+
+// FromJsonFS is a method available in Insert(). It adds records into the storage from a json file of fsys,
+// such as an embed.FS bundled into the binary
+func (i *Insert) FromJsonFS(fsys fs.FS, name string) (err error) {
+	defer recoverPanic(&err)
+
+	if i.obj != nil {
+		return errors.New("FromFile() params must be nil to insert from file")
+	}
+
+	f, err := fsys.Open(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return i.fromJson(f)
+}
+
+// fromJson adds the records of a json file into the storage
+func (i *Insert) fromJson(f io.Reader) error {
 	fileByte, err := io.ReadAll(f)
 	if err != nil {
 		return err
@@ -217,22 +310,40 @@ func (c *Collection) Filter(filter map[string]interface{}) *Filter {
 	var objMaps []map[string]interface{}
 	var err error
 
+	deadline := deadlineOf(c.timeout)
 	if filter != nil {
-		objMaps, err = c.decodeMany(MemgodbStorage)
+		memgodbMu.RLock()
+		objMaps, err = c.decodeStorage(MemgodbStorage, deadline)
+		memgodbMu.RUnlock()
+		if errors.Is(err, ErrTimeout) {
+			return &Filter{filter: filter, collection: *c, err: err}
+		}
 		if err != nil {
 			return nil
 		}
+		c.decrypt(objMaps)
 	}
 
 	return &Filter{
 		objMaps:    objMaps,
 		filter:     filter,
 		collection: *c,
+		deadline:   deadline,
 	}
 }
 
 // First is a method available in Filter(), it returns the first matching record from the filter.
-func (f *Filter) First() (map[string]interface{}, error) {
+func (f *Filter) First() (_ map[string]interface{}, err error) {
+	defer recoverPanic(&err)
+
+	if err := f.collection.chaos.inject(); err != nil {
+		return nil, err
+	}
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
 	if f.objMaps == nil {
 		return nil, errors.New("filter params cannot be nil")
 	}
@@ -240,10 +351,13 @@ func (f *Filter) First() (map[string]interface{}, error) {
 	notFound := true
 	var foundObj map[string]interface{}
 	counter := 0
-	for _, item := range f.objMaps {
+	for i, item := range f.objMaps {
+		if pastDeadline(f.deadline, i) {
+			return nil, ErrTimeout
+		}
 		for key, val := range f.filter {
 			if item["colName"] == f.collection.collectionName {
-				if v, ok := item[key]; ok && val == v {
+				if f.collection.matchField(item, key, val) {
 					if counter < 1 {
 						notFound = false
 						foundObj = item
@@ -263,27 +377,53 @@ func (f *Filter) First() (map[string]interface{}, error) {
 }
 
 // All is a method available in Filter(), it returns all the matching records from the filter.
-func (f *Filter) All() ([]map[string]interface{}, error) {
+func (f *Filter) All() (_ []map[string]interface{}, err error) {
+	defer recoverPanic(&err)
+
+	if err := f.collection.chaos.inject(); err != nil {
+		return nil, err
+	}
+
+	if f.err != nil {
+		return nil, f.err
+	}
+
 	if f.objMaps == nil {
 		var objMaps []map[string]interface{}
-		arrObj, err := json.Marshal(MemgodbStorage)
-		if err != nil {
-			return nil, err
-		}
+		if f.deadline.IsZero() {
+			memgodbMu.RLock()
+			arrObj, err := json.Marshal(MemgodbStorage)
+			memgodbMu.RUnlock()
+			if err != nil {
+				return nil, err
+			}
 
-		if err := json.Unmarshal(arrObj, &objMaps); err != nil {
-			return nil, err
+			if err := json.Unmarshal(arrObj, &objMaps); err != nil {
+				return nil, err
+			}
+		} else {
+			var err error
+			memgodbMu.RLock()
+			objMaps, err = f.collection.decodeStorage(MemgodbStorage, f.deadline)
+			memgodbMu.RUnlock()
+			if err != nil {
+				return nil, err
+			}
 		}
+		f.collection.decrypt(objMaps)
 
-		return objMaps, nil
+		return f.sorted(objMaps), nil
 	}
 
 	notFound := true
 	var foundObj []map[string]interface{}
-	for _, item := range f.objMaps {
+	for i, item := range f.objMaps {
+		if pastDeadline(f.deadline, i) {
+			return nil, ErrTimeout
+		}
 		for key, val := range f.filter {
 			if item["colName"] == f.collection.collectionName {
-				if v, ok := item[key]; ok && val == v {
+				if f.collection.matchField(item, key, val) {
 					notFound = false
 					foundObj = append(foundObj, item)
 				}
@@ -295,7 +435,7 @@ func (f *Filter) All() ([]map[string]interface{}, error) {
 		return nil, errors.New("record not found")
 	}
 
-	return foundObj, nil
+	return f.sorted(foundObj), nil
 }
 
 // Delete is used to delete a new record from the storage. It has two methods which are One() and Many().
@@ -304,10 +444,13 @@ func (c *Collection) Delete(filter map[string]interface{}) *Delete {
 	var err error
 
 	if filter != nil {
+		memgodbMu.RLock()
 		objMaps, err = c.decodeMany(MemgodbStorage)
+		memgodbMu.RUnlock()
 		if err != nil {
 			return nil
 		}
+		c.decrypt(objMaps)
 	}
 
 	return &Delete{
@@ -318,56 +461,72 @@ func (c *Collection) Delete(filter map[string]interface{}) *Delete {
 }
 
 // One is a method available in Delete(), it deletes a record and returns an error if any.
-func (d *Delete) One() error {
-	if d.objMaps == nil {
-		return errors.New("filter params cannot be nil")
-	}
+func (d *Delete) One() (err error) {
+	defer recoverPanic(&err)
 
-	notFound := true
-	for index, item := range d.objMaps {
-		for key, val := range d.filter {
-			if item["colName"] == d.collection.collectionName {
-				if v, ok := item[key]; ok && val == v {
-					notFound = false
-					if index < (len(MemgodbStorage) - 1) {
-						MemgodbStorage = append(MemgodbStorage[:index], MemgodbStorage[index+1:]...)
-						index--
-						break
-					} else {
-						MemgodbStorage = MemgodbStorage[:index]
-						break
-					}
-				}
-			}
-		}
+	if err := d.collection.chaos.inject(); err != nil {
+		return err
 	}
 
-	if notFound {
-		return errors.New("record not found")
+	if d.objMaps == nil {
+		return errors.New("filter params cannot be nil")
 	}
 
-	return nil
+	memgodbMu.Lock()
+	defer memgodbMu.Unlock()
+
+	return d.deleteMatching()
 }
 
 // All is a method available in Delete(), it deletes matching records from the filter and returns an error if any.
-func (d *Delete) All() error {
+func (d *Delete) All() (err error) {
+	defer recoverPanic(&err)
+
+	if err := d.collection.chaos.inject(); err != nil {
+		return err
+	}
+
+	memgodbMu.Lock()
+	defer memgodbMu.Unlock()
+
 	if d.objMaps == nil {
 		MemgodbStorage = MemgodbStorage[:0]
+		memgodbMigrations = nil
+		memgodbCleared()
+		memgodbChanged()
+		compactMemgodbIfNeeded()
 		return nil
 	}
 
+	return d.deleteMatching()
+}
+
+// deleteMatching deletes the records matching the filter. The caller must hold memgodbMu.
+func (d *Delete) deleteMatching() error {
+	// the records are decoded again under the lock, so the checks and the indexes see the latest writes
+	objMaps, err := d.collection.decodeMany(MemgodbStorage)
+	if err != nil {
+		return err
+	}
+	d.collection.decrypt(objMaps)
+
+	if err := d.collection.checkWrite(objMaps, d.filter); err != nil {
+		return err
+	}
+
 	notFound := true
-	for index, item := range d.objMaps {
+	deleted := 0
+	for index, item := range objMaps {
 		for key, val := range d.filter {
 			if item["colName"] == d.collection.collectionName {
-				if v, ok := item[key]; ok && val == v {
+				if d.collection.matchField(item, key, val) {
 					notFound = false
-					if index < (len(MemgodbStorage) - 1) {
-						MemgodbStorage = append(MemgodbStorage[:index], MemgodbStorage[index+1:]...)
-						index--
-					} else {
-						MemgodbStorage = MemgodbStorage[:index]
-					}
+					// the records left moved down by the number of records already deleted
+					index -= deleted
+					MemgodbStorage = append(MemgodbStorage[:index], MemgodbStorage[index+1:]...)
+					deleted++
+					memgodbRecordChanged(item, nil)
+					break
 				}
 			}
 		}
@@ -377,6 +536,8 @@ func (d *Delete) All() error {
 		return errors.New("record not found")
 	}
 
+	memgodbChanged()
+	compactMemgodbIfNeeded()
 	return nil
 }
 
@@ -386,10 +547,13 @@ func (c *Collection) Update(filter, obj map[string]interface{}) *Update {
 	var err error
 
 	if filter != nil {
+		memgodbMu.RLock()
 		objMaps, err = c.decodeMany(MemgodbStorage)
+		memgodbMu.RUnlock()
 		if err != nil {
 			return nil
 		}
+		c.decrypt(objMaps)
 	}
 
 	return &Update{
@@ -401,27 +565,54 @@ func (c *Collection) Update(filter, obj map[string]interface{}) *Update {
 }
 
 // One is a method available in Update(), it updates matching records from the filter, makes the necessry updated and returns an error if any.
-func (u *Update) One() error {
+func (u *Update) One() (err error) {
+	defer recoverPanic(&err)
+
+	if err := u.collection.chaos.inject(); err != nil {
+		return err
+	}
+
 	if u.objMaps == nil {
 		return errors.New("filter params cannot be nil")
 	}
 
+	memgodbMu.Lock()
+	defer memgodbMu.Unlock()
+
+	// the records are decoded again under the lock, so the checks and the indexes see the latest writes
+	objMaps, err := u.collection.decodeMany(MemgodbStorage)
+	if err != nil {
+		return err
+	}
+	u.collection.decrypt(objMaps)
+
+	if err := u.collection.checkWrite(objMaps, u.filter); err != nil {
+		return err
+	}
+
 	notFound := true
 	counter := 0
-	for index, item := range u.objMaps {
+	for index, item := range objMaps {
 		for key, val := range u.filter {
 			if item["colName"] == u.collection.collectionName {
-				if v, ok := item[key]; ok && val == v {
+				if u.collection.matchField(item, key, val) {
 					notFound = false
+					previous := copyRecord(item)
 					if counter < 1 {
 						for _, updateValue := range u.update {
 							item[key] = updateValue
 							counter++
 							break
 						}
-						item["updatedAt"] = time.Now()
+						item["updatedAt"] = u.collection.now()
+						item[versionField] = versionOf(item) + 1
+					}
+					encrypted, err := u.collection.encrypted(item)
+					if err != nil {
+						return err
 					}
-					MemgodbStorage[index] = item
+					MemgodbStorage[index] = encrypted
+					memgodbRecordChanged(previous, item)
 				}
 			}
 		}
@@ -431,52 +622,100 @@ func (u *Update) One() error {
 		return errors.New("record not found")
 	}
 
+	memgodbChanged()
 	return nil
 }
 
 // LoadDefault is used to load datas from the json file saved on the server using Persist() if any.
-func (n *Memgodb) LoadDefault() error {
-	f, err := os.Open("./memgodbstorage.json")
+func (n *Memgodb) LoadDefault() (err error) {
+	defer recoverPanic(&err)
+
+	f, err := os.Open(memgodbStorageFile)
 	if err != nil {
 		return errors.New("error finding file")
 	}
 	defer f.Close()
 
-	fileByte, err := io.ReadAll(f)
+	return n.load(f)
+}
+
+// LoadDefaultFS is used to load datas from the memgodbstorage.json file of fsys written by Persist(), such as an embed.FS bundled into the binary.
+func (n *Memgodb) LoadDefaultFS(fsys fs.FS) (err error) {
+	defer recoverPanic(&err)
+
+	f, err := fsys.Open(memgodbStorageName)
 	if err != nil {
-		return err
+		return errors.New("error finding file")
 	}
+	defer f.Close()
 
-	var obj interface{}
-	if err := json.Unmarshal(fileByte, &obj); err != nil {
-		return errors.New("invalid json file")
-	}
+	return n.load(f)
+}
 
-	t := reflect.TypeOf(obj)
-	if t.Kind() == reflect.Slice {
-		var objMap []interface{}
-		jsonByte, err := json.Marshal(obj)
-		if err != nil {
-			return err
+// load adds the records of a file written by Persist() into the storage. The file holds either a json array of
+// records, a single record, or records following each other like NDJSON. The records are decoded by
+// several goroutines and inserted in batches, so a large file is never decoded as a whole.
+func (n *Memgodb) load(f io.Reader) error {
+	return decodeParallel(f, loadWorkersOf(n.loadWorkers), func(records []interface{}) error {
+		memgodbMu.Lock()
+		defer memgodbMu.Unlock()
+
+		// the records tracking the migrations are kept apart from the others
+		stored := make([]interface{}, 0, len(records))
+		for _, record := range records {
+			if objMap, ok := record.(map[string]interface{}); ok && objMap["colName"] == migrationsCollection {
+				trackMigration(objMap)
+				continue
+			}
+			stored = append(stored, record)
 		}
 
-		if err := json.Unmarshal(jsonByte, &objMap); err != nil {
-			return err
+		MemgodbStorage = append(MemgodbStorage, stored...)
+		// the counters and the live queries see the records decrypted, like the other writes report them
+		col := Collection{logger: n.logger, encryptions: n.encryptions}
+		for _, record := range stored {
+			if record, ok := record.(map[string]interface{}); ok {
+				decrypted := copyRecord(record)
+				col.decrypt([]map[string]interface{}{decrypted})
+				memgodbRecordChanged(nil, decrypted)
+			}
 		}
+		memgodbChanged()
 
-		MemgodbStorage = append(MemgodbStorage, objMap...)
-	} else if t.Kind() == reflect.Map {
-		var objMap interface{}
-		jsonByte, err := json.Marshal(obj)
-		if err != nil {
-			return err
-		}
+		return nil
+	})
+}
+
+// LoadFiles is used to load the records of several files, such as the shards of a large dataset, in the format
+// accepted by LoadDefault(). The files are loaded concurrently, so the records of different files may be interleaved.
+// The error of the first file which failed to load is returned, the records of the other files are still loaded.
+func (n *Memgodb) LoadFiles(names ...string) (err error) {
+	defer recoverPanic(&err)
+
+	errs := make([]error, len(names))
 
-		if err := json.Unmarshal(jsonByte, &objMap); err != nil {
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+
+			f, err := os.Open(name)
+			if err != nil {
+				errs[i] = errors.New("error finding file")
+				return
+			}
+			defer f.Close()
+
+			errs[i] = n.load(f)
+		}(i, name)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
 			return err
 		}
-
-		MemgodbStorage = append(MemgodbStorage, objMap)
 	}
 
 	return nil
@@ -484,32 +723,113 @@ func (n *Memgodb) LoadDefault() error {
 
 // Persist is used to write data to file. All datas will be saved into a json file on the server.
 
-// This method will make sure all your your data's are saved into a json file. A cronJon runs ever minute and writes your data(s) into a json file to ensure data integrity
-func (n *Memgodb) Persist() error {
+// This method will make sure all your your data's are saved into a json file. A cronJon runs ever minute and writes your data(s) into a json file to ensure data integrity.
+// The file is only written when the datas changed since they were last persisted, and is replaced atomically so a crash never leaves it half written.
+func (n *Memgodb) Persist() (err error) {
+	defer recoverPanic(&err)
+
+	lock, unlock := memgodbMu.RLock, memgodbMu.RUnlock
+	if n.writeBarrier {
+		lock, unlock = memgodbMu.Lock, memgodbMu.Unlock
+	}
+
+	lock()
 	if MemgodbStorage == nil {
+		unlock()
 		return nil
 	}
 
-	persistMemgodbData = true
-	jsonByte, err := json.Marshal(MemgodbStorage)
-	if err != nil {
-		return err
+	persistMemgodbData.Store(true)
+	if !memgodbDirty.Swap(false) {
+		unlock()
+		return nil
 	}
 
-	file, err := os.Create("./memgodbstorage.json")
+	jsonByte, err := json.Marshal(withMigrations(n.ordered(n.persisted(MemgodbStorage)), n.persistCollections...))
+	if n.writeBarrier {
+		// mutations wait until the datas are safely on disk
+		defer unlock()
+	} else {
+		unlock()
+	}
 	if err != nil {
+		memgodbDirty.Store(true)
 		return err
 	}
-	defer file.Close()
 
-	_, err = file.Write(jsonByte)
-	if err != nil {
+	if err := writeFileAtomic(memgodbStorageFile, jsonByte); err != nil {
+		memgodbDirty.Store(true)
 		return err
 	}
 
 	return nil
 }
 
+// PersistCollections restricts Persist() to the records of the given collections, so ephemeral collections
+// (sessions, locks...) are never written to disk. Calling it without collections persists all the records again.
+func (n *Memgodb) PersistCollections(collections ...string) {
+	memgodbMu.Lock()
+	defer memgodbMu.Unlock()
+
+	n.persistCollections = nil
+	for _, col := range collections {
+		n.persistCollections = append(n.persistCollections, collectionName(col))
+	}
+	memgodbDirty.Store(true)
+}
+
+// persisted returns the records of storage Persist() writes. The caller must hold memgodbMu.
+func (n *Memgodb) persisted(storage []interface{}) []interface{} {
+	if len(n.persistCollections) == 0 {
+		return storage
+	}
+
+	records := []interface{}{}
+	for _, record := range storage {
+		objMap, ok := record.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		for _, col := range n.persistCollections {
+			if objMap["colName"] == col {
+				records = append(records, record)
+				break
+			}
+		}
+	}
+
+	return records
+}
+
+// newID returns the id of a new record, a UUID unless WithIDGenerator is used
+func (c *Collection) newID() interface{} {
+	if c.idGenerator != nil {
+		return c.idGenerator()
+	}
+
+	return uuid.New()
+}
+
+// now returns the current time of the records, see WithClock
+func (c *Collection) now() time.Time {
+	if c.clock != nil {
+		return c.clock()
+	}
+
+	return time.Now()
+}
+
+// collectionName returns the name a collection is stored with: lowercased and pluralized
+func collectionName(name string) string {
+	colName := strings.ToLower(name)
+	if len(colName) > 0 && string(colName[len(colName)-1]) != "s" {
+		colName = fmt.Sprintf("%ss", colName)
+	}
+
+	return colName
+}
+
 // decode decodes an interface{} into a map[string]interface{}
 func (c *Collection) decode(obj interface{}) (map[string]interface{}, error) {
 	objMap := make(map[string]interface{})
@@ -3,7 +3,9 @@ package fscache
 import (
 	"errors"
 	"fmt"
+	"os"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -113,6 +115,16 @@ func Test_Insert_FromJsonFile(t *testing.T) {
 	}
 }
 
+func Test_Insert_FromJsonFS(t *testing.T) {
+	ch := Cache{}
+
+	err := ch.Memgodb().Collection("fsuser").Insert(nil).FromJsonFS(os.DirFS("./testJsonFiles"), "objects.json")
+	assert.NoError(t, err)
+
+	err = ch.Memgodb().Collection("fsuser").Insert(nil).FromJsonFS(os.DirFS("./testJsonFiles"), "missing.json")
+	assert.Error(t, err)
+}
+
 func Test__Filter_First(t *testing.T) {
 	ch := Cache{}
 
@@ -270,6 +282,39 @@ func Test_Delete_All(t *testing.T) {
 	}
 }
 
+func Test_Delete_Matches(t *testing.T) {
+	ch := Cache{}
+
+	for _, name := range []string{"ada", "bob", "bob", "bob", "eve"} {
+		_, err := ch.Memgodb().Collection("deleted").Insert(map[string]interface{}{"name": name}).One()
+		assert.NoError(t, err)
+	}
+	filter := map[string]interface{}{"name": "bob"}
+
+	// One() and All() delete the adjacent matching records, and only them
+	for _, del := range []func(filter map[string]interface{}) error{
+		func(filter map[string]interface{}) error {
+			return ch.Memgodb().Collection("deleted").Delete(filter).One()
+		},
+		func(filter map[string]interface{}) error {
+			return ch.Memgodb().Collection("deleted").Delete(filter).All()
+		},
+	} {
+		assert.NoError(t, del(filter))
+		_, err := ch.Memgodb().Collection("deleted").Filter(filter).All()
+		assert.Error(t, err)
+		for _, name := range []string{"ada", "eve"} {
+			_, err = ch.Memgodb().Collection("deleted").Filter(map[string]interface{}{"name": name}).First()
+			assert.NoError(t, err, name)
+		}
+
+		for i := 0; i < 3; i++ {
+			_, err := ch.Memgodb().Collection("deleted").Insert(map[string]interface{}{"name": "bob"}).One()
+			assert.NoError(t, err)
+		}
+	}
+}
+
 func Test_Update_One(t *testing.T) {
 	ch := Cache{}
 
@@ -335,6 +380,7 @@ func Test_Update_One(t *testing.T) {
 }
 
 func Test_Persist(t *testing.T) {
+	withTempStorage(t)
 	ch := Cache{}
 
 	err := ch.Memgodb().Persist()
@@ -353,3 +399,54 @@ func Test_LoadDefault(t *testing.T) {
 		assert.Equal(t, errors.New("error finding file"), err)
 	}
 }
+
+func Test_LoadDefaultFS(t *testing.T) {
+	ch := Cache{}
+
+	fsys := fstest.MapFS{
+		"memgodbstorage.json": {Data: []byte(`[{"colName": "fsorders", "item": "book"}]`)},
+	}
+	assert.NoError(t, ch.Memgodb().LoadDefaultFS(fsys))
+
+	orders, err := ch.Memgodb().Collection("fsorders").Filter(map[string]interface{}{"item": "book"}).All()
+	assert.NoError(t, err)
+	assert.Len(t, orders, 1)
+
+	assert.Equal(t, errors.New("error finding file"), ch.Memgodb().LoadDefaultFS(fstest.MapFS{}))
+}
+
+func Test_Persist_DirtyTracking(t *testing.T) {
+	withTempStorage(t)
+
+	ch := Cache{}
+	WithWriteBarrier()(&ch)
+
+	_, err := ch.Memgodb().Collection("user").Insert(MemgodbTestCases[1]).One()
+	assert.NoError(t, err)
+	assert.NoError(t, ch.Memgodb().Persist())
+	assert.FileExists(t, memgodbStorageFile)
+
+	// nothing changed, so the file is not written again
+	assert.NoError(t, os.Remove(memgodbStorageFile))
+	assert.NoError(t, ch.Memgodb().Persist())
+	assert.NoFileExists(t, memgodbStorageFile)
+}
+
+func Test_PersistCollections(t *testing.T) {
+	withTempStorage(t)
+
+	ch := Cache{}
+	ch.Memgodb().PersistCollections("order")
+	defer ch.Memgodb().PersistCollections()
+
+	_, err := ch.Memgodb().Collection("order").Insert(map[string]interface{}{"item": "book"}).One()
+	assert.NoError(t, err)
+	_, err = ch.Memgodb().Collection("session").Insert(map[string]interface{}{"token": "secret"}).One()
+	assert.NoError(t, err)
+	assert.NoError(t, ch.Memgodb().Persist())
+
+	fileByte, err := os.ReadFile(memgodbStorageFile)
+	assert.NoError(t, err)
+	assert.Contains(t, string(fileByte), `"colName":"orders"`)
+	assert.NotContains(t, string(fileByte), `"colName":"sessions"`)
+}
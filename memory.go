@@ -0,0 +1,147 @@
+package fscache
+
+import (
+	"context"
+	"math"
+	runtimedebug "runtime/debug"
+	"runtime/metrics"
+	"sort"
+	"time"
+)
+
+const (
+	// heapMetric is the runtime metric of the memory occupied by the heap objects
+	heapMetric = "/memory/classes/heap/objects:bytes"
+	// defaultTargetHeapFraction is the fraction of the memory limit the heap is kept under by default
+	defaultTargetHeapFraction = 0.9
+	// defaultPressureInterval is how often the heap is checked by default
+	defaultPressureInterval = time.Second
+)
+
+// MemoryPressure object configures how Memdis reacts to memory pressure, see WithMemoryPressure()
+type MemoryPressure struct {
+	// Limit is the memory limit the heap is checked against. When 0, the soft memory limit of the process
+	// (GOMEMLIMIT or debug.SetMemoryLimit) is used, or else ContainerFraction of the container memory limit.
+	// The soft memory limit of the process is never changed.
+	Limit int64
+	// ContainerFraction is the fraction of the container memory limit the heap is checked against when the process
+	// has no soft memory limit, 0.9 when 0
+	ContainerFraction float64
+	// TargetHeapFraction is the fraction of the memory limit the heap is kept under, 0.9 when 0
+	TargetHeapFraction float64
+	// Interval is how often the heap is checked, every second when 0
+	Interval time.Duration
+	// OnPressure is called with the heap size and its target each time the heap exceeds the target,
+	// before the datas are evicted
+	OnPressure func(heap, target uint64)
+}
+
+// WithMemoryPressure makes Memdis watch the heap of the process and proactively evict datas, lowest priority first,
+// when it exceeds a fraction of the memory limit, so the cache gives memory back before the garbage collector
+// thrashes or the container gets killed. WithFullPolicy can make Memdis reject or drop the new datas instead.
+// The memory limit is pressure.Limit, or else the one set with debug.SetMemoryLimit or GOMEMLIMIT, which is only
+// read. Without any, the memory limit of the container (cgroup v1 or v2) the process runs in is used, so it works
+// out of the box in Kubernetes. Nothing is evicted while the process has no memory limit.
+func WithMemoryPressure(pressure MemoryPressure) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.memoryPressure = &pressure
+	}
+}
+
+// watchMemory checks the heap every pressure interval until ctx is done, and evicts datas while it exceeds its target
+func (md *Memdis) watchMemory(ctx context.Context) {
+	pressure := *md.memoryPressure
+	if pressure.ContainerFraction <= 0 {
+		pressure.ContainerFraction = defaultContainerFraction
+	}
+	container, hasContainer := ContainerMemoryLimit()
+	if pressure.TargetHeapFraction <= 0 {
+		pressure.TargetHeapFraction = defaultTargetHeapFraction
+	}
+	if pressure.Interval <= 0 {
+		pressure.Interval = defaultPressureInterval
+	}
+
+	ticker := time.NewTicker(pressure.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+
+		limit := pressure.Limit
+		if limit <= 0 {
+			// a negative value only reads the limit, which may have been changed since
+			limit = runtimedebug.SetMemoryLimit(-1)
+		}
+		if limit == math.MaxInt64 && hasContainer {
+			limit = int64(float64(container) * pressure.ContainerFraction)
+		}
+		if limit <= 0 || limit == math.MaxInt64 {
+			md.state().pressured.Store(false)
+			continue
+		}
+
+		target := uint64(float64(limit) * pressure.TargetHeapFraction)
+		heap := heapInUse()
+		md.state().pressured.Store(heap > target && md.fullPolicy != FullEvict)
+		if heap > target {
+			if pressure.OnPressure != nil {
+				pressure.OnPressure(heap, target)
+			}
+			// the other policies stop the new datas instead
+			if md.fullPolicy == FullEvict {
+				md.relieve(heap, target)
+			}
+		}
+	}
+}
+
+// heapInUse returns the memory occupied by the heap objects
+func heapInUse() uint64 {
+	sample := []metrics.Sample{{Name: heapMetric}}
+	metrics.Read(sample)
+
+	if sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+
+	return sample[0].Value.Uint64()
+}
+
+// relieve evicts the share of the datas the heap exceeds its target by, the lowest priority ones first
+func (md *Memdis) relieve(heap, target uint64) {
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	type candidate struct {
+		key  string
+		data MemdisData
+	}
+
+	candidates := make([]candidate, 0, len(md.storage))
+	for key, value := range md.storage {
+		candidates = append(candidates, candidate{key: key, data: value})
+	}
+	if len(candidates) == 0 {
+		return
+	}
+
+	count := int(math.Ceil(float64(len(candidates)) * float64(heap-target) / float64(heap)))
+	sort.Slice(candidates, func(i, j int) bool {
+		return evictedBefore(candidates[i].data, candidates[j].data)
+	})
+
+	for _, c := range candidates[:count] {
+		if debug {
+			md.logger.Info().Msgf("data object [%v] got evicted under memory pressure", md.loggedKey(c.key))
+		}
+		md.remove(storedIndex, c.key)
+		md.state().evictions.Add(1)
+		md.notify(KeyEvict, c.key)
+		md.forgetDigest(c.key)
+	}
+}
@@ -0,0 +1,29 @@
+package fscache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryPressureRelieve(t *testing.T) {
+	ch := Cache{}
+	WithMaxCost(100)(&ch)
+
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, ch.Memdis().SetWithCost(fmt.Sprintf("pressure%d", i), i, int64(i+1)))
+	}
+
+	// the heap exceeds its target by 30%, so 30% of the datas are evicted, the cheapest first
+	ch.Memdis().relieve(1000, 700)
+
+	for i := 0; i < 10; i++ {
+		_, err := ch.Memdis().Get(fmt.Sprintf("pressure%d", i))
+		if i < 3 {
+			assert.Error(t, err)
+		} else {
+			assert.NoError(t, err)
+		}
+	}
+}
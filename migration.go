@@ -0,0 +1,195 @@
+package fscache
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// migrationsCollection is the colName of the records tracking the applied migration versions, so they are
+// persisted and loaded along with the records
+const migrationsCollection = "_migrations"
+
+var (
+	// memgodbMigrations are the records tracking the migrations applied by collection, guarded by memgodbMu. They are
+	// kept apart from MemgodbStorage so the queries, the stats and the counters never see them.
+	memgodbMigrations map[string]map[string]interface{}
+)
+
+var (
+	// errInvalidMigration the migration returned no document
+	errInvalidMigration = errors.New("migration must return a document")
+	// ErrMigrationConflict is returned by Migrate() when the documents of the collection were written while the
+	// migration ran, nothing being migrated
+	ErrMigrationConflict = errors.New("documents changed during the migration")
+)
+
+// Migrate applies up to every document of collection, unless a migration of the same or a higher version was already
+// applied to it. Migrations are tracked apart from the documents, in "_migrations" records written along with them
+// by Persist() and SnapshotExport(), so running them again on every start only applies the new ones. The documents keep their id, colName and createdAt,
+// and their version is incremented. No document is migrated if up returns nil for any of them.
+// up runs without holding the lock of Memgodb, so it may read other collections. If the documents of collection are
+// written while it runs, nothing is migrated and ErrMigrationConflict is returned, so the migration can be retried.
+func (n *Memgodb) Migrate(collection string, version int, up func(doc map[string]interface{}) map[string]interface{}) (err error) {
+	defer recoverPanic(&err)
+
+	col := n.Collection(collection)
+
+	memgodbMu.RLock()
+	applied := migrationVersion(col.collectionName)
+	if version <= applied {
+		memgodbMu.RUnlock()
+		return nil
+	}
+	objMaps, err := col.decodeMany(MemgodbStorage)
+	memgodbMu.RUnlock()
+	if err != nil {
+		return err
+	}
+	col.decrypt(objMaps)
+
+	// the documents are migrated by id, along with the version they had
+	migrated := make(map[string]map[string]interface{})
+	versions := make(map[string]int)
+	for _, item := range objMaps {
+		if item["colName"] != col.collectionName {
+			continue
+		}
+
+		doc := up(copyRecord(item))
+		if doc == nil {
+			return errInvalidMigration
+		}
+		id := fmt.Sprint(item["id"])
+		for _, field := range []string{"id", "colName", "createdAt"} {
+			doc[field] = item[field]
+		}
+		doc["updatedAt"] = col.now()
+		doc[versionField] = versionOf(item) + 1
+
+		migrated[id] = doc
+		versions[id] = versionOf(item)
+	}
+
+	encrypted := make(map[string]interface{}, len(migrated))
+	for id, doc := range migrated {
+		if encrypted[id], err = col.encrypted(doc); err != nil {
+			return err
+		}
+	}
+
+	memgodbMu.Lock()
+	defer memgodbMu.Unlock()
+
+	if version <= migrationVersion(col.collectionName) {
+		return nil
+	}
+
+	objMaps, err = col.decodeMany(MemgodbStorage)
+	if err != nil {
+		return err
+	}
+	col.decrypt(objMaps)
+
+	// the documents must be the ones up migrated, at the same version
+	indexes := make(map[string]int, len(migrated))
+	for index, item := range objMaps {
+		if item["colName"] != col.collectionName {
+			continue
+		}
+
+		id := fmt.Sprint(item["id"])
+		if version, ok := versions[id]; !ok || version != versionOf(item) {
+			return ErrMigrationConflict
+		}
+		indexes[id] = index
+	}
+	if len(indexes) != len(migrated) {
+		return ErrMigrationConflict
+	}
+
+	for id, index := range indexes {
+		MemgodbStorage[index] = encrypted[id]
+		memgodbRecordChanged(objMaps[index], migrated[id])
+	}
+
+	tracking := map[string]interface{}{
+		"colName":    migrationsCollection,
+		"collection": col.collectionName,
+		"version":    version,
+		"createdAt":  col.now(),
+		"updatedAt":  col.now(),
+	}
+	// the records tracking the migrations used to be written without an id
+	if previous := memgodbMigrations[col.collectionName]; previous["id"] != nil {
+		tracking["id"], tracking["createdAt"] = previous["id"], previous["createdAt"]
+	} else {
+		tracking["id"] = col.newID()
+	}
+	trackMigration(tracking)
+	memgodbChanged()
+
+	return nil
+}
+
+// MigrationVersion returns the version of the last migration applied to collection, 0 if none was
+func (n *Memgodb) MigrationVersion(collection string) int {
+	memgodbMu.RLock()
+	defer memgodbMu.RUnlock()
+
+	return migrationVersion(collectionName(collection))
+}
+
+// migrationVersion returns the version of the last migration applied to collection. The caller must hold memgodbMu.
+func migrationVersion(collection string) int {
+	return intOf(memgodbMigrations[collection]["version"])
+}
+
+// trackMigration records tracking as the last migration applied to its collection, unless a later one was already
+// applied, e.g. when loading the records. The caller must hold memgodbMu.
+func trackMigration(tracking map[string]interface{}) {
+	collection := fmt.Sprint(tracking["collection"])
+	if previous, ok := memgodbMigrations[collection]; ok && intOf(previous["version"]) > intOf(tracking["version"]) {
+		return
+	}
+
+	if memgodbMigrations == nil {
+		memgodbMigrations = make(map[string]map[string]interface{})
+	}
+	memgodbMigrations[collection] = tracking
+}
+
+// withMigrations returns records followed by the records tracking the migrations of collections, all of them when
+// empty, so they are loaded back along with them. The caller must hold memgodbMu.
+func withMigrations(records []interface{}, collections ...string) []interface{} {
+	var tracked []string
+	for collection := range memgodbMigrations {
+		if len(collections) == 0 || slices.Contains(collections, collection) {
+			tracked = append(tracked, collection)
+		}
+	}
+	if len(tracked) == 0 {
+		return records
+	}
+	sort.Strings(tracked)
+
+	// records may be the storage itself, which must not be appended to
+	all := make([]interface{}, 0, len(records)+len(tracked))
+	all = append(all, records...)
+	for _, collection := range tracked {
+		all = append(all, memgodbMigrations[collection])
+	}
+
+	return all
+}
+
+// copyRecord returns a shallow copy of record
+func copyRecord(record map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(record))
+	for key, value := range record {
+		copied[key] = value
+	}
+
+	return copied
+}
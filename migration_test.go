@@ -0,0 +1,75 @@
+package fscache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Migrate(t *testing.T) {
+	ch := Cache{}
+
+	_, err := ch.Memgodb().Collection("migrated").Insert(map[string]interface{}{"name": "John Doe"}).One()
+	assert.NoError(t, err)
+
+	splitName := func(doc map[string]interface{}) map[string]interface{} {
+		names := strings.SplitN(doc["name"].(string), " ", 2)
+		doc["firstName"], doc["lastName"] = names[0], names[1]
+		delete(doc, "name")
+		return doc
+	}
+	assert.NoError(t, ch.Memgodb().Migrate("migrated", 1, splitName))
+	assert.Equal(t, 1, ch.Memgodb().MigrationVersion("migrated"))
+
+	// migrations already applied are skipped
+	assert.NoError(t, ch.Memgodb().Migrate("migrated", 1, splitName))
+
+	record, err := ch.Memgodb().Collection("migrated").Filter(map[string]interface{}{"firstName": "John"}).First()
+	assert.NoError(t, err)
+	assert.Equal(t, "Doe", record["lastName"])
+	assert.NotContains(t, record, "name")
+	assert.Equal(t, 2.0, record[versionField])
+
+	assert.Equal(t, errInvalidMigration, ch.Memgodb().Migrate("migrated", 2, func(map[string]interface{}) map[string]interface{} {
+		return nil
+	}))
+	assert.Equal(t, 1, ch.Memgodb().MigrationVersion("migrated"))
+
+	// the records tracking the migrations have an id, and are kept apart from the documents
+	memgodbMu.RLock()
+	assert.NotEmpty(t, memgodbMigrations["migrateds"]["id"])
+	memgodbMu.RUnlock()
+	_, err = ch.Memgodb().Collection(migrationsCollection).Filter(map[string]interface{}{"collection": "migrateds"}).First()
+	assert.Error(t, err)
+	assert.NotContains(t, ch.Stats().Memgodb.Collections, migrationsCollection)
+
+	// they are exported and loaded back along with the documents
+	var export bytes.Buffer
+	assert.NoError(t, ch.Memgodb().SnapshotExport(&export))
+	assert.Contains(t, export.String(), `"collection":"migrateds"`)
+	assert.NoError(t, ch.Memgodb().load(strings.NewReader(`{"colName":"_migrations","collection":"loadedmigrations","version":3}`)))
+	assert.Equal(t, 3, ch.Memgodb().MigrationVersion("loadedmigrations"))
+	_, err = ch.Memgodb().Collection(migrationsCollection).Filter(map[string]interface{}{"collection": "loadedmigrations"}).First()
+	assert.Error(t, err)
+
+	// up runs outside of the lock, so it may use Memgodb
+	assert.NoError(t, ch.Memgodb().Migrate("migrated", 2, func(doc map[string]interface{}) map[string]interface{} {
+		_, err := ch.Memgodb().Collection("migrated").Filter(map[string]interface{}{"firstName": "John"}).First()
+		assert.NoError(t, err)
+		doc["migrated"] = true
+		return doc
+	}))
+	assert.Equal(t, 2, ch.Memgodb().MigrationVersion("migrated"))
+
+	// the documents written while up runs are not overwritten
+	assert.Equal(t, ErrMigrationConflict, ch.Memgodb().Migrate("migrated", 3, func(doc map[string]interface{}) map[string]interface{} {
+		assert.NoError(t, ch.Memgodb().Collection("migrated").Patch(map[string]interface{}{"firstName": "John"}, map[string]interface{}{"age": 30}))
+		return doc
+	}))
+	assert.Equal(t, 2, ch.Memgodb().MigrationVersion("migrated"))
+	record, err = ch.Memgodb().Collection("migrated").Filter(map[string]interface{}{"firstName": "John"}).First()
+	assert.NoError(t, err)
+	assert.Equal(t, 30.0, record["age"])
+}
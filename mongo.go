@@ -0,0 +1,653 @@
+package fscache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	// ErrNoDocuments is returned by MongoCollection.FindOne() when no document matches, like mongo.ErrNoDocuments
+	ErrNoDocuments = errors.New("mongo: no documents in result")
+	// errInvalidUpdate the update is not made of update operators
+	errInvalidUpdate = errors.New("update document must contain update operators like $set, $unset or $inc")
+	// errCursorExhausted the cursor has no current document
+	errCursorExhausted = errors.New("cursor has no current document")
+)
+
+// M is an unordered document, like bson.M. The bson.M values of mongo-driver can be given as they are.
+type M = map[string]interface{}
+
+type (
+	// MongoCollection object mimics the Collection of mongo-driver on top of a Memgodb collection, so unit tests can
+	// swap a real MongoDB for fs-cache. The filters match the documents having all their fields, which are compared
+	// by value or with the query operators $eq, $ne, $gt, $gte, $lt, $lte, $in, $nin, $exists, $size, $type and
+	// $elemMatch. "_id" refers to the id of the records, and the documents found carry it under "_id" as well as "id".
+	// The documents, filters and updates may be maps (bson.M), ordered documents (bson.D) or structs, whose fields are
+	// named by their bson tags, like mongo-driver does, or else by their json tags.
+	MongoCollection struct {
+		collection *Collection
+	}
+
+	// InsertOneResult object is the result of MongoCollection.InsertOne()
+	InsertOneResult struct {
+		InsertedID interface{}
+	}
+
+	// InsertManyResult object is the result of MongoCollection.InsertMany()
+	InsertManyResult struct {
+		InsertedIDs []interface{}
+	}
+
+	// UpdateResult object is the result of MongoCollection.UpdateOne() and UpdateMany()
+	UpdateResult struct {
+		MatchedCount  int64
+		ModifiedCount int64
+	}
+
+	// DeleteResult object is the result of MongoCollection.DeleteOne() and DeleteMany()
+	DeleteResult struct {
+		DeletedCount int64
+	}
+
+	// SingleResult object is the result of MongoCollection.FindOne()
+	SingleResult struct {
+		record map[string]interface{}
+		err    error
+	}
+
+	// MongoCursor object iterates over the documents found by MongoCollection.Find()
+	MongoCursor struct {
+		records []map[string]interface{}
+		current int
+	}
+)
+
+// Mongo returns the collection wrapped in a MongoCollection mimicking the Collection of mongo-driver
+func (c *Collection) Mongo() *MongoCollection {
+	return &MongoCollection{
+		collection: c,
+	}
+}
+
+// InsertOne inserts a document, a map or a struct, and returns its generated id
+func (m *MongoCollection) InsertOne(ctx context.Context, document interface{}) (*InsertOneResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	saved, err := m.collection.Insert(mongoValue(document)).One()
+	if err != nil {
+		return nil, err
+	}
+
+	return &InsertOneResult{InsertedID: saved.(map[string]interface{})["id"]}, nil
+}
+
+// InsertMany inserts documents, and returns their generated ids in the same order
+func (m *MongoCollection) InsertMany(ctx context.Context, documents []interface{}) (*InsertManyResult, error) {
+	result := &InsertManyResult{}
+	for _, document := range documents {
+		inserted, err := m.InsertOne(ctx, document)
+		if err != nil {
+			return result, err
+		}
+		result.InsertedIDs = append(result.InsertedIDs, inserted.InsertedID)
+	}
+
+	return result, nil
+}
+
+// FindOne returns the first document matching filter, or ErrNoDocuments if there is none
+func (m *MongoCollection) FindOne(ctx context.Context, filter interface{}) *SingleResult {
+	cursor, err := m.Find(ctx, filter)
+	if err != nil {
+		return &SingleResult{err: err}
+	}
+
+	if len(cursor.records) == 0 {
+		return &SingleResult{err: ErrNoDocuments}
+	}
+
+	return &SingleResult{record: cursor.records[0]}
+}
+
+// Find returns a cursor over the documents matching filter, an empty filter matching them all
+func (m *MongoCollection) Find(ctx context.Context, filter interface{}) (_ *MongoCursor, err error) {
+	defer recoverPanic(&err)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c := m.collection
+	if err := c.chaos.inject(); err != nil {
+		return nil, err
+	}
+
+	query, err := mongoQuery(c, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	memgodbMu.RLock()
+	objMaps, err := c.decodeStorage(MemgodbStorage, deadlineOf(c.timeout))
+	memgodbMu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	c.decrypt(objMaps)
+
+	var records []map[string]interface{}
+	for _, item := range objMaps {
+		if c.mongoMatches(item, query) {
+			item["_id"] = item["id"]
+			records = append(records, item)
+		}
+	}
+
+	return &MongoCursor{records: c.ordered(records), current: -1}, nil
+}
+
+// CountDocuments returns the number of documents matching filter
+func (m *MongoCollection) CountDocuments(ctx context.Context, filter interface{}) (int64, error) {
+	cursor, err := m.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(cursor.records)), nil
+}
+
+// UpdateOne applies the $set, $unset and $inc operators of update to the first document matching filter
+func (m *MongoCollection) UpdateOne(ctx context.Context, filter interface{}, update interface{}) (*UpdateResult, error) {
+	return m.update(ctx, filter, update, 1)
+}
+
+// UpdateMany applies the $set, $unset and $inc operators of update to all the documents matching filter
+func (m *MongoCollection) UpdateMany(ctx context.Context, filter interface{}, update interface{}) (*UpdateResult, error) {
+	return m.update(ctx, filter, update, 0)
+}
+
+// DeleteOne deletes the first document matching filter
+func (m *MongoCollection) DeleteOne(ctx context.Context, filter interface{}) (*DeleteResult, error) {
+	return m.delete(ctx, filter, 1)
+}
+
+// DeleteMany deletes all the documents matching filter
+func (m *MongoCollection) DeleteMany(ctx context.Context, filter interface{}) (*DeleteResult, error) {
+	return m.delete(ctx, filter, 0)
+}
+
+// update updates up to limit documents matching filter, all of them when limit is 0
+func (m *MongoCollection) update(ctx context.Context, filter, update interface{}, limit int) (_ *UpdateResult, err error) {
+	defer recoverPanic(&err)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c := m.collection
+	if err := c.chaos.inject(); err != nil {
+		return nil, err
+	}
+
+	query, err := mongoQuery(c, filter)
+	if err != nil {
+		return nil, err
+	}
+	ops, err := mongoQuery(c, update)
+	if err != nil {
+		return nil, err
+	}
+	for op, fields := range ops {
+		if _, ok := fields.(map[string]interface{}); !ok || (op != "$set" && op != "$unset" && op != "$inc") {
+			return nil, errInvalidUpdate
+		}
+		for field := range fields.(map[string]interface{}) {
+			if field == "_id" || isReserved(field) {
+				return nil, errReservedField
+			}
+		}
+	}
+
+	memgodbMu.Lock()
+	defer memgodbMu.Unlock()
+
+	objMaps, err := c.decodeMany(MemgodbStorage)
+	if err != nil {
+		return nil, err
+	}
+	c.decrypt(objMaps)
+
+	matched := c.mongoMatching(objMaps, query, limit)
+	if err := c.checkWriteWhere(objMaps, func(item map[string]interface{}) bool {
+		_, ok := matched[fmt.Sprint(item["id"])]
+		return ok
+	}); err != nil {
+		return nil, err
+	}
+
+	result := &UpdateResult{}
+	updated := make(map[int]interface{})
+	records := make(map[int]map[string]interface{})
+	for index, item := range objMaps {
+		if _, ok := matched[fmt.Sprint(item["id"])]; !ok {
+			continue
+		}
+		result.MatchedCount++
+
+		record, err := mongoUpdated(item, ops)
+		if err != nil {
+			return nil, err
+		}
+		if jsonEqual(record, item) {
+			continue
+		}
+		record["updatedAt"] = c.now()
+		record[versionField] = versionOf(item) + 1
+
+		encrypted, err := c.encrypted(record)
+		if err != nil {
+			return nil, err
+		}
+		updated[index] = encrypted
+		records[index] = record
+	}
+
+	for index, record := range updated {
+		MemgodbStorage[index] = record
+		memgodbRecordChanged(objMaps[index], records[index])
+	}
+	result.ModifiedCount = int64(len(updated))
+	if len(updated) > 0 {
+		memgodbChanged()
+	}
+
+	return result, nil
+}
+
+// delete deletes up to limit documents matching filter, all of them when limit is 0
+func (m *MongoCollection) delete(ctx context.Context, filter interface{}, limit int) (_ *DeleteResult, err error) {
+	defer recoverPanic(&err)
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	c := m.collection
+	if err := c.chaos.inject(); err != nil {
+		return nil, err
+	}
+
+	query, err := mongoQuery(c, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	memgodbMu.Lock()
+	defer memgodbMu.Unlock()
+
+	objMaps, err := c.decodeMany(MemgodbStorage)
+	if err != nil {
+		return nil, err
+	}
+	c.decrypt(objMaps)
+
+	matched := c.mongoMatching(objMaps, query, limit)
+	isMatched := func(item map[string]interface{}) bool {
+		_, ok := matched[fmt.Sprint(item["id"])]
+		return ok
+	}
+	if err := c.checkWriteWhere(objMaps, isMatched); err != nil {
+		return nil, err
+	}
+
+	kept := MemgodbStorage[:0]
+	for index, item := range objMaps {
+		if isMatched(item) {
+			memgodbRecordChanged(item, nil)
+			continue
+		}
+		kept = append(kept, MemgodbStorage[index])
+	}
+	MemgodbStorage = kept
+
+	if len(matched) > 0 {
+		memgodbChanged()
+		compactMemgodbIfNeeded()
+	}
+
+	return &DeleteResult{DeletedCount: int64(len(matched))}, nil
+}
+
+// mongoMatching returns the ids of up to limit records matching query, in the iteration order of the collection.
+// The caller must hold memgodbMu.
+func (c *Collection) mongoMatching(objMaps []map[string]interface{}, query map[string]interface{}, limit int) map[string]bool {
+	var records []map[string]interface{}
+	for _, item := range objMaps {
+		if c.mongoMatches(item, query) {
+			records = append(records, item)
+		}
+	}
+	records = c.ordered(records)
+
+	matched := make(map[string]bool)
+	for _, record := range records {
+		if limit > 0 && len(matched) == limit {
+			break
+		}
+		matched[fmt.Sprint(record["id"])] = true
+	}
+
+	return matched
+}
+
+// mongoMatches reports whether item belongs to the collection and matches all the fields of query
+func (c *Collection) mongoMatches(item, query map[string]interface{}) bool {
+	if item["colName"] != c.collectionName {
+		return false
+	}
+
+	for field, filter := range query {
+		if field == "_id" {
+			field = "id"
+		}
+
+		value, present := item[field]
+		ops, isOps := queryOperators(filter)
+		if !isOps {
+			if !present || !c.equal(filter, value) {
+				return false
+			}
+			continue
+		}
+
+		others := make(map[string]interface{})
+		for op, arg := range ops {
+			if matched, ok := c.mongoCompare(op, arg, value, present); !ok {
+				others[op] = arg
+			} else if !matched {
+				return false
+			}
+		}
+		if len(others) > 0 && !c.matchOperators(others, value, present) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// mongoCompare reports whether value matches the comparison operator op, and false as second value if op is not
+// a comparison operator
+func (c *Collection) mongoCompare(op string, arg, value interface{}, present bool) (bool, bool) {
+	switch op {
+	case "$eq":
+		return present && c.equal(arg, value), true
+	case "$ne":
+		return !present || !c.equal(arg, value), true
+	case "$gt":
+		return present && c.less(arg, value), true
+	case "$gte":
+		return present && !c.less(value, arg), true
+	case "$lt":
+		return present && c.less(value, arg), true
+	case "$lte":
+		return present && !c.less(arg, value), true
+	case "$in", "$nin":
+		in := false
+		args, _ := arg.([]interface{})
+		for _, one := range args {
+			if present && c.equal(one, value) {
+				in = true
+				break
+			}
+		}
+		return in == (op == "$in"), true
+	}
+
+	return false, false
+}
+
+// mongoUpdated returns a copy of record with the update operators ops applied
+func mongoUpdated(record, ops map[string]interface{}) (map[string]interface{}, error) {
+	updated := copyRecord(record)
+	for op, fields := range ops {
+		for field, value := range fields.(map[string]interface{}) {
+			switch op {
+			case "$set":
+				updated[field] = value
+			case "$unset":
+				delete(updated, field)
+			case "$inc":
+				increment, ok := value.(float64)
+				current, isNumber := updated[field].(float64)
+				if _, present := updated[field]; !ok || (present && !isNumber) {
+					return nil, errInvalidUpdate
+				}
+				updated[field] = current + increment
+			}
+		}
+	}
+
+	return updated, nil
+}
+
+// mongoQuery returns document, a map, an ordered document or a struct, as a json document so its numbers compare
+// with the ones of the records. A nil document is empty.
+func mongoQuery(c *Collection, document interface{}) (map[string]interface{}, error) {
+	if document == nil {
+		return map[string]interface{}{}, nil
+	}
+
+	document = mongoValue(document)
+	if kind := reflect.TypeOf(document).Kind(); kind != reflect.Map && kind != reflect.Struct {
+		return nil, errors.New("document must either be a [map], an ordered document or a [struct]")
+	}
+
+	return c.decode(document)
+}
+
+// mongoValue returns value with its ordered documents (bson.D) turned into maps, and its structs having bson tags
+// turned into maps keyed by their bson names, so they are stored and compared like maps (bson.M)
+func mongoValue(value interface{}) interface{} {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return value
+		}
+		document := make(map[string]interface{}, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			document[iter.Key().String()] = mongoValue(iter.Value().Interface())
+		}
+		return document
+	case reflect.Slice:
+		if isOrderedDocument(v.Type()) {
+			document := make(map[string]interface{}, v.Len())
+			for i := 0; i < v.Len(); i++ {
+				document[v.Index(i).Field(0).String()] = mongoValue(v.Index(i).Field(1).Interface())
+			}
+			return document
+		}
+		switch v.Type().Elem().Kind() {
+		case reflect.Interface, reflect.Map, reflect.Slice, reflect.Struct:
+		default:
+			return value
+		}
+		values := make([]interface{}, v.Len())
+		for i := range values {
+			values[i] = mongoValue(v.Index(i).Interface())
+		}
+		return values
+	case reflect.Struct:
+		if !hasBSONTags(v.Type()) {
+			return value
+		}
+		document := make(map[string]interface{})
+		for i := 0; i < v.NumField(); i++ {
+			name, omitEmpty, ok := bsonName(v.Type().Field(i))
+			if !ok || (omitEmpty && v.Field(i).IsZero()) {
+				continue
+			}
+			document[name] = mongoValue(v.Field(i).Interface())
+		}
+		return document
+	}
+
+	return value
+}
+
+// isOrderedDocument reports whether t is an ordered document like bson.D, a slice of Key and Value pairs
+func isOrderedDocument(t reflect.Type) bool {
+	elem := t.Elem()
+	return elem.Kind() == reflect.Struct && elem.NumField() == 2 &&
+		elem.Field(0).Name == "Key" && elem.Field(0).Type.Kind() == reflect.String &&
+		elem.Field(1).Name == "Value" && elem.Field(1).Type.Kind() == reflect.Interface
+}
+
+// hasBSONTags reports whether a field of the struct type t has a bson tag
+func hasBSONTags(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := t.Field(i).Tag.Lookup("bson"); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bsonName returns the name of field in a document like mongo-driver does: its bson tag, or else its json tag, or
+// else its lowercased name, and whether it is left out when empty. It returns false for the fields left out.
+func bsonName(field reflect.StructField) (string, bool, bool) {
+	if !field.IsExported() {
+		return "", false, false
+	}
+
+	tag, ok := field.Tag.Lookup("bson")
+	if !ok {
+		tag = field.Tag.Get("json")
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	if name == "-" {
+		return "", false, false
+	}
+	if name == "" {
+		name = strings.ToLower(field.Name)
+	}
+
+	return name, strings.Contains(options, "omitempty"), true
+}
+
+// Decode decodes the document found into v, like encoding/json
+func (r *SingleResult) Decode(v interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	return decodeRecord(r.record, v)
+}
+
+// Err returns the error of FindOne(), ErrNoDocuments if no document matched
+func (r *SingleResult) Err() error {
+	return r.err
+}
+
+// Next moves the cursor to the next document, and reports whether there is one
+func (cur *MongoCursor) Next(ctx context.Context) bool {
+	if ctx.Err() != nil || cur.current >= len(cur.records)-1 {
+		return false
+	}
+
+	cur.current++
+	return true
+}
+
+// Decode decodes the current document into v, like encoding/json
+func (cur *MongoCursor) Decode(v interface{}) error {
+	if cur.current < 0 || cur.current >= len(cur.records) {
+		return errCursorExhausted
+	}
+
+	return decodeRecord(cur.records[cur.current], v)
+}
+
+// All decodes all the documents left into results, a pointer to a slice, and closes the cursor
+func (cur *MongoCursor) All(ctx context.Context, results interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	left := cur.records[min(cur.current+1, len(cur.records)):]
+	if err := decodeRecord(left, results); err != nil {
+		return err
+	}
+
+	return cur.Close(ctx)
+}
+
+// Close closes the cursor
+func (cur *MongoCursor) Close(ctx context.Context) error {
+	cur.current = len(cur.records)
+	return nil
+}
+
+// decodeRecord decodes record, or a slice of records, into v, the fields of the structs having bson tags being
+// named by them
+func decodeRecord(record interface{}, v interface{}) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+
+	return decodeBSONFields(record, reflect.ValueOf(v))
+}
+
+// decodeBSONFields decodes into the fields of the structs of v having bson tags the fields of record named by them
+func decodeBSONFields(record interface{}, v reflect.Value) error {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		records, _ := record.([]map[string]interface{})
+		for i := 0; i < v.Len() && i < len(records); i++ {
+			if err := decodeBSONFields(records[i], v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		document, ok := record.(map[string]interface{})
+		if !ok || !hasBSONTags(v.Type()) {
+			return nil
+		}
+		for i := 0; i < v.NumField(); i++ {
+			name, _, ok := bsonName(v.Type().Field(i))
+			value, present := document[name]
+			if !ok || !present {
+				continue
+			}
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			if err := json.Unmarshal(data, v.Field(i).Addr().Interface()); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,97 @@
+package fscache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMongoCollection(t *testing.T) {
+	ch := Cache{}
+	users := ch.Memgodb().Collection("mongouser").Mongo()
+	ctx := context.Background()
+
+	type user struct {
+		Name string  `json:"name"`
+		Age  float64 `json:"age"`
+	}
+
+	inserted, err := users.InsertOne(ctx, M{"name": "mongo-john", "age": 30})
+	assert.NoError(t, err)
+	assert.NotEmpty(t, inserted.InsertedID)
+	_, err = users.InsertMany(ctx, []interface{}{user{Name: "mongo-jane", Age: 25}, M{"name": "mongo-joe", "age": 40}})
+	assert.NoError(t, err)
+
+	var found user
+	assert.NoError(t, users.FindOne(ctx, M{"_id": inserted.InsertedID}).Decode(&found))
+	assert.Equal(t, user{Name: "mongo-john", Age: 30}, found)
+	assert.Equal(t, ErrNoDocuments, users.FindOne(ctx, M{"name": "mongo-john", "age": 31}).Err())
+
+	cursor, err := users.Find(ctx, M{"age": M{"$gte": 30}})
+	assert.NoError(t, err)
+	var older []user
+	assert.NoError(t, cursor.All(ctx, &older))
+	assert.ElementsMatch(t, []user{{Name: "mongo-john", Age: 30}, {Name: "mongo-joe", Age: 40}}, older)
+
+	count, err := users.CountDocuments(ctx, M{"name": M{"$in": []interface{}{"mongo-jane", "mongo-joe"}}})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, count)
+
+	updated, err := users.UpdateOne(ctx, M{"name": "mongo-john"}, M{"$set": M{"city": "Lagos"}, "$inc": M{"age": 1}})
+	assert.NoError(t, err)
+	assert.Equal(t, &UpdateResult{MatchedCount: 1, ModifiedCount: 1}, updated)
+	assert.NoError(t, users.FindOne(ctx, M{"city": "Lagos"}).Decode(&found))
+	assert.EqualValues(t, 31, found.Age)
+
+	_, err = users.UpdateMany(ctx, M{}, M{"age": 1})
+	assert.Equal(t, errInvalidUpdate, err)
+
+	deleted, err := users.DeleteMany(ctx, M{"age": M{"$lt": 35}})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, deleted.DeletedCount)
+
+	count, err = users.CountDocuments(ctx, nil)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+}
+
+func TestMongoCollectionBSON(t *testing.T) {
+	ch := Cache{}
+	users := ch.Memgodb().Collection("mongobson").Mongo()
+	ctx := context.Background()
+
+	// element and document mimic bson.E and bson.D of mongo-driver
+	type element struct {
+		Key   string
+		Value interface{}
+	}
+	type document []element
+	type user struct {
+		ID   string  `bson:"_id,omitempty"`
+		Name string  `bson:"fullName"`
+		Age  float64 `bson:"age"`
+	}
+
+	inserted, err := users.InsertOne(ctx, user{Name: "bson-john", Age: 30})
+	assert.NoError(t, err)
+	_, err = users.InsertOne(ctx, document{{Key: "fullName", Value: "bson-jane"}, {Key: "age", Value: 25}})
+	assert.NoError(t, err)
+
+	// the documents are found with their id under _id, and decoded by their bson names
+	var found user
+	assert.NoError(t, users.FindOne(ctx, document{{Key: "fullName", Value: "bson-john"}}).Decode(&found))
+	assert.Equal(t, user{ID: fmt.Sprint(inserted.InsertedID), Name: "bson-john", Age: 30}, found)
+
+	updated, err := users.UpdateOne(ctx, document{{Key: "_id", Value: inserted.InsertedID}},
+		document{{Key: "$inc", Value: document{{Key: "age", Value: 1}}}})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, updated.ModifiedCount)
+
+	cursor, err := users.Find(ctx, document{{Key: "age", Value: M{"$gte": 26}}})
+	assert.NoError(t, err)
+	var older []user
+	assert.NoError(t, cursor.All(ctx, &older))
+	assert.Equal(t, []user{{ID: fmt.Sprint(inserted.InsertedID), Name: "bson-john", Age: 31}}, older)
+}
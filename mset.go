@@ -0,0 +1,117 @@
+package fscache
+
+import (
+	"fmt"
+	"time"
+)
+
+// ValueWithTTL object is a value set by MSet() along with its time to live, like the duration of Set()
+type ValueWithTTL struct {
+	Value interface{}
+	TTL   time.Duration
+}
+
+// MSet() sets many datas at once, replacing the values already set. The datas are set atomically: concurrent
+// readers see either none or all of them. Keys canonicalized into the same key are rejected with a BulkError,
+// and the new datas are all or none added when Memdis is full.
+func (md *Memdis) MSet(items map[string]ValueWithTTL) (err error) {
+	defer recoverPanic(&err)
+
+	if err := md.chaos.inject(); err != nil {
+		return err
+	}
+
+	datas := make(map[string]MemdisData, len(items))
+	originals := make(map[string]string, len(items))
+	var duplicates []*ItemError
+	for key, item := range items {
+		canonical, digestOf, err := md.digestedKey(key)
+		if err != nil {
+			return err
+		}
+
+		if other, ok := originals[canonical]; ok {
+			duplicates = append(duplicates, &ItemError{
+				Index: -1,
+				Key:   key,
+				Err:   fmt.Errorf("%w: same key as %q", errDuplicateKey, other),
+			})
+			continue
+		}
+		originals[canonical] = key
+
+		ttl := md.ttlOf([]time.Duration{item.TTL})
+		datas[canonical] = MemdisData{
+			Value:    item.Value,
+			Duration: expiresAt(ttl),
+			TTL:      ttl,
+			digestOf: digestOf,
+		}
+	}
+	if err := bulkError(duplicates); err != nil {
+		return err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	added := make(map[string]MemdisData)
+	var cost int64
+	for key, data := range datas {
+		if _, _, ok := md.lookup(key); !ok {
+			added[key] = data
+			cost += costOf(data)
+		}
+	}
+
+	if ok, err := md.room(cost); !ok {
+		return err
+	}
+
+	for key, data := range datas {
+		if index, _, ok := md.lookup(key); ok {
+			md.store(key, data)
+			if index == mappedIndex {
+				md.mapped.deleted[key] = true
+			}
+		}
+	}
+	md.insertMany([]map[string]MemdisData{added})
+
+	return nil
+}
+
+// MGet() retrieves the values of keys at once, in the order of keys, nil for the keys which are not found.
+// The values are read atomically: concurrent writers change either none or all of them.
+func (md *Memdis) MGet(keys ...string) (_ []interface{}, err error) {
+	defer recoverPanic(&err)
+
+	if err := md.chaos.inject(); err != nil {
+		return nil, err
+	}
+
+	canonical := make([]string, len(keys))
+	for i, key := range keys {
+		if canonical[i], err = md.canonicalKey(key); err != nil {
+			return nil, err
+		}
+	}
+
+	defer md.flushReadsIfFull()
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
+	values := make([]interface{}, len(keys))
+	for i, key := range canonical {
+		index, data, ok := md.lookup(key)
+		if !ok {
+			md.readMissed(key)
+			continue
+		}
+
+		md.read(index, key)
+		values[i] = data.Value
+	}
+
+	return values, nil
+}
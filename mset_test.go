@@ -0,0 +1,59 @@
+package fscache
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMSet(t *testing.T) {
+	ch := Cache{}
+	WithKeyTransform(KeyLower)(&ch)
+
+	assert.NoError(t, ch.Memdis().Set("b", "old"))
+
+	err := ch.Memdis().MSet(map[string]ValueWithTTL{
+		"a": {Value: 1},
+		"b": {Value: 2, TTL: time.Minute},
+	})
+	assert.NoError(t, err)
+
+	values, err := ch.Memdis().MGet("a", "B", "c")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{1, 2, nil}, values)
+
+	ttl, err := ch.Memdis().TTL("b")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+
+	// keys canonicalized into the same key reject the whole batch
+	err = ch.Memdis().MSet(map[string]ValueWithTTL{
+		"c": {Value: 3},
+		"d": {Value: 4},
+		"D": {Value: 5},
+	})
+	assert.ErrorIs(t, err, errDuplicateKey)
+	_, err = ch.Memdis().Get("c")
+	assert.ErrorIs(t, err, errKeyNotFound)
+
+	// readers see either none or all the datas of a batch
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			assert.NoError(t, ch.Memdis().MSet(map[string]ValueWithTTL{"x": {Value: i}, "y": {Value: i}}))
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			values, err := ch.Memdis().MGet("x", "y")
+			assert.NoError(t, err)
+			assert.Equal(t, values[0], values[1])
+		}
+	}()
+	wg.Wait()
+}
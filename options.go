@@ -0,0 +1,30 @@
+package fscache
+
+import "time"
+
+// Option configures the cache on New()
+type Option func(*Cache)
+
+// WithRefreshAhead refreshes data set with GetOrLoad() in the background once they are accessed
+// with less than threshold of their lifetime remaining, so hot keys never expire on readers.
+func WithRefreshAhead(threshold time.Duration) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.refreshAhead = threshold
+	}
+}
+
+// WithIDGenerator makes Memgodb generate the id of the records with generate instead of a UUID,
+// e.g. to use ULIDs in production or deterministic ids in tests. generate must be safe for concurrent use.
+func WithIDGenerator(generate func() string) Option {
+	return func(c *Cache) {
+		c.MemgodbInstance.idGenerator = generate
+	}
+}
+
+// WithClock makes Memgodb set the createdAt and updatedAt of the records with now instead of time.Now,
+// e.g. to get deterministic timestamps in tests. now must be safe for concurrent use.
+func WithClock(now func() time.Time) Option {
+	return func(c *Cache) {
+		c.MemgodbInstance.clock = now
+	}
+}
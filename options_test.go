@@ -0,0 +1,30 @@
+package fscache
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_WithIDGenerator_WithClock(t *testing.T) {
+	createdAt := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	var ids atomic.Int64
+
+	ch := Cache{}
+	WithIDGenerator(func() string {
+		return fmt.Sprintf("id-%d", ids.Add(1))
+	})(&ch)
+	WithClock(func() time.Time {
+		return createdAt
+	})(&ch)
+
+	res, err := ch.Memgodb().Collection("clocked").Insert(map[string]interface{}{"name": "john"}).One()
+	assert.NoError(t, err)
+
+	record := res.(map[string]interface{})
+	assert.Equal(t, "id-1", record["id"])
+	assert.Equal(t, createdAt, record["createdAt"])
+}
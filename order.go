@@ -0,0 +1,95 @@
+package fscache
+
+import (
+	"fmt"
+	"sort"
+)
+
+// IterationOrder defines the order Keys(), Values(), exports and the other enumerations return the datas
+// and records in
+type IterationOrder int
+
+const (
+	// OrderUnspecified enumerates the datas in no particular order, which is the fastest
+	OrderUnspecified IterationOrder = iota
+	// OrderInsertion enumerates the datas in the order they were first set, and the records in the order
+	// they were inserted
+	OrderInsertion
+	// OrderLexicographic enumerates the datas by key, and the records by id
+	OrderLexicographic
+)
+
+// WithIterationOrder makes the enumerations of Memdis and Memgodb reproducible, e.g. for golden-file tests and diffs.
+// It applies to Keys(), Values(), KeyValuePairs(), ForEachParallel(), SaveSnapshot(), the records returned by
+// All() unless Sort() is used, Persist() and SnapshotExport().
+func WithIterationOrder(order IterationOrder) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.iterationOrder = order
+		c.MemgodbInstance.iterationOrder = order
+	}
+}
+
+// orderedSnapshot calls fn like snapshot(), in the order set with WithIterationOrder(). The caller must hold md.state().mu.
+func (md *Memdis) orderedSnapshot(fn func(index int, key string, value MemdisData)) {
+	type snapshotEntry struct {
+		index int
+		key   string
+		value MemdisData
+	}
+
+	var entries []snapshotEntry
+	md.unorderedSnapshot(func(index int, key string, value MemdisData) {
+		entries = append(entries, snapshotEntry{index: index, key: key, value: value})
+	})
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if md.iterationOrder == OrderInsertion && a.value.sequence != b.value.sequence {
+			return a.value.sequence < b.value.sequence
+		}
+		return md.originalKey(a.key) < md.originalKey(b.key)
+	})
+
+	for _, entry := range entries {
+		fn(entry.index, entry.key, entry.value)
+	}
+}
+
+// ordered returns the records of storage in the order set with WithIterationOrder(), sorting a copy if needed.
+// Records are kept in the order they were inserted, so only OrderLexicographic sorts them.
+func (n *Memgodb) ordered(storage []interface{}) []interface{} {
+	if n.iterationOrder != OrderLexicographic {
+		return storage
+	}
+
+	records := make([]interface{}, len(storage))
+	copy(records, storage)
+	sort.SliceStable(records, func(i, j int) bool {
+		return recordID(records[i]) < recordID(records[j])
+	})
+
+	return records
+}
+
+// ordered returns the records found by a query in the order set with WithIterationOrder() like Memgodb.ordered()
+func (c *Collection) ordered(records []map[string]interface{}) []map[string]interface{} {
+	if c.iterationOrder != OrderLexicographic {
+		return records
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return fmt.Sprint(records[i]["id"]) < fmt.Sprint(records[j]["id"])
+	})
+
+	return records
+}
+
+// recordID returns the id of a record of the storage
+func recordID(record interface{}) string {
+	objMap, ok := record.(map[string]interface{})
+	if !ok {
+		return ""
+	}
+
+	return fmt.Sprint(objMap["id"])
+}
@@ -0,0 +1,50 @@
+package fscache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIterationOrder(t *testing.T) {
+	insertion := Cache{}
+	WithIterationOrder(OrderInsertion)(&insertion)
+	lexicographic := Cache{}
+	WithIterationOrder(OrderLexicographic)(&lexicographic)
+
+	for _, ch := range []*Cache{&insertion, &lexicographic} {
+		assert.NoError(t, ch.Memdis().Set("c", 1))
+		assert.NoError(t, ch.Memdis().Set("a", 2))
+		assert.NoError(t, ch.Memdis().Set("b", 3))
+	}
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, []string{"c", "a", "b"}, insertion.Memdis().Keys())
+		assert.Equal(t, []string{"a", "b", "c"}, lexicographic.Memdis().Keys())
+	}
+	assert.Equal(t, []interface{}{1, 2, 3}, insertion.Memdis().Values())
+}
+
+func Test_IterationOrder(t *testing.T) {
+	ids := []string{"c", "a", "b"}
+	var next int
+	ch := Cache{}
+	WithIterationOrder(OrderLexicographic)(&ch)
+	WithIDGenerator(func() string {
+		next++
+		return fmt.Sprintf("ordered-%s", ids[(next-1)%len(ids)])
+	})(&ch)
+
+	for range ids {
+		_, err := ch.Memgodb().Collection("ordereds").Insert(map[string]interface{}{"city": "Paris"}).One()
+		assert.NoError(t, err)
+	}
+
+	records, err := ch.Memgodb().Collection("ordereds").Filter(map[string]interface{}{"city": "Paris"}).All()
+	assert.NoError(t, err)
+	assert.Len(t, records, 3)
+	assert.Equal(t, "ordered-a", records[0]["id"])
+	assert.Equal(t, "ordered-b", records[1]["id"])
+	assert.Equal(t, "ordered-c", records[2]["id"])
+}
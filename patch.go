@@ -0,0 +1,423 @@
+package fscache
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var (
+	// errRecordNotFound record not found
+	errRecordNotFound = errors.New("record not found")
+	// errReservedField the field is managed by Memgodb
+	errReservedField = errors.New("id, colName, createdAt, updatedAt and _version cannot be patched")
+	// errInvalidPatch the patch is not valid
+	errInvalidPatch = errors.New("invalid patch")
+	// errPatchTestFailed a test operation of a patch failed
+	errPatchTestFailed = errors.New("patch test failed")
+)
+
+// reservedFields are the fields of the records managed by Memgodb
+var reservedFields = []string{"id", "colName", "createdAt", "updatedAt", versionField}
+
+// PatchOp object is a JSON Patch (RFC 6902) operation
+type PatchOp struct {
+	// Op is one of add, remove, replace, move, copy and test
+	Op string `json:"op"`
+	// Path is the JSON Pointer (RFC 6901) of the value to operate on, e.g. "/addresses/0/city"
+	Path string `json:"path"`
+	// From is the JSON Pointer of the value to move or copy
+	From string `json:"from,omitempty"`
+	// Value is the value to add, replace or test
+	Value interface{} `json:"value"`
+}
+
+// Patch applies a JSON Merge Patch (RFC 7386) to the records matching the filter: the fields of mergePatch replace
+// the ones of the records, nested documents are merged, and fields set to nil are removed.
+// It returns an error if no record matches the filter.
+func (c *Collection) Patch(filter, mergePatch map[string]interface{}) (err error) {
+	defer recoverPanic(&err)
+
+	for field := range mergePatch {
+		if isReserved(field) {
+			return errReservedField
+		}
+	}
+
+	return c.patch(filter, func(record map[string]interface{}) (map[string]interface{}, error) {
+		return mergePatchOf(record, mergePatch).(map[string]interface{}), nil
+	})
+}
+
+// PatchOps applies JSON Patch (RFC 6902) operations to the records matching the filter. The operations are
+// applied in order, and no record is updated if one of them fails, including a failing test operation.
+// It returns an error if no record matches the filter.
+func (c *Collection) PatchOps(filter map[string]interface{}, ops []PatchOp) (err error) {
+	defer recoverPanic(&err)
+
+	for _, op := range ops {
+		// the whole record can't be replaced, its reserved fields would be lost
+		if op.Path == "" {
+			return errInvalidPatch
+		}
+
+		for _, pointer := range []string{op.Path, op.From} {
+			tokens, err := parsePointer(pointer)
+			if err != nil {
+				return err
+			}
+			if len(tokens) > 0 && isReserved(tokens[0]) {
+				return errReservedField
+			}
+		}
+	}
+
+	return c.patch(filter, func(record map[string]interface{}) (map[string]interface{}, error) {
+		var doc interface{} = record
+		var err error
+		for _, op := range ops {
+			if doc, err = applyPatchOp(doc, op); err != nil {
+				return nil, err
+			}
+		}
+
+		patched, ok := doc.(map[string]interface{})
+		if !ok {
+			return nil, errInvalidPatch
+		}
+
+		return patched, nil
+	})
+}
+
+// patch replaces the records of the collection matching the filter with the result of fn.
+// The storage is left untouched if fn fails for any of them.
+func (c *Collection) patch(filter map[string]interface{}, fn func(record map[string]interface{}) (map[string]interface{}, error)) error {
+	memgodbMu.Lock()
+	defer memgodbMu.Unlock()
+
+	objMaps, err := c.decodeMany(MemgodbStorage)
+	if err != nil {
+		return err
+	}
+	c.decrypt(objMaps)
+
+	if err := c.checkWrite(objMaps, filter); err != nil {
+		return err
+	}
+
+	patched := make(map[int]interface{})
+	records := make(map[int]map[string]interface{})
+	for index, item := range objMaps {
+		if !c.matches(item, filter) {
+			continue
+		}
+
+		record, err := fn(item)
+		if err != nil {
+			return err
+		}
+		record["updatedAt"] = c.now()
+		record[versionField] = versionOf(item) + 1
+
+		encrypted, err := c.encrypted(record)
+		if err != nil {
+			return err
+		}
+		patched[index] = encrypted
+		records[index] = record
+	}
+
+	if len(patched) == 0 {
+		return errRecordNotFound
+	}
+
+	for index, record := range patched {
+		MemgodbStorage[index] = record
+		memgodbRecordChanged(objMaps[index], records[index])
+	}
+	memgodbChanged()
+
+	return nil
+}
+
+// matches reports whether item belongs to the collection and matches any of the fields of filter, same as Filter()
+func (c *Collection) matches(item, filter map[string]interface{}) bool {
+	if item["colName"] != c.collectionName {
+		return false
+	}
+
+	for key, val := range filter {
+		if c.matchField(item, key, val) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// mergePatchOf returns target with mergePatch applied as defined by RFC 7386
+func mergePatchOf(target, mergePatch interface{}) interface{} {
+	patch, ok := mergePatch.(map[string]interface{})
+	if !ok {
+		return mergePatch
+	}
+
+	doc, ok := target.(map[string]interface{})
+	if !ok {
+		doc = make(map[string]interface{})
+	}
+
+	merged := make(map[string]interface{}, len(doc))
+	for key, value := range doc {
+		merged[key] = value
+	}
+	for key, value := range patch {
+		if value == nil {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = mergePatchOf(merged[key], value)
+	}
+
+	return merged
+}
+
+// applyPatchOp returns doc with op applied as defined by RFC 6902
+func applyPatchOp(doc interface{}, op PatchOp) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add":
+		return pointerAdd(doc, path, op.Value)
+	case "remove":
+		doc, _, err := pointerRemove(doc, path)
+		return doc, err
+	case "replace":
+		if doc, _, err = pointerRemove(doc, path); err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, op.Value)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		var value interface{}
+		if op.Op == "move" {
+			doc, value, err = pointerRemove(doc, from)
+		} else {
+			value, err = pointerGet(doc, from)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		return pointerAdd(doc, path, value)
+	case "test":
+		value, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !jsonEqual(value, op.Value) {
+			return nil, errPatchTestFailed
+		}
+		return doc, nil
+	}
+
+	return nil, errInvalidPatch
+}
+
+// parsePointer splits a JSON Pointer into its unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, errInvalidPatch
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, token := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+// pointerGet returns the value of doc at path
+func pointerGet(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch d := doc.(type) {
+		case map[string]interface{}:
+			value, ok := d[token]
+			if !ok {
+				return nil, errPathNotFound
+			}
+			doc = value
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(d) {
+				return nil, errPathNotFound
+			}
+			doc = d[index]
+		default:
+			return nil, errPathNotFound
+		}
+	}
+
+	return doc, nil
+}
+
+// pointerAdd returns a copy of doc with value added at path. An index of "-" appends to a slice.
+func pointerAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token := path[0]
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(d)+1)
+		for k, v := range d {
+			copied[k] = v
+		}
+
+		if len(path) == 1 {
+			copied[token] = value
+			return copied, nil
+		}
+
+		child, ok := d[token]
+		if !ok {
+			return nil, errPathNotFound
+		}
+		child, err := pointerAdd(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		copied[token] = child
+
+		return copied, nil
+	case []interface{}:
+		if len(path) == 1 {
+			index := len(d)
+			if token != "-" {
+				var err error
+				if index, err = strconv.Atoi(token); err != nil || index < 0 || index > len(d) {
+					return nil, errPathNotFound
+				}
+			}
+
+			copied := make([]interface{}, 0, len(d)+1)
+			copied = append(copied, d[:index]...)
+			copied = append(copied, value)
+			return append(copied, d[index:]...), nil
+		}
+
+		index, err := strconv.Atoi(token)
+		if err != nil || index < 0 || index >= len(d) {
+			return nil, errPathNotFound
+		}
+		child, err := pointerAdd(d[index], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+
+		copied := append([]interface{}(nil), d...)
+		copied[index] = child
+
+		return copied, nil
+	}
+
+	return nil, errPathNotFound
+}
+
+// pointerRemove returns a copy of doc without the value at path, along with the removed value
+func pointerRemove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, errInvalidPatch
+	}
+
+	token := path[0]
+	switch d := doc.(type) {
+	case map[string]interface{}:
+		child, ok := d[token]
+		if !ok {
+			return nil, nil, errPathNotFound
+		}
+
+		copied := make(map[string]interface{}, len(d))
+		for k, v := range d {
+			copied[k] = v
+		}
+
+		if len(path) == 1 {
+			delete(copied, token)
+			return copied, child, nil
+		}
+
+		child, removed, err := pointerRemove(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		copied[token] = child
+
+		return copied, removed, nil
+	case []interface{}:
+		index, err := strconv.Atoi(token)
+		if err != nil || index < 0 || index >= len(d) {
+			return nil, nil, errPathNotFound
+		}
+
+		if len(path) == 1 {
+			copied := make([]interface{}, 0, len(d)-1)
+			copied = append(copied, d[:index]...)
+			return append(copied, d[index+1:]...), d[index], nil
+		}
+
+		child, removed, err := pointerRemove(d[index], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+
+		copied := append([]interface{}(nil), d...)
+		copied[index] = child
+
+		return copied, removed, nil
+	}
+
+	return nil, nil, errPathNotFound
+}
+
+// jsonEqual reports whether a and b have the same json representation
+func jsonEqual(a, b interface{}) bool {
+	var decodedA, decodedB interface{}
+	jsonA, errA := json.Marshal(a)
+	jsonB, errB := json.Marshal(b)
+	if errA != nil || errB != nil {
+		return false
+	}
+	if json.Unmarshal(jsonA, &decodedA) != nil || json.Unmarshal(jsonB, &decodedB) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(decodedA, decodedB)
+}
+
+// isReserved reports whether field is managed by Memgodb
+func isReserved(field string) bool {
+	for _, reserved := range reservedFields {
+		if field == reserved {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,83 @@
+package fscache
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Patch(t *testing.T) {
+	ch := Cache{}
+
+	_, err := ch.Memgodb().Collection("patched").Insert(map[string]interface{}{
+		"name":    "john",
+		"age":     30,
+		"address": map[string]interface{}{"city": "Paris", "zip": "75001"},
+	}).One()
+	assert.NoError(t, err)
+
+	filter := map[string]interface{}{"name": "john"}
+	assert.Equal(t, errReservedField, ch.Memgodb().Collection("patched").Patch(filter, map[string]interface{}{"id": "other"}))
+	assert.Equal(t, errRecordNotFound, ch.Memgodb().Collection("patched").Patch(map[string]interface{}{"name": "jane"}, map[string]interface{}{"age": 31}))
+
+	err = ch.Memgodb().Collection("patched").Patch(filter, map[string]interface{}{
+		"age":     31,
+		"address": map[string]interface{}{"city": "Lyon", "zip": nil},
+	})
+	assert.NoError(t, err)
+
+	record, err := ch.Memgodb().Collection("patched").Filter(filter).First()
+	assert.NoError(t, err)
+	assert.Equal(t, 31.0, record["age"])
+	assert.Equal(t, map[string]interface{}{"city": "Lyon"}, record["address"])
+	assert.NotNil(t, record["updatedAt"])
+}
+
+func Test_PatchOps(t *testing.T) {
+	ch := Cache{}
+
+	_, err := ch.Memgodb().Collection("patchedops").Insert(map[string]interface{}{
+		"name": "john",
+		"tags": []interface{}{"a", "b"},
+	}).One()
+	assert.NoError(t, err)
+
+	filter := map[string]interface{}{"name": "john"}
+	err = ch.Memgodb().Collection("patchedops").PatchOps(filter, []PatchOp{
+		{Op: "test", Path: "/name", Value: "john"},
+		{Op: "add", Path: "/tags/-", Value: "c"},
+		{Op: "remove", Path: "/tags/0"},
+		{Op: "copy", From: "/name", Path: "/nickname"},
+		{Op: "replace", Path: "/name", Value: "johnny"},
+	})
+	assert.NoError(t, err)
+
+	record, err := ch.Memgodb().Collection("patchedops").Filter(map[string]interface{}{"name": "johnny"}).First()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"b", "c"}, record["tags"])
+	assert.Equal(t, "john", record["nickname"])
+
+	// nothing is applied when an operation fails
+	err = ch.Memgodb().Collection("patchedops").PatchOps(map[string]interface{}{"name": "johnny"}, []PatchOp{
+		{Op: "replace", Path: "/name", Value: "jack"},
+		{Op: "test", Path: "/nickname", Value: "jack"},
+	})
+	assert.Equal(t, errPatchTestFailed, err)
+	_, err = ch.Memgodb().Collection("patchedops").Filter(map[string]interface{}{"name": "johnny"}).First()
+	assert.NoError(t, err)
+
+	assert.Equal(t, errReservedField, ch.Memgodb().Collection("patchedops").PatchOps(filter, []PatchOp{{Op: "remove", Path: "/id"}}))
+
+	// an explicit null survives the json encoding of the operations
+	data, err := json.Marshal([]PatchOp{{Op: "replace", Path: "/nickname", Value: nil}})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"op":"replace","path":"/nickname","value":null}]`, string(data))
+	var ops []PatchOp
+	assert.NoError(t, json.Unmarshal(data, &ops))
+	assert.NoError(t, ch.Memgodb().Collection("patchedops").PatchOps(map[string]interface{}{"name": "johnny"}, ops))
+	record, err = ch.Memgodb().Collection("patchedops").Filter(map[string]interface{}{"name": "johnny"}).First()
+	assert.NoError(t, err)
+	assert.Contains(t, record, "nickname")
+	assert.Nil(t, record["nickname"])
+}
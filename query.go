@@ -0,0 +1,168 @@
+package fscache
+
+import (
+	"reflect"
+	"strings"
+)
+
+// matchField reports whether the field of item matches filter, which is either a value compared using the
+// collation of the collection, or an object of query operators like {"$size": 2}
+func (c *Collection) matchField(item map[string]interface{}, field string, filter interface{}) bool {
+	value, ok := item[field]
+	if ops, isOps := queryOperators(filter); isOps {
+		return c.matchOperators(ops, value, ok)
+	}
+
+	return ok && c.equal(filter, value)
+}
+
+// matchOperators reports whether value matches all the query operators. Unknown operators never match.
+func (c *Collection) matchOperators(ops map[string]interface{}, value interface{}, present bool) bool {
+	for op, arg := range ops {
+		switch op {
+		case "$elemMatch":
+			if !present || !c.elemMatch(value, arg) {
+				return false
+			}
+		case "$size":
+			elements, ok := value.([]interface{})
+			size, isNumber := toInt(arg)
+			if !present || !ok || !isNumber || len(elements) != size {
+				return false
+			}
+		case "$exists":
+			exists, ok := arg.(bool)
+			if !ok || exists != present {
+				return false
+			}
+		case "$type":
+			if !present || !matchType(value, arg) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchType reports whether the json type of value is typ, or one of the types of the slice typ.
+// The types are "string", "number", "bool", "object", "array" and "null".
+func matchType(value, typ interface{}) bool {
+	switch t := typ.(type) {
+	case string:
+		return jsonType(value) == t || (t == "boolean" && jsonType(value) == "bool")
+	case []interface{}:
+		for _, one := range t {
+			if matchType(value, one) {
+				return true
+			}
+		}
+	case []string:
+		for _, one := range t {
+			if matchType(value, one) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// jsonType returns the json type of value
+func jsonType(value interface{}) string {
+	if value == nil {
+		return "null"
+	}
+
+	switch reflect.ValueOf(value).Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "bool"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Pointer:
+		return jsonType(reflect.ValueOf(value).Elem().Interface())
+	}
+
+	return ""
+}
+
+// elemMatch reports whether an element of the array value matches all the criteria: fields of sub-objects like
+// {"sku": "A1", "qty": {"$size": 2}}, or query operators applied to the elements themselves
+func (c *Collection) elemMatch(value, criteria interface{}) bool {
+	elements, ok := value.([]interface{})
+	if !ok {
+		return false
+	}
+
+	for _, element := range elements {
+		if ops, isOps := queryOperators(criteria); isOps {
+			if c.matchOperators(ops, element, true) {
+				return true
+			}
+			continue
+		}
+
+		fields, ok := criteria.(map[string]interface{})
+		object, isObject := element.(map[string]interface{})
+		if !ok || !isObject {
+			continue
+		}
+
+		matched := true
+		for field, filter := range fields {
+			if !c.matchField(object, field, filter) {
+				matched = false
+				break
+			}
+		}
+		if matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// queryOperators returns filter as query operators if all its keys start with "$"
+func queryOperators(filter interface{}) (map[string]interface{}, bool) {
+	ops, ok := filter.(map[string]interface{})
+	if !ok || len(ops) == 0 {
+		return nil, false
+	}
+
+	for op := range ops {
+		if !strings.HasPrefix(op, "$") {
+			return nil, false
+		}
+	}
+
+	return ops, true
+}
+
+// toInt converts a whole number given as any numeric type, like the float64 of decoded json, to an int
+func toInt(number interface{}) (int, bool) {
+	switch n := number.(type) {
+	case int:
+		return n, true
+	case int32:
+		return int(n), true
+	case int64:
+		return int(n), true
+	case float64:
+		if n == float64(int(n)) {
+			return int(n), true
+		}
+	}
+
+	return 0, false
+}
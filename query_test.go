@@ -0,0 +1,78 @@
+package fscache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Filter_ElemMatch_Size(t *testing.T) {
+	ch := Cache{}
+
+	orders := []map[string]interface{}{
+		{"ref": "order1", "items": []interface{}{
+			map[string]interface{}{"sku": "A1", "qty": 1},
+			map[string]interface{}{"sku": "B2", "qty": 5},
+		}},
+		{"ref": "order2", "items": []interface{}{
+			map[string]interface{}{"sku": "A1", "qty": 5},
+		}},
+	}
+	for _, order := range orders {
+		_, err := ch.Memgodb().Collection("lineitems").Insert(order).One()
+		assert.NoError(t, err)
+	}
+
+	// both criteria must match the same element
+	records, err := ch.Memgodb().Collection("lineitems").Filter(map[string]interface{}{
+		"items": map[string]interface{}{"$elemMatch": map[string]interface{}{"sku": "A1", "qty": 5.0}},
+	}).All()
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "order2", records[0]["ref"])
+
+	records, err = ch.Memgodb().Collection("lineitems").Filter(map[string]interface{}{
+		"items": map[string]interface{}{"$size": 2},
+	}).All()
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "order1", records[0]["ref"])
+
+	_, err = ch.Memgodb().Collection("lineitems").Filter(map[string]interface{}{
+		"items": map[string]interface{}{"$size": 3},
+	}).First()
+	assert.Error(t, err)
+}
+
+func Test_Filter_Exists_Type(t *testing.T) {
+	ch := Cache{}
+
+	for _, user := range []map[string]interface{}{
+		{"name": "john", "phone": "0123"},
+		{"name": "jane", "phone": 123},
+		{"name": "jack"},
+	} {
+		_, err := ch.Memgodb().Collection("typed").Insert(user).One()
+		assert.NoError(t, err)
+	}
+
+	records, err := ch.Memgodb().Collection("typed").Filter(map[string]interface{}{
+		"phone": map[string]interface{}{"$exists": false},
+	}).All()
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "jack", records[0]["name"])
+
+	records, err = ch.Memgodb().Collection("typed").Filter(map[string]interface{}{
+		"phone": map[string]interface{}{"$exists": true, "$type": "number"},
+	}).All()
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "jane", records[0]["name"])
+
+	records, err = ch.Memgodb().Collection("typed").Filter(map[string]interface{}{
+		"phone": map[string]interface{}{"$type": []string{"string", "number"}},
+	}).All()
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+}
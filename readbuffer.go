@@ -0,0 +1,81 @@
+package fscache
+
+const (
+	// readBufferSize is the number of reads whose bookkeeping waits for the write lock. Like the buffers of
+	// ristretto, the reads past it are dropped, which keeps the eviction order approximate but the reads concurrent.
+	readBufferSize = 64
+)
+
+// bufferedRead is a read whose bookkeeping is deferred until the write lock is held
+type bufferedRead struct {
+	key string
+	// expired is set when the read found the data expired, so it is removed
+	expired bool
+}
+
+// read counts a hit of the data of key stored at index, and buffers its recency update.
+// The caller must hold md.state().mu, for reading at least.
+func (md *Memdis) read(index int, key string) {
+	md.state().hits.Add(1)
+	// the datas of the mapped snapshot are read only
+	if index != mappedIndex {
+		md.bufferRead(bufferedRead{key: key})
+	}
+}
+
+// readMissed counts a miss of key, and buffers the removal of its data if it expired.
+// The caller must hold md.state().mu, for reading at least.
+func (md *Memdis) readMissed(key string) {
+	md.state().misses.Add(1)
+	if _, ok := md.storage[key]; ok {
+		md.bufferRead(bufferedRead{key: key, expired: true})
+	}
+}
+
+// bufferRead queues read, unless the buffer is full
+func (md *Memdis) bufferRead(read bufferedRead) {
+	select {
+	case md.state().reads <- read:
+	default:
+	}
+}
+
+// flushReadsIfFull applies the buffered reads once the buffer is half full, if the write lock is free.
+// The caller must not hold md.state().mu.
+func (md *Memdis) flushReadsIfFull() {
+	if len(md.state().reads) < readBufferSize/2 || !md.state().mu.TryLock() {
+		return
+	}
+	defer md.state().mu.Unlock()
+
+	md.applyReads()
+}
+
+// flushReads applies the buffered reads, if any. The caller must not hold md.state().mu.
+func (md *Memdis) flushReads() {
+	if len(md.state().reads) == 0 {
+		return
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	md.applyReads()
+}
+
+// applyReads updates the recency of the datas read and removes the ones found expired.
+// The caller must hold md.state().mu.
+func (md *Memdis) applyReads() {
+	for {
+		select {
+		case read := <-md.state().reads:
+			if read.expired {
+				md.dropExpired(read.key)
+			} else if _, ok := md.storage[read.key]; ok {
+				md.touch(read.key)
+			}
+		default:
+			return
+		}
+	}
+}
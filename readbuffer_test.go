@@ -0,0 +1,39 @@
+package fscache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadsRunConcurrently(t *testing.T) {
+	ch := Cache{}
+	WithMaxEntries(2)(&ch)
+	assert.NoError(t, ch.Memdis().Set("a", "a"))
+	assert.NoError(t, ch.Memdis().Set("b", "b"))
+
+	// the reads don't wait for the other readers
+	ch.MemdisInstance.state().mu.RLock()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := ch.Memdis().Get("a")
+		assert.NoError(t, err)
+		assert.Len(t, ch.Memdis().GetMany([]string{"a", "b"}), 2)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("the reads are serialized")
+	}
+	ch.MemdisInstance.state().mu.RUnlock()
+
+	// their recency is applied before the next write
+	assert.NoError(t, ch.Memdis().Set("c", "c"))
+	assert.ElementsMatch(t, []string{"a", "c"}, ch.Memdis().Keys())
+
+	entry, err := ch.Memdis().GetEntry("a")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, entry.Hits)
+}
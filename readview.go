@@ -0,0 +1,129 @@
+package fscache
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// ReadView object is an immutable point-in-time copy of the Memdis storage.
+	// Readers never take the Memdis lock, so they don't block writers.
+	ReadView struct {
+		memdis   *Memdis
+		snapshot atomic.Pointer[readViewSnapshot]
+	}
+
+	// readViewSnapshot holds the copied datas of a ReadView
+	readViewSnapshot struct {
+		storage map[string]MemdisData
+		entries []readViewEntry
+		takenAt time.Time
+	}
+
+	// readViewEntry is a data of a ReadView along with its original key
+	readViewEntry struct {
+		key  string
+		data MemdisData
+	}
+)
+
+// ReadView returns an immutable point-in-time copy of the Memdis storage.
+// Use Refresh() to take a new copy when needed.
+func (c *Cache) ReadView() *ReadView {
+	rv := &ReadView{
+		memdis: &c.MemdisInstance,
+	}
+	rv.Refresh()
+
+	return rv
+}
+
+// Refresh() takes a new point-in-time copy of the Memdis storage, leaving out the datas already expired
+func (rv *ReadView) Refresh() {
+	rv.memdis.state().mu.RLock()
+	snapshot := &readViewSnapshot{
+		storage: make(map[string]MemdisData),
+		takenAt: time.Now(),
+	}
+	for key, value := range rv.memdis.storage {
+		if value.expired(snapshot.takenAt) {
+			continue
+		}
+		snapshot.entries = append(snapshot.entries, readViewEntry{key: rv.memdis.originalKey(key), data: value})
+		snapshot.storage[key] = value
+	}
+	if rv.memdis.mapped != nil {
+		rv.memdis.mapped.each(snapshot.takenAt, func(key string, value MemdisData) {
+			// the datas set since the snapshot was mapped hide it, even once expired
+			if _, ok := rv.memdis.storage[key]; ok {
+				return
+			}
+			snapshot.entries = append(snapshot.entries, readViewEntry{key: rv.memdis.originalKey(key), data: value})
+			snapshot.storage[key] = value
+		})
+	}
+	rv.memdis.state().mu.RUnlock()
+
+	rv.snapshot.Store(snapshot)
+}
+
+// TakenAt() returns the time the current copy was taken
+func (rv *ReadView) TakenAt() time.Time {
+	return rv.snapshot.Load().takenAt
+}
+
+// Get() retrieves a data from the copy, unless it expired since the copy was taken
+func (rv *ReadView) Get(key string) (_ interface{}, err error) {
+	defer recoverPanic(&err)
+
+	key, err = rv.memdis.canonicalKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if val, ok := rv.snapshot.Load().storage[key]; ok && !val.expired(time.Now()) {
+		return val.Value, nil
+	}
+
+	return nil, errKeyNotFound
+}
+
+// Keys() returns all the keys in the copy, leaving out the datas expired since the copy was taken like Get()
+func (rv *ReadView) Keys() []string {
+	var keys []string
+	rv.each(func(entry readViewEntry) {
+		keys = append(keys, entry.key)
+	})
+
+	return keys
+}
+
+// Values() returns all the values in the copy, leaving out the datas expired since the copy was taken like Get()
+func (rv *ReadView) Values() []interface{} {
+	var values []interface{}
+	rv.each(func(entry readViewEntry) {
+		values = append(values, entry.data.Value)
+	})
+
+	return values
+}
+
+// Size() retrieves the total keys in the copy, leaving out the datas expired since the copy was taken like Get()
+func (rv *ReadView) Size() int {
+	var size int
+	rv.each(func(readViewEntry) {
+		size++
+	})
+
+	return size
+}
+
+// each calls fn with the entries of the copy which did not expire since it was taken
+func (rv *ReadView) each(fn func(entry readViewEntry)) {
+	now := time.Now()
+	for _, entry := range rv.snapshot.Load().entries {
+		if !entry.data.expired(now) {
+			fn(entry)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package fscache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadView(t *testing.T) {
+	ch := Cache{}
+
+	if err := ch.Memdis().Set("rv1", "value1"); err != nil {
+		assert.Error(t, err)
+	}
+
+	rv := ch.ReadView()
+	value, err := rv.Get("rv1")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "value1", value)
+
+	// writes after the copy was taken are not visible until Refresh()
+	if err := ch.Memdis().Set("rv2", "value2"); err != nil {
+		assert.Error(t, err)
+	}
+	_, err = rv.Get("rv2")
+	assert.Equal(t, errKeyNotFound, err)
+
+	rv.Refresh()
+	value, err = rv.Get("rv2")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "value2", value)
+	assert.EqualValues(t, 2, rv.Size())
+
+	// the datas expiring after the copy was taken are not served anymore
+	assert.NoError(t, ch.Memdis().Set("rv3", "value3", 20*time.Millisecond))
+	rv.Refresh()
+	_, err = rv.Get("rv3")
+	assert.NoError(t, err)
+	time.Sleep(30 * time.Millisecond)
+	_, err = rv.Get("rv3")
+	assert.Equal(t, errKeyNotFound, err)
+	assert.ElementsMatch(t, []string{"rv1", "rv2"}, rv.Keys())
+	assert.ElementsMatch(t, []interface{}{"value1", "value2"}, rv.Values())
+	assert.EqualValues(t, 2, rv.Size())
+
+	// and the datas already expired are not copied
+	rv.Refresh()
+	assert.ElementsMatch(t, []string{"rv1", "rv2"}, rv.Keys())
+}
@@ -0,0 +1,257 @@
+package fscache
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"sync"
+	"time"
+)
+
+const (
+	// TraceGet is the operation of the reads: Get(), GetWithExpiration(), GetOrLoad() and GetOrLoadContext()
+	TraceGet = "get"
+	// TraceSet is the operation of the writes: Set(), OverWrite() and OverWriteOrSet()
+	TraceSet = "set"
+	// TraceDel is the operation of Del()
+	TraceDel = "del"
+)
+
+var (
+	// errInvalidTrace the trace is not made of json operations
+	errInvalidTrace = errors.New("invalid trace")
+)
+
+type (
+	// TraceOp object is an operation recorded with WithRecorder
+	TraceOp struct {
+		// Op is the operation, TraceGet, TraceSet or TraceDel
+		Op string `json:"op"`
+		// Key is the key of the operation
+		Key string `json:"key"`
+		// Hit is whether a read found its data in the cache
+		Hit bool `json:"hit,omitempty"`
+		// TTL is the duration a write set its data with
+		TTL time.Duration `json:"ttl,omitempty"`
+		// Size is the estimated size in bytes of the value of a write
+		Size int `json:"size,omitempty"`
+		// Err is the error the operation returned, if any
+		Err string `json:"err,omitempty"`
+		// Time is the time the operation started at
+		Time time.Time `json:"time"`
+		// Duration is the time the operation took
+		Duration time.Duration `json:"duration"`
+	}
+
+	// ReplayReport object holds the outcome of the operations of a trace replayed with Replay()
+	ReplayReport struct {
+		// Ops is the number of operations replayed
+		Ops int
+		// Hits and Misses are the number of reads which found, or did not find, their data
+		Hits   int
+		Misses int
+		// RecordedHits and RecordedMisses are the number of reads which found, or did not find, their data when
+		// the trace was recorded
+		RecordedHits   int
+		RecordedMisses int
+		// Duration is the time the replay took
+		Duration time.Duration
+	}
+
+	// recorder writes the operations recorded with WithRecorder
+	recorder struct {
+		mu  sync.Mutex
+		enc *json.Encoder
+	}
+)
+
+// WithRecorder records the key value operations of Memdis (their keys, hit or miss and timings) into w as json
+// operations following each other like NDJSON, so the trace can be replayed with Replay() against other configs.
+// The values are not recorded.
+func WithRecorder(w io.Writer) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.recorder = &recorder{enc: json.NewEncoder(w)}
+	}
+}
+
+// record writes op. It does nothing when r is nil.
+func (r *recorder) record(op TraceOp) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// a failing writer must not fail the operations
+	_ = r.enc.Encode(op)
+}
+
+// traceOf returns the operation op on key started at start which returned err
+func traceOf(op, key string, ttl time.Duration, start time.Time, err error) TraceOp {
+	trace := TraceOp{
+		Op:       op,
+		Key:      key,
+		TTL:      ttl,
+		Time:     start,
+		Duration: time.Since(start),
+	}
+	if err != nil {
+		trace.Err = err.Error()
+	}
+
+	return trace
+}
+
+// HitRatio returns the fraction of the replayed reads which found their data
+func (r ReplayReport) HitRatio() float64 {
+	if r.Hits+r.Misses == 0 {
+		return 0
+	}
+
+	return float64(r.Hits) / float64(r.Hits+r.Misses)
+}
+
+// valueSize estimates the size in bytes of value, the length of its json encoding unless it is a string or bytes
+func valueSize(value interface{}) int {
+	switch v := value.(type) {
+	case nil:
+		return 0
+	case string:
+		return len(v)
+	case []byte:
+		return len(v)
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		return 0
+	}
+
+	return len(data)
+}
+
+// replayer re-executes the operations of a trace against a cache. The datas expire in the time of the trace
+// rather than the time of the replay, which runs much faster than the recorded traffic did.
+type replayer struct {
+	cache *Cache
+	// ttl replaces the ttls of the trace when it is not 0
+	ttl time.Duration
+
+	// now is the time of the last operation replayed
+	now time.Time
+	// deadlines are the times the datas expire at, in the time of the trace
+	deadlines map[string]time.Time
+	// sizes are the sizes of the values last set
+	sizes map[string]int
+	// placeholders is the buffer the placeholder values are sliced from
+	placeholders []byte
+	report       ReplayReport
+}
+
+// newReplayer returns a replayer of the operations of a trace against c
+func newReplayer(c *Cache, ttl time.Duration) *replayer {
+	return &replayer{
+		cache:     c,
+		ttl:       ttl,
+		deadlines: make(map[string]time.Time),
+		sizes:     make(map[string]int),
+	}
+}
+
+// replay re-executes op
+func (r *replayer) replay(op TraceOp) error {
+	md := &r.cache.MemdisInstance
+
+	r.now = op.Time
+	r.report.Ops++
+	switch op.Op {
+	case TraceGet:
+		if op.Hit {
+			r.report.RecordedHits++
+		} else {
+			r.report.RecordedMisses++
+		}
+
+		if deadline, ok := r.deadlines[op.Key]; ok && !op.Time.Before(deadline) {
+			md.Del(op.Key)
+			delete(r.deadlines, op.Key)
+		}
+
+		if _, err := md.Get(op.Key); err == nil {
+			r.report.Hits++
+		} else if errors.Is(err, errKeyNotFound) {
+			r.report.Misses++
+			r.set(op.Key, 0, op.Time)
+		}
+	case TraceSet:
+		if op.Err == "" {
+			r.sizes[op.Key] = op.Size
+			r.set(op.Key, op.TTL, op.Time)
+		}
+	case TraceDel:
+		md.Del(op.Key)
+		delete(r.deadlines, op.Key)
+	default:
+		return errInvalidTrace
+	}
+
+	return nil
+}
+
+// set sets the data of key at now, expiring after ttl unless the ttl of the replayer replaces it
+func (r *replayer) set(key string, ttl time.Duration, now time.Time) {
+	if r.ttl != 0 {
+		ttl = r.ttl
+	}
+
+	r.cache.MemdisInstance.OverWriteOrSet(key, r.placeholder(r.sizes[key]))
+	if ttl > 0 {
+		r.deadlines[key] = now.Add(ttl)
+	} else {
+		delete(r.deadlines, key)
+	}
+}
+
+// placeholder returns a value of size bytes standing for a recorded value, so budgets like WithMaxMemory apply
+// to the replayed datas. The placeholders share their bytes.
+func (r *replayer) placeholder(size int) []byte {
+	if size == 0 {
+		return nil
+	}
+	if size > len(r.placeholders) {
+		r.placeholders = make([]byte, size)
+	}
+
+	return r.placeholders[:size:size]
+}
+
+// Replay re-executes the operations of a trace recorded with WithRecorder against c, so eviction policies and
+// budgets can be evaluated against real workloads. Like a cache-aside application, the datas missed by the reads
+// are set right after. The replayed datas hold placeholder values of the sizes recorded, and expire in the time
+// of the trace.
+func Replay(r io.Reader, c *Cache) (_ ReplayReport, err error) {
+	defer recoverPanic(&err)
+
+	start := time.Now()
+	replayer := newReplayer(c, 0)
+
+	dec := json.NewDecoder(bufio.NewReader(r))
+	for {
+		var op TraceOp
+		if err := dec.Decode(&op); err == io.EOF {
+			break
+		} else if err != nil {
+			return replayer.report, errInvalidTrace
+		}
+
+		if err := replayer.replay(op); err != nil {
+			return replayer.report, err
+		}
+	}
+
+	replayer.report.Duration = time.Since(start)
+
+	return replayer.report, nil
+}
@@ -0,0 +1,50 @@
+package fscache
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordReplay(t *testing.T) {
+	var trace bytes.Buffer
+	ch := Cache{}
+	WithRecorder(&trace)(&ch)
+
+	assert.NoError(t, ch.Memdis().Set("a", "value", time.Minute))
+	assert.NoError(t, ch.Memdis().Set("b", "value"))
+	assert.NoError(t, ch.Memdis().Set("c", "value"))
+	for _, key := range []string{"a", "b", "c", "a", "missing"} {
+		ch.Memdis().Get(key)
+	}
+	assert.NoError(t, ch.Memdis().Del("b"))
+
+	var first TraceOp
+	assert.NoError(t, json.NewDecoder(bytes.NewReader(trace.Bytes())).Decode(&first))
+	assert.Equal(t, TraceSet, first.Op)
+	assert.Equal(t, "a", first.Key)
+	assert.Equal(t, time.Minute, first.TTL)
+
+	unbounded := Cache{}
+	report, err := Replay(bytes.NewReader(trace.Bytes()), &unbounded)
+	assert.NoError(t, err)
+	assert.Equal(t, 9, report.Ops)
+	assert.Equal(t, 4, report.RecordedHits)
+	assert.Equal(t, 1, report.RecordedMisses)
+	assert.Equal(t, 4, report.Hits)
+	assert.Equal(t, 1, report.Misses)
+	assert.Equal(t, 0.8, report.HitRatio())
+
+	// a smaller budget misses more
+	bounded := Cache{}
+	WithMaxCost(1)(&bounded)
+	report, err = Replay(bytes.NewReader(trace.Bytes()), &bounded)
+	assert.NoError(t, err)
+	assert.Less(t, report.Hits, 4)
+
+	_, err = Replay(bytes.NewReader([]byte(`{"op": "unknown"}`)), &unbounded)
+	assert.Equal(t, errInvalidTrace, err)
+}
@@ -0,0 +1,44 @@
+package fscache
+
+import (
+	"errors"
+	"fmt"
+	runtimedebug "runtime/debug"
+
+	"github.com/rs/zerolog"
+)
+
+var (
+	// ErrPanic is returned by the operations which recovered from an internal panic, instead of crashing the process
+	ErrPanic = errors.New("internal panic")
+)
+
+// recoverPanic recovers from a panic of the operation returning err, and sets err to ErrPanic along with
+// the panic value, and its stack trace in debug mode. It must be deferred by the operation.
+func recoverPanic(err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	if debug {
+		*err = fmt.Errorf("%w: %v\n%s", ErrPanic, r, runtimedebug.Stack())
+		return
+	}
+	*err = fmt.Errorf("%w: %v", ErrPanic, r)
+}
+
+// logPanic recovers from a panic of a background goroutine and logs it, along with its stack trace in debug mode.
+// It must be deferred by the goroutine.
+func logPanic(logger zerolog.Logger) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	if debug {
+		logger.Error().Msgf("%v: %v\n%s", ErrPanic, r, runtimedebug.Stack())
+		return
+	}
+	logger.Error().Msgf("%v: %v", ErrPanic, r)
+}
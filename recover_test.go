@@ -0,0 +1,50 @@
+package fscache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverPanic(t *testing.T) {
+	ch := Cache{}
+
+	_, err := ch.Memdis().GetOrLoad("panicking", func(key string) (interface{}, error) {
+		var values map[string]interface{}
+		values[key] = "value"
+		return values, nil
+	})
+	assert.ErrorIs(t, err, ErrPanic)
+
+	assert.NoError(t, ch.Memdis().Set("panicking", "value"))
+	err = ch.Memdis().ForEachParallel(func(entry Entry) error {
+		panic("bad cast")
+	}, 2)
+	assert.ErrorIs(t, err, ErrPanic)
+
+	// the cache stays usable once it recovered
+	value, err := ch.Memdis().Get("panicking")
+	assert.NoError(t, err)
+	assert.Equal(t, "value", value)
+}
+
+func Test_RecoverPanic(t *testing.T) {
+	ch := Cache{}
+
+	err := ch.Memgodb().Migrate("panicking", 1, func(doc map[string]interface{}) map[string]interface{} {
+		panic("bad cast")
+	})
+	assert.NoError(t, err)
+
+	_, err = ch.Memgodb().Collection("panicking").Insert(map[string]interface{}{"name": "john"}).One()
+	assert.NoError(t, err)
+
+	err = ch.Memgodb().Migrate("panicking", 2, func(doc map[string]interface{}) map[string]interface{} {
+		panic("bad cast")
+	})
+	assert.ErrorIs(t, err, ErrPanic)
+
+	// the storage is still usable once it recovered
+	_, err = ch.Memgodb().Collection("panicking").Filter(map[string]interface{}{"name": "john"}).First()
+	assert.NoError(t, err)
+}
@@ -0,0 +1,77 @@
+package fscache
+
+import (
+	"path"
+	"strings"
+)
+
+// redacted replaces the values of the redacted keys and fields
+const redacted = "[REDACTED]"
+
+// WithRedaction redacts the keys and document fields matching one of patterns in the debug logs and in the records
+// returned by Redact(), so secrets cached in documents don't leak into logs or exports. Patterns use the syntax of
+// path.Match and are matched case insensitively against the field names, and against the whole Memdis keys as
+// well as each of their ":" separated parts, e.g. "*token*" or "password".
+func WithRedaction(patterns ...string) Option {
+	return func(c *Cache) {
+		lowered := make([]string, len(patterns))
+		for i, pattern := range patterns {
+			lowered[i] = strings.ToLower(pattern)
+		}
+
+		c.MemdisInstance.redactions = lowered
+		c.MemgodbInstance.redactions = lowered
+	}
+}
+
+// Redact returns a copy of record with the fields matching the WithRedaction patterns redacted,
+// including the ones of nested documents. Use it before exporting or logging records.
+func (n *Memgodb) Redact(record map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(record))
+	for field, value := range record {
+		switch {
+		case redacts(n.redactions, field):
+			copied[field] = redacted
+		case isDocument(value):
+			copied[field] = n.Redact(value.(map[string]interface{}))
+		default:
+			copied[field] = value
+		}
+	}
+
+	return copied
+}
+
+// loggedKey returns the original key as it can be logged
+func (md *Memdis) loggedKey(key string) string {
+	key = md.originalKey(key)
+	if redacts(md.redactions, key) {
+		return redacted
+	}
+
+	for _, part := range strings.Split(key, ":") {
+		if redacts(md.redactions, part) {
+			return redacted
+		}
+	}
+
+	return key
+}
+
+// redacts reports whether name matches one of patterns
+func redacts(patterns []string, name string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isDocument reports whether value is a nested document
+func isDocument(value interface{}) bool {
+	_, ok := value.(map[string]interface{})
+	return ok
+}
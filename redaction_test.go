@@ -0,0 +1,37 @@
+package fscache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoggedKey(t *testing.T) {
+	ch := Cache{}
+	WithRedaction("session", "*token*")(&ch)
+
+	assert.Equal(t, "user:1", ch.MemdisInstance.loggedKey("user:1"))
+	assert.Equal(t, redacted, ch.MemdisInstance.loggedKey("session:abc"))
+	assert.Equal(t, redacted, ch.MemdisInstance.loggedKey("user:1:refreshToken"))
+}
+
+func Test_Redact(t *testing.T) {
+	ch := Cache{}
+	WithRedaction("*token*", "password")(&ch)
+
+	record := map[string]interface{}{
+		"name":     "john",
+		"Password": "secret",
+		"auth": map[string]interface{}{
+			"accessToken": "abc",
+			"provider":    "github",
+		},
+	}
+
+	redactedRecord := ch.Memgodb().Redact(record)
+	assert.Equal(t, "john", redactedRecord["name"])
+	assert.Equal(t, redacted, redactedRecord["Password"])
+	assert.Equal(t, map[string]interface{}{"accessToken": redacted, "provider": "github"}, redactedRecord["auth"])
+	// the record itself is left untouched
+	assert.Equal(t, "secret", record["Password"])
+}
@@ -0,0 +1,263 @@
+package fscache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	// redisNoKey is the ttl the TTL command of Redis returns for the keys which don't exist
+	redisNoKey time.Duration = -2
+	// redisKeepTTL is the expiration keeping the time to live of the key, like redis.KeepTTL of go-redis
+	redisKeepTTL time.Duration = -1
+)
+
+var (
+	// ErrRedisNil is returned by the RedisAdapter reads of the keys which don't exist, like redis.Nil of go-redis
+	ErrRedisNil = errors.New("redis: nil")
+	// errNotInteger the value is not an integer
+	errNotInteger = errors.New("ERR value is not an integer or out of range")
+)
+
+// RedisAdapter object implements the most used commands of the go-redis client on top of Memdis, so the tests of
+// code depending on Redis can run in-process. Its methods return what the Result() of the go-redis commands
+// return, e.g. Get(ctx, key) returns the result of client.Get(ctx, key).Result().
+type RedisAdapter struct {
+	memdis *Memdis
+}
+
+// Redis returns a RedisAdapter backed by the Memdis storage
+func (c *Cache) Redis() *RedisAdapter {
+	return &RedisAdapter{
+		memdis: &c.MemdisInstance,
+	}
+}
+
+// Get returns the value of key as a string, or ErrRedisNil if the key doesn't exist
+func (r *RedisAdapter) Get(ctx context.Context, key string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	value, err := r.memdis.Get(key)
+	if errors.Is(err, errKeyNotFound) {
+		return "", ErrRedisNil
+	} else if err != nil {
+		return "", err
+	}
+
+	return redisString(value), nil
+}
+
+// Set sets the value of key, expiring after expiration unless it is 0. An expiration of -1, redis.KeepTTL of go-redis,
+// keeps the time to live of the key.
+func (r *RedisAdapter) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) (err error) {
+	defer recoverPanic(&err)
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if expiration == redisKeepTTL {
+		return r.memdis.overWriteOrSet(key, nil, true, func(MemdisData, bool) (interface{}, error) {
+			return redisString(value), nil
+		})
+	}
+
+	return r.memdis.OverWriteOrSet(key, redisString(value), redisTTL(expiration))
+}
+
+// SetNX sets the value of key only if it doesn't exist, and reports whether it was set
+func (r *RedisAdapter) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	return r.memdis.SetNX(key, redisString(value), redisTTL(expiration))
+}
+
+// MGet returns the values of keys, nil for the keys which don't exist
+func (r *RedisAdapter) MGet(ctx context.Context, keys ...string) ([]interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	found, _ := r.memdis.GetManyStrict(keys)
+
+	values := make([]interface{}, len(keys))
+	for i, key := range keys {
+		if value, ok := found[key]; ok {
+			values[i] = redisString(value)
+		}
+	}
+
+	return values, nil
+}
+
+// Del deletes keys, and returns how many existed
+func (r *RedisAdapter) Del(ctx context.Context, keys ...string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, key := range keys {
+		if err := r.memdis.Del(key); err == nil {
+			deleted++
+		} else if !errors.Is(err, errKeyNotFound) {
+			return deleted, err
+		}
+	}
+
+	return deleted, nil
+}
+
+// Exists returns how many of keys exist, counting the keys given more than once as many times
+func (r *RedisAdapter) Exists(ctx context.Context, keys ...string) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	var count int64
+	for _, key := range keys {
+		if _, err := r.memdis.TTL(key); err == nil {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+// Expire sets the time to live of key, and reports whether the key exists.
+// The key is deleted if expiration is not positive.
+func (r *RedisAdapter) Expire(ctx context.Context, key string, expiration time.Duration) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	var err error
+	if expiration <= 0 {
+		err = r.memdis.Del(key)
+	} else {
+		err = r.memdis.Expire(key, expiration)
+	}
+	if errors.Is(err, errKeyNotFound) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
+// Persist removes the time to live of key, and reports whether it had one
+func (r *RedisAdapter) Persist(ctx context.Context, key string) (bool, error) {
+	ttl, err := r.TTL(ctx, key)
+	if err != nil || ttl < 0 {
+		return false, err
+	}
+
+	return true, r.memdis.Persist(key)
+}
+
+// TTL returns the remaining lifetime of key, -1 if it never expires and -2 if it doesn't exist
+func (r *RedisAdapter) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	ttl, err := r.memdis.TTL(key)
+	if errors.Is(err, errKeyNotFound) {
+		return redisNoKey, nil
+	}
+
+	return ttl, err
+}
+
+// Incr increments the integer value of key by one, and returns the new value
+func (r *RedisAdapter) Incr(ctx context.Context, key string) (int64, error) {
+	return r.IncrBy(ctx, key, 1)
+}
+
+// Decr decrements the integer value of key by one, and returns the new value
+func (r *RedisAdapter) Decr(ctx context.Context, key string) (int64, error) {
+	return r.IncrBy(ctx, key, -1)
+}
+
+// IncrBy increments the integer value of key by value, and returns the new value.
+// A key which doesn't exist is set to value, and the time to live of the key is kept.
+func (r *RedisAdapter) IncrBy(ctx context.Context, key string, value int64) (_ int64, err error) {
+	defer recoverPanic(&err)
+
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+
+	var result int64
+	err = r.memdis.overWriteOrSet(key, nil, true, func(prev MemdisData, found bool) (interface{}, error) {
+		result = value
+		if found {
+			current, err := strconv.ParseInt(redisString(prev.Value), 10, 64)
+			if err != nil {
+				return nil, errNotInteger
+			}
+			result += current
+		}
+
+		return strconv.FormatInt(result, 10), nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
+// Keys returns the keys matching the glob-style pattern, see Memdis.KeysMatching()
+func (r *RedisAdapter) Keys(ctx context.Context, pattern string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return r.memdis.KeysMatching(pattern)
+}
+
+// FlushAll deletes all the keys
+func (r *RedisAdapter) FlushAll(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return r.memdis.Clear()
+}
+
+// Ping checks the adapter is usable, it always is
+func (r *RedisAdapter) Ping(ctx context.Context) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return "PONG", nil
+}
+
+// redisString formats value the way Redis stores it, as a string
+func redisString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	}
+
+	return fmt.Sprint(value)
+}
+
+// redisTTL returns the Memdis ttl of a Redis expiration, 0 meaning no expiration. Set() handles redisKeepTTL itself.
+func redisTTL(expiration time.Duration) time.Duration {
+	if expiration <= 0 {
+		return NoExpiration
+	}
+
+	return expiration
+}
@@ -0,0 +1,100 @@
+package fscache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedisAdapter(t *testing.T) {
+	ch := Cache{}
+	rdb := ch.Redis()
+	ctx := context.Background()
+
+	_, err := rdb.Get(ctx, "missing")
+	assert.Equal(t, ErrRedisNil, err)
+
+	assert.NoError(t, rdb.Set(ctx, "name", "john", 0))
+	assert.NoError(t, rdb.Set(ctx, "name", "jane", time.Minute))
+	name, err := rdb.Get(ctx, "name")
+	assert.NoError(t, err)
+	assert.Equal(t, "jane", name)
+
+	set, err := rdb.SetNX(ctx, "name", "joe", 0)
+	assert.NoError(t, err)
+	assert.False(t, set)
+
+	ttl, err := rdb.TTL(ctx, "name")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+	ttl, err = rdb.TTL(ctx, "missing")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(-2), ttl)
+
+	count, err := rdb.Incr(ctx, "visits")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, count)
+	count, err = rdb.IncrBy(ctx, "visits", 10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 11, count)
+	_, err = rdb.Incr(ctx, "name")
+	assert.Equal(t, errNotInteger, err)
+	name, err = rdb.Get(ctx, "name")
+	assert.NoError(t, err)
+	assert.Equal(t, "jane", name)
+
+	// KeepTTL keeps the time to live of the key, and so does IncrBy
+	assert.NoError(t, rdb.Set(ctx, "name", "jane", redisKeepTTL))
+	ttl, err = rdb.TTL(ctx, "name")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+	assert.NoError(t, rdb.Set(ctx, "counter", "1", time.Minute))
+	_, err = rdb.Incr(ctx, "counter")
+	assert.NoError(t, err)
+	ttl, err = rdb.TTL(ctx, "counter")
+	assert.NoError(t, err)
+	assert.InDelta(t, time.Minute, ttl, float64(time.Second))
+	_, err = rdb.Del(ctx, "counter")
+	assert.NoError(t, err)
+
+	values, err := rdb.MGet(ctx, "name", "missing", "visits")
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"jane", nil, "11"}, values)
+
+	keys, err := rdb.Keys(ctx, "vis*")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"visits"}, keys)
+
+	exists, err := rdb.Exists(ctx, "name", "missing", "visits")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, exists)
+
+	ok, err := rdb.Expire(ctx, "visits", 0)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	deleted, err := rdb.Del(ctx, "name", "visits")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, deleted)
+
+	assert.NoError(t, rdb.FlushAll(ctx))
+	pong, err := rdb.Ping(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, "PONG", pong)
+
+	// IncrBy goes through the room, chaos and recorder of the other writes
+	full := Cache{}
+	WithMaxCost(1)(&full)
+	WithFullPolicy(FullReject)(&full)
+	_, err = full.Redis().Incr(ctx, "first")
+	assert.NoError(t, err)
+	_, err = full.Redis().Incr(ctx, "second")
+	assert.Equal(t, ErrStoreFull, err)
+
+	flaky := Cache{}
+	WithChaos(Chaos{ErrorRate: 1})(&flaky)
+	_, err = flaky.Redis().Incr(ctx, "visits")
+	assert.ErrorIs(t, err, ErrInjected)
+}
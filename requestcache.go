@@ -0,0 +1,23 @@
+package fscache
+
+import (
+	"context"
+	"os"
+
+	"github.com/rs/zerolog"
+)
+
+// NewRequestCache returns a Memdis storage scoped to ctx, e.g. the context of an http request, to memoize
+// per-user datas without leaking them across requests. All its datas are discarded once ctx is done.
+// It runs no background job, so expired datas are only swept once ctx is done.
+func NewRequestCache(ctx context.Context) *Memdis {
+	md := &Memdis{
+		logger: zerolog.New(os.Stderr).With().Timestamp().Logger(),
+	}
+
+	context.AfterFunc(ctx, func() {
+		md.Clear()
+	})
+
+	return md
+}
@@ -0,0 +1,25 @@
+package fscache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRequestCache(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	md := NewRequestCache(ctx)
+	assert.NoError(t, md.Set("user", "john"))
+	value, err := md.Get("user")
+	assert.NoError(t, err)
+	assert.Equal(t, "john", value)
+
+	// the datas are discarded once the request ends
+	cancel()
+	assert.Eventually(t, func() bool {
+		return md.Size() == 0
+	}, time.Second, time.Millisecond)
+}
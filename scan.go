@@ -0,0 +1,114 @@
+package fscache
+
+import (
+	"container/heap"
+	"time"
+)
+
+const (
+	// defaultScanCount is the number of datas a Scan() examines when its count is not positive
+	defaultScanCount = 10
+	// scanMappedCursor flags the cursors of Scan() pointing in the mapped snapshot, the lower bits being an offset
+	scanMappedCursor uint64 = 1 << 63
+)
+
+// scanPosition is the position of a data in the iteration of Scan()
+type scanPosition struct {
+	key      string
+	position uint64
+}
+
+// scanHeap keeps the scanPositions with the lowest positions, the highest one on top
+type scanHeap []scanPosition
+
+func (h scanHeap) Len() int                   { return len(h) }
+func (h scanHeap) Less(i, j int) bool         { return h[i].position > h[j].position }
+func (h scanHeap) Swap(i, j int)              { h[i], h[j] = h[j], h[i] }
+func (h *scanHeap) Push(position interface{}) { *h = append(*h, position.(scanPosition)) }
+func (h *scanHeap) Pop() interface{} {
+	old := *h
+	position := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return position
+}
+
+// Scan() pages through the keys in the storage like the SCAN command of Redis, so large caches can be enumerated
+// incrementally instead of materializing every key at once like Keys() does. Start with cursor 0 and call Scan()
+// with the returned cursor until it is 0. Each call examines up to count datas, 10 when count is not positive,
+// and returns the ones matching the glob-style pattern of KeysMatching(), all of them when pattern is empty.
+// A key present during the whole iteration is returned at least once, a key set or deleted meanwhile may or may
+// not be. Each call only holds the read lock of Memdis for one pass over the storage.
+func (md *Memdis) Scan(cursor uint64, count int, pattern string) (keys []string, next uint64, err error) {
+	defer recoverPanic(&err)
+
+	if pattern != "" {
+		if _, err := globMatch(pattern, ""); err != nil {
+			return nil, 0, err
+		}
+	}
+	if count <= 0 {
+		count = defaultScanCount
+	}
+
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
+	now := time.Now()
+	page := make(scanHeap, 0, count)
+	keep := func(key string, position uint64) {
+		if len(page) < count {
+			heap.Push(&page, scanPosition{key: key, position: position})
+		} else if position < page[0].position {
+			page[0] = scanPosition{key: key, position: position}
+			heap.Fix(&page, 0)
+		}
+	}
+
+	// the datas in memory are iterated in the order they were first set, then the ones of the mapped snapshot in
+	// the order of the file
+	if cursor&scanMappedCursor == 0 {
+		for key, data := range md.storage {
+			if data.sequence > cursor && !data.expired(now) {
+				keep(key, data.sequence)
+			}
+		}
+	} else if md.mapped != nil {
+		offset := cursor &^ scanMappedCursor
+		for key, position := range md.mapped.index {
+			if uint64(position.offset) < offset || md.mapped.deleted[key] || position.expired(now) {
+				continue
+			}
+			keep(key, scanMappedCursor|uint64(position.offset))
+		}
+	}
+
+	positions := make([]scanPosition, len(page))
+	for i := len(page) - 1; i >= 0; i-- {
+		positions[i] = heap.Pop(&page).(scanPosition)
+	}
+
+	keys = []string{}
+	for _, position := range positions {
+		original := md.originalKey(position.key)
+		if pattern != "" {
+			if matched, _ := globMatch(pattern, original); !matched {
+				continue
+			}
+		}
+		keys = append(keys, original)
+	}
+
+	switch {
+	case len(positions) == count:
+		next = positions[len(positions)-1].position
+		if next&scanMappedCursor != 0 {
+			next++
+		}
+	case cursor&scanMappedCursor == 0 && md.mapped != nil:
+		next = scanMappedCursor
+	default:
+		next = 0
+	}
+
+	return keys, next, nil
+}
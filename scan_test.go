@@ -0,0 +1,61 @@
+package fscache
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScan(t *testing.T) {
+	withTempStorage(t)
+
+	saved := Cache{}
+	assert.NoError(t, saved.Memdis().Set("cold:1", 1))
+	assert.NoError(t, saved.Memdis().Set("cold:2", 2))
+	assert.NoError(t, saved.Memdis().SaveSnapshot())
+
+	ch := Cache{}
+	var all []string
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("user:%d", i)
+		assert.NoError(t, ch.Memdis().Set(key, i))
+		all = append(all, key)
+	}
+	assert.NoError(t, ch.Memdis().MapSnapshot(memdisStorageFile))
+	defer ch.Memdis().UnmapSnapshot()
+	all = append(all, "cold:1", "cold:2")
+
+	scanAll := func(count int, pattern string, during func(page int)) []string {
+		var keys []string
+		var cursor uint64
+		for page := 0; ; page++ {
+			found, next, err := ch.Memdis().Scan(cursor, count, pattern)
+			assert.NoError(t, err)
+			assert.LessOrEqual(t, len(found), max(count, defaultScanCount))
+			keys = append(keys, found...)
+			if next == 0 {
+				return keys
+			}
+			cursor = next
+			if during != nil {
+				during(page)
+			}
+		}
+	}
+
+	assert.ElementsMatch(t, all, scanAll(7, "", nil))
+	assert.ElementsMatch(t, all, scanAll(0, "", nil))
+	assert.ElementsMatch(t, []string{"user:1", "user:10", "user:11", "user:12", "user:13", "user:14", "user:15",
+		"user:16", "user:17", "user:18", "user:19"}, scanAll(5, "user:1*", nil))
+
+	// the keys present during the whole iteration are returned despite the writes
+	keys := scanAll(7, "", func(page int) {
+		assert.NoError(t, ch.Memdis().Set(fmt.Sprintf("new:%d", page), page))
+		assert.NoError(t, ch.Memdis().OverWrite(fmt.Sprintf("user:%d", page), -page))
+	})
+	assert.Subset(t, keys, all)
+
+	_, _, err := ch.Memdis().Scan(0, 10, "user:[")
+	assert.ErrorIs(t, err, errBadPattern)
+}
@@ -0,0 +1,140 @@
+package fscache
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+)
+
+var (
+	// errInvalidSeed invalid seed file
+	errInvalidSeed = errors.New("invalid seed file")
+)
+
+type (
+	// seedDocument is the content of a json seed file
+	seedDocument struct {
+		// Keys are set into Memdis
+		Keys map[string]interface{} `json:"keys"`
+		// Collections are inserted into Memgodb by collection name
+		Collections map[string][]map[string]interface{} `json:"collections"`
+	}
+
+	// seedLine is a line of a ndjson seed file, it either sets a key or inserts a record into a collection
+	seedLine struct {
+		Key        string      `json:"key"`
+		Collection string      `json:"collection"`
+		Value      interface{} `json:"value"`
+	}
+)
+
+// WithSeedFile loads the keys and collections of a json or ndjson (.ndjson or .jsonl) seed file before the cache is returned.
+// A json seed file holds an object such as {"keys": {"greeting": "hello"}, "collections": {"users": [{"name": "john"}]}}.
+// Each line of a ndjson seed file is either {"key": "greeting", "value": "hello"} or {"collection": "users", "value": {"name": "john"}}.
+// If the seed file cannot be loaded, the error is returned by Err() and the cache starts with the datas loaded before it.
+func WithSeedFile(name string) Option {
+	return func(c *Cache) {
+		fileByte, err := os.ReadFile(name)
+		if err == nil {
+			err = c.seed(name, fileByte)
+		}
+		c.seedFailed(name, err)
+	}
+}
+
+// WithSeedFS loads the seed files of fsys matching pattern before the cache is returned, see WithSeedFile for their format.
+// It works with an embed.FS, so fixtures can be bundled into the binary. If a seed file cannot be loaded, the error
+// is returned by Err() and the remaining seed files are still loaded.
+func WithSeedFS(fsys fs.FS, pattern string) Option {
+	return func(c *Cache) {
+		names, err := fs.Glob(fsys, pattern)
+		if err != nil {
+			c.seedFailed(pattern, err)
+			return
+		}
+
+		for _, name := range names {
+			fileByte, err := fs.ReadFile(fsys, name)
+			if err == nil {
+				err = c.seed(name, fileByte)
+			}
+			c.seedFailed(name, err)
+		}
+	}
+}
+
+// seedFailed records the error of a seed file that could not be loaded
+func (c *Cache) seedFailed(name string, err error) {
+	if err == nil {
+		return
+	}
+
+	c.fail(fmt.Errorf("error loading seed %s: %w", name, err))
+}
+
+// seed loads the content of the seed file name
+func (c *Cache) seed(name string, fileByte []byte) error {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".ndjson", ".jsonl":
+		return c.seedLines(fileByte)
+	}
+
+	var doc seedDocument
+	if err := json.Unmarshal(fileByte, &doc); err != nil {
+		return errInvalidSeed
+	}
+
+	for key, value := range doc.Keys {
+		if err := c.MemdisInstance.Set(key, value); err != nil {
+			return fmt.Errorf("key %s: %w", key, err)
+		}
+	}
+
+	for col, records := range doc.Collections {
+		for _, record := range records {
+			if _, err := c.MemgodbInstance.Collection(col).Insert(record).One(); err != nil {
+				return fmt.Errorf("collection %s: %w", col, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// seedLines loads the lines of a ndjson seed file
+func (c *Cache) seedLines(fileByte []byte) error {
+	scanner := bufio.NewScanner(bytes.NewReader(fileByte))
+	scanner.Buffer(nil, len(fileByte)+1)
+
+	for number := 1; scanner.Scan(); number++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		var line seedLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			return fmt.Errorf("line %d: %w", number, errInvalidSeed)
+		}
+
+		switch {
+		case line.Key != "":
+			if err := c.MemdisInstance.Set(line.Key, line.Value); err != nil {
+				return fmt.Errorf("line %d: %w", number, err)
+			}
+		case line.Collection != "":
+			if _, err := c.MemgodbInstance.Collection(line.Collection).Insert(line.Value).One(); err != nil {
+				return fmt.Errorf("line %d: %w", number, err)
+			}
+		default:
+			return fmt.Errorf("line %d: %w", number, errInvalidSeed)
+		}
+	}
+
+	return scanner.Err()
+}
@@ -0,0 +1,50 @@
+package fscache
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithSeedFile(t *testing.T) {
+	fs := New(WithSeedFile("./testJsonFiles/seeds/users.json"))
+
+	value, err := fs.Memdis().Get("greeting")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", value)
+
+	users, err := fs.Memgodb().Collection("seedusers").Filter(map[string]interface{}{"name": "jane"}).All()
+	assert.NoError(t, err)
+	assert.Len(t, users, 1)
+
+	assert.NoError(t, fs.(Observer).Err())
+
+	var invalid Operations
+	assert.NotPanics(t, func() {
+		invalid = New(WithSeedFile("./testJsonFiles/string.json"))
+	})
+	assert.ErrorIs(t, invalid.(Observer).Err(), errInvalidSeed)
+	assert.NoError(t, invalid.Memdis().Set("key", "value"))
+
+	missing := New(WithSeedFile("./testJsonFiles/seeds/missing.json"), WithSeedFile("./testJsonFiles/string.json"))
+	assert.ErrorIs(t, missing.(Observer).Err(), os.ErrNotExist)
+}
+
+func TestWithSeedFS(t *testing.T) {
+	fs := New(WithSeedFS(os.DirFS("./testJsonFiles/seeds"), "*.ndjson"))
+
+	value, err := fs.Memdis().Get("currency")
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", value)
+	assert.Equal(t, 1, fs.Memdis().Size())
+
+	orders, err := fs.Memgodb().Collection("seedorders").Filter(map[string]interface{}{"item": "book"}).All()
+	assert.NoError(t, err)
+	assert.Len(t, orders, 1)
+	assert.NoError(t, fs.(Observer).Err())
+
+	invalid := New(WithSeedFS(os.DirFS("./testJsonFiles/seeds"), "["))
+	assert.ErrorIs(t, invalid.(Observer).Err(), path.ErrBadPattern)
+}
@@ -0,0 +1,145 @@
+package fscache
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var (
+	// errDuplicateKey key is set more than once
+	errDuplicateKey = errors.New("duplicate key")
+)
+
+// DuplicatePolicy defines how SetMany() handles keys set more than once or already set
+type DuplicatePolicy int
+
+const (
+	// DuplicateKeepLast keeps the last value of a key, replacing the value already set if any
+	DuplicateKeepLast DuplicatePolicy = iota
+	// DuplicateKeepFirst keeps the first value of a key, the value already set if any
+	DuplicateKeepFirst
+	// DuplicateError rejects the whole SetMany() if a key is set more than once or already set,
+	// with a BulkError listing all these keys
+	DuplicateError
+)
+
+// SetManyReport object reports what SetMany() applied
+type SetManyReport struct {
+	// Added are the keys which were not set before
+	Added []string
+	// Replaced are the keys whose value was replaced
+	Replaced []string
+	// Skipped are the keys whose value was discarded in favour of another one
+	Skipped []string
+}
+
+// WithDuplicatePolicy sets how SetMany() handles keys set more than once or already set, DuplicateKeepLast by default
+func WithDuplicatePolicy(policy DuplicatePolicy) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.duplicatePolicy = policy
+	}
+}
+
+// SetManyWithReport() sets many data objects into memory like SetMany(), and reports what was applied
+func (md *Memdis) SetManyWithReport(data []map[string]MemdisData) (_ SetManyReport, err error) {
+	defer recoverPanic(&err)
+
+	var report SetManyReport
+
+	data, err = md.canonicalData(data)
+	if err != nil {
+		return report, err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	// find which object provides the value kept for each key
+	winners := make(map[string]int)
+	var duplicates []*ItemError
+	for i, cache := range data {
+		for key := range cache {
+			if _, ok := winners[key]; ok {
+				if md.duplicatePolicy == DuplicateError {
+					duplicates = append(duplicates, &ItemError{
+						Index: -1,
+						Key:   md.originalKey(key),
+						Err:   fmt.Errorf("%w: set more than once", errDuplicateKey),
+					})
+					continue
+				}
+				if md.duplicatePolicy == DuplicateKeepFirst {
+					continue
+				}
+			}
+			winners[key] = i
+		}
+	}
+
+	if md.duplicatePolicy == DuplicateError {
+		for key := range winners {
+			if _, _, ok := md.lookup(key); ok {
+				duplicates = append(duplicates, &ItemError{
+					Index: -1,
+					Key:   md.originalKey(key),
+					Err:   fmt.Errorf("%w: already set", errDuplicateKey),
+				})
+			}
+		}
+	}
+	if err := bulkError(duplicates); err != nil {
+		return report, err
+	}
+
+	var objects []map[string]MemdisData
+	for i, cache := range data {
+		fs := make(map[string]MemdisData)
+		for key, value := range cache {
+			if winners[key] != i {
+				report.Skipped = append(report.Skipped, md.originalKey(key))
+				continue
+			}
+
+			if index, _, ok := md.lookup(key); ok {
+				if md.duplicatePolicy == DuplicateKeepFirst {
+					report.Skipped = append(report.Skipped, md.originalKey(key))
+					continue
+				}
+
+				md.replace(index, key, md.withDeadline(value))
+				report.Replaced = append(report.Replaced, md.originalKey(key))
+				continue
+			}
+
+			fs[key] = value
+			report.Added = append(report.Added, md.originalKey(key))
+		}
+
+		if len(fs) > 0 {
+			objects = append(objects, fs)
+		}
+	}
+
+	var cost int64
+	for _, fs := range objects {
+		for _, value := range fs {
+			cost += costOf(value)
+		}
+	}
+
+	// the new datas are all or none added when Memdis is full, the replaced ones are kept
+	ok, err := md.room(cost)
+	if ok {
+		md.insertMany(objects)
+	} else {
+		report.Skipped = append(report.Skipped, report.Added...)
+		report.Added = nil
+	}
+
+	sort.Strings(report.Added)
+	sort.Strings(report.Replaced)
+	sort.Strings(report.Skipped)
+
+	return report, err
+}
@@ -0,0 +1,63 @@
+package fscache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetManyWithReport(t *testing.T) {
+	data := []map[string]MemdisData{
+		{"dup1": MemdisData{Value: "first"}, "dup2": MemdisData{Value: "only"}},
+		{"dup1": MemdisData{Value: "last"}},
+	}
+
+	testCases := []struct {
+		name     string
+		policy   DuplicatePolicy
+		expected interface{}
+		report   SetManyReport
+		err      error
+	}{
+		{
+			name:     "keep last",
+			policy:   DuplicateKeepLast,
+			expected: "last",
+			report:   SetManyReport{Added: []string{"dup1", "dup2"}, Replaced: []string{"existing"}, Skipped: []string{"dup1"}},
+		},
+		{
+			name:     "keep first",
+			policy:   DuplicateKeepFirst,
+			expected: "first",
+			report:   SetManyReport{Added: []string{"dup1", "dup2"}, Skipped: []string{"dup1", "existing"}},
+		},
+		{
+			name:   "error",
+			policy: DuplicateError,
+			err:    errDuplicateKey,
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			ch := Cache{}
+			WithDuplicatePolicy(testCase.policy)(&ch)
+			assert.NoError(t, ch.Memdis().Set("existing", "value"))
+
+			report, err := ch.Memdis().SetManyWithReport(append(data, map[string]MemdisData{
+				"existing": {Value: "new"},
+			}))
+			if testCase.err != nil {
+				assert.ErrorIs(t, err, testCase.err)
+				assert.EqualValues(t, []string{"existing"}, ch.Memdis().Keys())
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.report, report)
+			value, err := ch.Memdis().Get("dup1")
+			assert.NoError(t, err)
+			assert.EqualValues(t, testCase.expected, value)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package fscache
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// errInvalidSetOptions set options are not valid
+	errInvalidSetOptions = errors.New("NX and XX cannot be used together")
+)
+
+// SetOptions object holds the per data options of SetWithOptions()
+type SetOptions struct {
+	// TTL is the duration after which the data expires, NoExpiration by default
+	TTL time.Duration
+	// Tags groups datas so they can be deleted together with DelByTag()
+	Tags []string
+	// Priority protects the data from eviction, datas with the lowest priority are evicted first
+	Priority int
+	// NX only sets the data if the key does not exist yet
+	NX bool
+	// XX only sets the data if the key already exists
+	XX bool
+	// Cost is the weight of the data against the WithMaxCost budget
+	Cost int64
+}
+
+// SetWithOptions() sets a data into the in-memmory storage using opts.
+// Without NX or XX, the data is added if the key does not exist yet and replaced otherwise.
+func (md *Memdis) SetWithOptions(key string, value interface{}, opts SetOptions) (err error) {
+	defer recoverPanic(&err)
+
+	if err := md.chaos.inject(); err != nil {
+		return err
+	}
+
+	if opts.NX && opts.XX {
+		return errInvalidSetOptions
+	}
+
+	key, digestOf, err := md.digestedKey(key)
+	if err != nil {
+		return err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	index, _, ok := md.lookup(key)
+	if ok && opts.NX {
+		return errKeyExists
+	}
+	if !ok && opts.XX {
+		return errKeyNotFound
+	}
+
+	ttl := md.resolveTTL(opts.TTL)
+	data := MemdisData{
+		Value:    value,
+		Duration: expiresAt(ttl),
+		TTL:      ttl,
+		cost:     opts.Cost,
+		rank:     opts.Priority,
+		tags:     append([]string(nil), opts.Tags...),
+		digestOf: digestOf,
+	}
+
+	if ok {
+		md.replace(index, key, data)
+		return nil
+	}
+
+	if ok, err := md.room(costOf(data)); !ok {
+		return err
+	}
+	md.insert(key, data)
+
+	return nil
+}
+
+// DelByTag() deletes all the datas set with tag and returns how many were deleted. The expired datas set with tag
+// are removed as expired, and are not counted.
+func (md *Memdis) DelByTag(tag string) int {
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	var keys []string
+	for key, value := range md.storage {
+		if value.hasTag(tag) {
+			keys = append(keys, key)
+		}
+	}
+
+	deleted := 0
+	for _, key := range keys {
+		index, _, ok := md.lookup(key)
+		if !ok {
+			md.dropExpired(key)
+			continue
+		}
+
+		md.deleteKey(index, key)
+		deleted++
+	}
+
+	return deleted
+}
+
+// hasTag reports whether the data was set with tag
+func (d MemdisData) hasTag(tag string) bool {
+	for _, t := range d.tags {
+		if t == tag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,37 @@
+package fscache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetWithOptions(t *testing.T) {
+	ch := Cache{}
+	WithMaxCost(2)(&ch)
+
+	assert.NoError(t, ch.Memdis().SetWithOptions("opt1", "value1", SetOptions{Priority: 1, Tags: []string{"group"}}))
+	assert.Equal(t, errKeyExists, ch.Memdis().SetWithOptions("opt1", "value2", SetOptions{NX: true}))
+	assert.Equal(t, errKeyNotFound, ch.Memdis().SetWithOptions("opt2", "value2", SetOptions{XX: true}))
+	assert.Equal(t, errInvalidSetOptions, ch.Memdis().SetWithOptions("opt2", "value2", SetOptions{NX: true, XX: true}))
+
+	assert.NoError(t, ch.Memdis().SetWithOptions("opt1", "value2", SetOptions{XX: true, Priority: 1, Tags: []string{"group"}}))
+	value, err := ch.Memdis().Get("opt1")
+	assert.NoError(t, err)
+	assert.EqualValues(t, "value2", value)
+
+	// the data with the lowest priority is evicted first
+	assert.NoError(t, ch.Memdis().SetWithOptions("opt2", "value2", SetOptions{Tags: []string{"group"}}))
+	assert.NoError(t, ch.Memdis().SetWithOptions("opt3", "value3", SetOptions{Cost: 1}))
+	assert.ElementsMatch(t, []string{"opt1", "opt3"}, ch.Memdis().Keys())
+
+	assert.EqualValues(t, 1, ch.Memdis().DelByTag("group"))
+	assert.EqualValues(t, []string{"opt3"}, ch.Memdis().Keys())
+
+	// the tagged datas which already expired are not counted
+	assert.NoError(t, ch.Memdis().SetWithOptions("opt4", "value4", SetOptions{TTL: time.Millisecond, Tags: []string{"stale"}}))
+	time.Sleep(5 * time.Millisecond)
+	assert.EqualValues(t, 0, ch.Memdis().DelByTag("stale"))
+	assert.NotContains(t, ch.MemdisInstance.storage, "opt4")
+}
@@ -0,0 +1,69 @@
+package fscache
+
+import (
+	"os"
+	"os/signal"
+)
+
+// EnableSignalHandlers() makes the cache handle the signals operators expect from a stateful component:
+// SIGHUP writes the Memdis snapshot with SaveSnapshot() and persists Memgodb with Persist(), and SIGUSR1 logs stats.
+// It returns a function to stop handling the signals, which waits for the signal being handled if any. The signals
+// are not handled on platforms without them.
+func (c *Cache) EnableSignalHandlers() (stop func()) {
+	signals := make(chan os.Signal, 1)
+	var handled []os.Signal
+	for _, sig := range []os.Signal{persistSignal, statsSignal} {
+		if sig != nil {
+			handled = append(handled, sig)
+		}
+	}
+	if len(handled) == 0 {
+		return func() {}
+	}
+	signal.Notify(signals, handled...)
+
+	done, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case sig := <-signals:
+				c.handleSignal(sig)
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(signals)
+		close(done)
+		// the signal being handled is handled completely
+		<-stopped
+	}
+}
+
+// handleSignal runs the operation triggered by sig
+func (c *Cache) handleSignal(sig os.Signal) {
+	logger := c.MemdisInstance.logger
+
+	switch sig {
+	case persistSignal:
+		if err := c.MemdisInstance.SaveSnapshot(); err != nil {
+			logger.Info().Msgf("snapshot error: %v", err)
+		}
+		if err := c.MemgodbInstance.Persist(); err != nil {
+			logger.Info().Msgf("persist error: %v", err)
+		}
+		logger.Info().Msgf("%v: datas persisted", sig)
+	case statsSignal:
+		stats := c.Stats()
+		logger.Info().
+			Int("memdisDatas", stats.Memdis.Datas).
+			Int64("memdisCost", stats.Memdis.Cost).
+			Uint64("memdisHits", stats.Memdis.Hits).
+			Uint64("memdisMisses", stats.Memdis.Misses).
+			Int("memgodbRecords", stats.Memgodb.Records).
+			Msgf("%v: stats", sig)
+	}
+}
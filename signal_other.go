@@ -0,0 +1,12 @@
+//go:build !unix
+
+package fscache
+
+import "os"
+
+var (
+	// persistSignal is not handled on the platforms without SIGHUP
+	persistSignal os.Signal
+	// statsSignal is not handled on the platforms without SIGUSR1
+	statsSignal os.Signal
+)
@@ -0,0 +1,43 @@
+package fscache
+
+import (
+	"bytes"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignalHandlers(t *testing.T) {
+	if persistSignal == nil {
+		t.Skip("signals are not handled on this platform")
+	}
+	withTempStorage(t)
+
+	ch := Cache{}
+	assert.NoError(t, ch.Memdis().Set("signaled", "value"))
+	_, err := ch.Memgodb().Collection("signaled").Insert(map[string]interface{}{"name": "signaled"}).One()
+	assert.NoError(t, err)
+
+	stop := ch.EnableSignalHandlers()
+	defer stop()
+
+	process, err := os.FindProcess(os.Getpid())
+	assert.NoError(t, err)
+	assert.NoError(t, process.Signal(persistSignal))
+
+	// both storages are persisted
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(memgodbStorageFile)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	assert.FileExists(t, memdisStorageFile)
+
+	var logs bytes.Buffer
+	logged := Cache{MemdisInstance: Memdis{logger: zerolog.New(&logs)}}
+	assert.NoError(t, logged.Memdis().Set("signaled", "value"))
+	logged.handleSignal(statsSignal)
+	assert.Contains(t, logs.String(), `"memdisDatas":1`)
+}
@@ -0,0 +1,15 @@
+//go:build unix
+
+package fscache
+
+import (
+	"os"
+	"syscall"
+)
+
+var (
+	// persistSignal triggers the persistence of the datas
+	persistSignal os.Signal = syscall.SIGHUP
+	// statsSignal triggers the logging of the stats
+	statsSignal os.Signal = syscall.SIGUSR1
+)
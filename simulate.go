@@ -0,0 +1,133 @@
+package fscache
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"time"
+	"unsafe"
+)
+
+const (
+	// simulationSampleEvery is the number of operations between two samples of the entries and memory of a simulation
+	simulationSampleEvery = 256
+)
+
+// entryOverhead is the estimated size in bytes of a data besides its key and value
+var entryOverhead = int64(unsafe.Sizeof(MemdisData{}))
+
+type (
+	// Scenario object is a hypothetical config of the cache evaluated by Simulate()
+	Scenario struct {
+		// Name identifies the scenario in the results
+		Name string
+		// MaxEntries is the maximum number of datas like WithMaxEntries, the least recently used evicted first,
+		// unbounded when 0. Use Options for the other budgets, e.g. WithMaxMemory or WithMaxCost.
+		MaxEntries int64
+		// TTL replaces the ttls of the trace when it is not 0, a negative TTL meaning the datas never expire
+		TTL time.Duration
+		// Admission enables the TinyLFU admission filter of WithAdmission
+		Admission bool
+		// Options are further options of the cache, e.g. WithKeyTransform
+		Options []Option
+	}
+
+	// SimulationResult object holds the expected outcome of a scenario
+	SimulationResult struct {
+		Scenario Scenario
+		// Report is the outcome of the operations of the trace
+		Report ReplayReport
+		// PeakEntries is the highest number of datas sampled during the simulation
+		PeakEntries int
+		// PeakMemory is the highest estimated size in bytes of the keys and values sampled during the simulation
+		PeakMemory int64
+	}
+)
+
+// Simulate replays a trace recorded with WithRecorder against each scenario and reports its expected hit rate and
+// memory use, so MaxEntries, TTL and eviction policy combinations can be compared for capacity planning without
+// running the production traffic again. The replayed datas hold placeholder values of the sizes recorded, from
+// which the memory use is estimated.
+func Simulate(trace io.Reader, scenarios ...Scenario) (_ []SimulationResult, err error) {
+	defer recoverPanic(&err)
+
+	var ops []TraceOp
+	dec := json.NewDecoder(bufio.NewReader(trace))
+	for {
+		var op TraceOp
+		if err := dec.Decode(&op); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, errInvalidTrace
+		}
+		ops = append(ops, op)
+	}
+
+	results := make([]SimulationResult, 0, len(scenarios))
+	for _, scenario := range scenarios {
+		result, err := simulate(ops, scenario)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+// simulate replays ops against a cache configured by scenario
+func simulate(ops []TraceOp, scenario Scenario) (SimulationResult, error) {
+	c := &Cache{}
+	if scenario.MaxEntries > 0 {
+		WithMaxEntries(int(scenario.MaxEntries))(c)
+	}
+	if scenario.Admission {
+		WithAdmission()(c)
+	}
+	for _, opt := range scenario.Options {
+		opt(c)
+	}
+
+	start := time.Now()
+	replayer := newReplayer(c, scenario.TTL)
+	result := SimulationResult{Scenario: scenario}
+
+	for i, op := range ops {
+		if err := replayer.replay(op); err != nil {
+			return result, err
+		}
+
+		if i%simulationSampleEvery == 0 || i == len(ops)-1 {
+			entries, memory := replayer.usage()
+			result.PeakEntries = max(result.PeakEntries, entries)
+			result.PeakMemory = max(result.PeakMemory, memory)
+		}
+	}
+
+	replayer.report.Duration = time.Since(start)
+	result.Report = replayer.report
+
+	return result, nil
+}
+
+// usage returns the number of datas of the replayed cache which have not expired, and their estimated size in bytes
+func (r *replayer) usage() (int, int64) {
+	md := &r.cache.MemdisInstance
+
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
+	var entries int
+	var memory int64
+	for key := range md.storage {
+		original := md.originalKey(key)
+		if deadline, ok := r.deadlines[original]; ok && !r.now.Before(deadline) {
+			continue
+		}
+
+		entries++
+		memory += entryOverhead + int64(len(original)+r.sizes[original])
+	}
+
+	return entries, memory
+}
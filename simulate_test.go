@@ -0,0 +1,71 @@
+package fscache
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulate(t *testing.T) {
+	var trace bytes.Buffer
+	enc := json.NewEncoder(&trace)
+	now := time.Now()
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("key%d", i%10)
+		now = now.Add(time.Second)
+		assert.NoError(t, enc.Encode(TraceOp{Op: TraceSet, Key: key, Size: 100, Time: now}))
+		assert.NoError(t, enc.Encode(TraceOp{Op: TraceGet, Key: fmt.Sprintf("key%d", (i+5)%10), Hit: true, Time: now}))
+	}
+
+	results, err := Simulate(bytes.NewReader(trace.Bytes()),
+		Scenario{Name: "unbounded"},
+		Scenario{Name: "small", MaxEntries: 2},
+		Scenario{Name: "short ttl", TTL: 3 * time.Second},
+	)
+	assert.NoError(t, err)
+	assert.Len(t, results, 3)
+
+	unbounded, small, short := results[0], results[1], results[2]
+	assert.Equal(t, "unbounded", unbounded.Scenario.Name)
+	assert.Equal(t, 2000, unbounded.Report.Ops)
+	assert.Equal(t, 10, unbounded.PeakEntries)
+	assert.Equal(t, 10*(entryOverhead+int64(len("key0")+100)), unbounded.PeakMemory)
+	assert.Greater(t, unbounded.Report.HitRatio(), 0.99)
+
+	assert.Equal(t, 2, small.PeakEntries)
+	assert.Less(t, small.Report.HitRatio(), unbounded.Report.HitRatio())
+
+	// the keys are read 5 seconds after being set, past their ttl, and only the last 3 seconds of sets and fills live
+	assert.Less(t, short.Report.HitRatio(), 0.01)
+	assert.LessOrEqual(t, short.PeakEntries, 6)
+}
+
+func TestSimulateBudgets(t *testing.T) {
+	var trace bytes.Buffer
+	enc := json.NewEncoder(&trace)
+	now := time.Now()
+	for i := 0; i < 100; i++ {
+		now = now.Add(time.Second)
+		key := fmt.Sprintf("key%d", i%4)
+		assert.NoError(t, enc.Encode(TraceOp{Op: TraceSet, Key: key, Size: 1000, Time: now}))
+		assert.NoError(t, enc.Encode(TraceOp{Op: TraceGet, Key: key, Hit: true, Time: now}))
+		// the key read just before the set of the next one is the least recently used
+		assert.NoError(t, enc.Encode(TraceOp{Op: TraceGet, Key: fmt.Sprintf("key%d", (i+2)%4), Hit: true, Time: now}))
+	}
+
+	results, err := Simulate(bytes.NewReader(trace.Bytes()),
+		Scenario{Name: "lru", MaxEntries: 2},
+		Scenario{Name: "memory", Options: []Option{WithMaxMemory(2 * entrySize("key0", make([]byte, 1000)))}},
+	)
+	assert.NoError(t, err)
+
+	lru, memory := results[0], results[1]
+	assert.Equal(t, 2, lru.PeakEntries)
+	// the datas sized by the trace are evicted by the memory budget
+	assert.Equal(t, 2, memory.PeakEntries)
+	assert.Equal(t, lru.Report.Hits, memory.Report.Hits)
+}
@@ -0,0 +1,93 @@
+package fscache
+
+import "hash/fnv"
+
+const (
+	// sketchDepth is the number of rows of the count-min sketch
+	sketchDepth = 4
+	// sketchMaxCount is the value at which the sketch counters saturate
+	sketchMaxCount = 15
+)
+
+// sketchSeeds spreads a key hash over the rows of the count-min sketch
+var sketchSeeds = [sketchDepth]uint64{0xc3a5c85c97cb3127, 0xb492b66fbe98f273, 0x9ae16a3b2f90404f, 0xcbf29ce484222325}
+
+// frequencySketch is a count-min sketch estimating how often keys are accessed.
+// Counters are halved periodically so the estimates favour recent accesses.
+type frequencySketch struct {
+	rows      [sketchDepth][]uint8
+	mask      uint64
+	additions int
+	resetAt   int
+}
+
+// newFrequencySketch creates a sketch sized for about width distinct keys
+func newFrequencySketch(width int) *frequencySketch {
+	size := 16
+	for size < width {
+		size <<= 1
+	}
+
+	s := &frequencySketch{
+		mask:    uint64(size - 1),
+		resetAt: size * 10,
+	}
+	for i := range s.rows {
+		s.rows[i] = make([]uint8, size)
+	}
+
+	return s
+}
+
+// hash returns the hash of key
+func (s *frequencySketch) hash(key string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(key))
+
+	return h.Sum64()
+}
+
+// index returns the counter of the row i used by hash
+func (s *frequencySketch) index(hash uint64, i int) uint64 {
+	h := (hash ^ sketchSeeds[i]) * 0x9e3779b97f4a7c15
+	return (h ^ (h >> 32)) & s.mask
+}
+
+// increment records an access to key
+func (s *frequencySketch) increment(key string) {
+	hash := s.hash(key)
+	for i := range s.rows {
+		if counter := &s.rows[i][s.index(hash, i)]; *counter < sketchMaxCount {
+			*counter++
+		}
+	}
+
+	s.additions++
+	if s.additions >= s.resetAt {
+		s.reset()
+	}
+}
+
+// estimate returns the estimated access frequency of key
+func (s *frequencySketch) estimate(key string) uint8 {
+	hash := s.hash(key)
+
+	min := uint8(sketchMaxCount)
+	for i := range s.rows {
+		if counter := s.rows[i][s.index(hash, i)]; counter < min {
+			min = counter
+		}
+	}
+
+	return min
+}
+
+// reset halves every counter so old accesses weigh less than recent ones
+func (s *frequencySketch) reset() {
+	for i := range s.rows {
+		for j := range s.rows[i] {
+			s.rows[i][j] >>= 1
+		}
+	}
+	s.additions /= 2
+}
@@ -0,0 +1,34 @@
+package fscache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdmission(t *testing.T) {
+	ch := Cache{}
+	WithMaxCost(2)(&ch)
+	WithAdmission()(&ch)
+
+	assert.NoError(t, ch.Memdis().Set("hot1", "value"))
+	assert.NoError(t, ch.Memdis().Set("hot2", "value"))
+	for i := 0; i < 5; i++ {
+		_, _ = ch.Memdis().Get("hot1")
+		_, _ = ch.Memdis().Get("hot2")
+	}
+
+	// a key seen only once doesn't evict the frequently used ones
+	assert.NoError(t, ch.Memdis().Set("once", "value"))
+	assert.ElementsMatch(t, []string{"hot1", "hot2"}, ch.Memdis().Keys())
+}
+
+func TestFrequencySketch(t *testing.T) {
+	sketch := newFrequencySketch(16)
+	for i := 0; i < 3; i++ {
+		sketch.increment("key1")
+	}
+
+	assert.GreaterOrEqual(t, sketch.estimate("key1"), uint8(3))
+	assert.Less(t, sketch.estimate("key2"), sketch.estimate("key1"))
+}
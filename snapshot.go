@@ -0,0 +1,221 @@
+package fscache
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// memdisStorageName is the name of the file SaveSnapshot() writes the Memdis datas into
+	memdisStorageName = "memdisstorage.json"
+)
+
+var (
+	// memdisStorageFile is the path of the file SaveSnapshot() writes the Memdis datas into
+	memdisStorageFile = "./" + memdisStorageName
+)
+
+// snapshotData object is a data as it is written into a snapshot file
+type snapshotData struct {
+	Key   string      `json:"key"`
+	Value interface{} `json:"value"`
+	// ExpiresAt is the absolute expiration time of the data, so it neither becomes immortal nor gets
+	// a fresh lifetime when it is loaded back
+	ExpiresAt *time.Time    `json:"expiresAt,omitempty"`
+	TTL       time.Duration `json:"ttl,omitempty"`
+}
+
+// WithWriteBarrier makes mutations wait while Memgodb.Persist() and Memdis.SaveSnapshot() write the datas
+// until they are synced to disk, so no more than the datas changed since the last persistence can be lost.
+func WithWriteBarrier() Option {
+	return func(c *Cache) {
+		c.MemdisInstance.writeBarrier = true
+		c.MemgodbInstance.writeBarrier = true
+	}
+}
+
+// SaveSnapshot() writes all the Memdis datas along with their expiration into a json file on the server.
+// Once called, a cronJob keeps writing the datas every minute. The file is only written when the datas changed
+// since the last snapshot, and is replaced atomically so a crash never leaves it half written.
+func (md *Memdis) SaveSnapshot() (err error) {
+	defer recoverPanic(&err)
+
+	lock, unlock := md.state().mu.RLock, md.state().mu.RUnlock
+	if md.writeBarrier {
+		lock, unlock = md.state().mu.Lock, md.state().mu.Unlock
+	}
+
+	lock()
+	md.state().persistSnapshot.Store(true)
+	if !md.state().dirty.Swap(false) {
+		unlock()
+		return nil
+	}
+
+	var datas []snapshotData
+	md.snapshot(func(_ int, key string, value MemdisData) {
+		if !md.persistedKey(md.originalKey(key)) {
+			return
+		}
+
+		data := snapshotData{
+			Key:   md.originalKey(key),
+			Value: value.Value,
+			TTL:   value.TTL,
+		}
+		if !value.Duration.IsZero() {
+			expiresAt := value.Duration
+			data.ExpiresAt = &expiresAt
+		}
+		datas = append(datas, data)
+	})
+
+	jsonByte, err := json.Marshal(datas)
+	if md.writeBarrier {
+		// mutations wait until the datas are safely on disk
+		defer unlock()
+	} else {
+		unlock()
+	}
+	if err != nil {
+		md.state().dirty.Store(true)
+		return err
+	}
+
+	if err := writeFileAtomic(memdisStorageFile, jsonByte); err != nil {
+		md.state().dirty.Store(true)
+		return err
+	}
+
+	return nil
+}
+
+// PersistPrefix() restricts SaveSnapshot() to the keys starting with one of prefixes, so ephemeral datas
+// (sessions, locks...) are never written to disk. Calling it without prefixes persists all the keys again.
+func (md *Memdis) PersistPrefix(prefixes ...string) {
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	md.persistPrefixes = append([]string(nil), prefixes...)
+	md.state().dirty.Store(true)
+}
+
+// persistedKey reports whether key is written by SaveSnapshot(). The caller must hold md.state().mu.
+func (md *Memdis) persistedKey(key string) bool {
+	if len(md.persistPrefixes) == 0 {
+		return true
+	}
+
+	for _, prefix := range md.persistPrefixes {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// LoadSnapshot() loads the datas written by SaveSnapshot() if any. Datas keep the expiration they were saved with,
+// the ones which expired in the meantime are not loaded. Values are decoded as json values, e.g. numbers as float64.
+func (md *Memdis) LoadSnapshot() (err error) {
+	defer recoverPanic(&err)
+
+	f, err := os.Open(memdisStorageFile)
+	if err != nil {
+		return errors.New("error finding file")
+	}
+	defer f.Close()
+
+	return md.load(f)
+}
+
+// LoadSnapshotFS() loads the datas of the memdisstorage.json file of fsys written by SaveSnapshot(),
+// such as an embed.FS bundled into the binary.
+func (md *Memdis) LoadSnapshotFS(fsys fs.FS) (err error) {
+	defer recoverPanic(&err)
+
+	f, err := fsys.Open(memdisStorageName)
+	if err != nil {
+		return errors.New("error finding file")
+	}
+	defer f.Close()
+
+	return md.load(f)
+}
+
+// load sets the datas of a file written by SaveSnapshot(), either a json array or NDJSON
+func (md *Memdis) load(f io.Reader) error {
+	return decodeParallel(f, loadWorkersOf(md.loadWorkers), md.restore)
+}
+
+// restore sets the datas of a snapshot, replacing the ones already set with the same key
+func (md *Memdis) restore(datas []snapshotData) error {
+	now := time.Now()
+	fs := make(map[string]MemdisData, len(datas))
+	for _, data := range datas {
+		key, digestOf, err := md.digestedKey(data.Key)
+		if err != nil {
+			return err
+		}
+
+		value := MemdisData{
+			Value:    data.Value,
+			TTL:      data.TTL,
+			digestOf: digestOf,
+		}
+		if data.ExpiresAt != nil {
+			value.Duration = *data.ExpiresAt
+		}
+		if value.expired(now) {
+			continue
+		}
+
+		fs[key] = value
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	for key := range fs {
+		if index, _, ok := md.lookup(key); ok {
+			md.remove(index, key)
+		}
+	}
+	if len(fs) > 0 {
+		md.insertMany([]map[string]MemdisData{fs})
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes data into a temporary file synced to disk, then renames it to name,
+// so name holds either its previous content or data even if the process crashes
+func writeFileAtomic(name string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(file.Name(), name)
+}
@@ -0,0 +1,79 @@
+package fscache
+
+import (
+	"os"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	withTempStorage(t)
+
+	ch := Cache{}
+	assert.NoError(t, ch.Memdis().Set("persisted", "value"))
+	assert.NoError(t, ch.Memdis().Set("expiring", "value", time.Hour))
+	assert.NoError(t, ch.Memdis().Set("expired", "value", 50*time.Millisecond))
+	_, expiring, _ := ch.MemdisInstance.lookup("expiring")
+
+	assert.NoError(t, ch.Memdis().SaveSnapshot())
+	time.Sleep(60 * time.Millisecond)
+
+	loaded := Cache{}
+	assert.NoError(t, loaded.Memdis().LoadSnapshot())
+	assert.ElementsMatch(t, []string{"persisted", "expiring"}, loaded.Memdis().Keys())
+
+	// the datas keep the expiration they were saved with
+	_, data, _ := loaded.MemdisInstance.lookup("expiring")
+	assert.True(t, expiring.Duration.Equal(data.Duration))
+	_, data, _ = loaded.MemdisInstance.lookup("persisted")
+	assert.True(t, data.Duration.IsZero())
+}
+
+func TestLoadSnapshotFS(t *testing.T) {
+	ch := Cache{}
+
+	fsys := fstest.MapFS{
+		"memdisstorage.json": {Data: []byte(`[{"key": "greeting", "value": "hello"}]`)},
+	}
+	assert.NoError(t, ch.Memdis().LoadSnapshotFS(fsys))
+
+	value, err := ch.Memdis().Get("greeting")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", value)
+}
+
+func TestSnapshotDirtyTracking(t *testing.T) {
+	withTempStorage(t)
+
+	ch := Cache{}
+	WithWriteBarrier()(&ch)
+	assert.NoError(t, ch.Memdis().Set("dirty1", "value"))
+	assert.NoError(t, ch.Memdis().SaveSnapshot())
+	assert.FileExists(t, memdisStorageFile)
+
+	// nothing changed, so the file is not written again
+	assert.NoError(t, os.Remove(memdisStorageFile))
+	assert.NoError(t, ch.Memdis().SaveSnapshot())
+	assert.NoFileExists(t, memdisStorageFile)
+
+	assert.NoError(t, ch.Memdis().Del("dirty1"))
+	assert.NoError(t, ch.Memdis().SaveSnapshot())
+	assert.FileExists(t, memdisStorageFile)
+}
+
+func TestPersistPrefix(t *testing.T) {
+	withTempStorage(t)
+
+	ch := Cache{}
+	ch.Memdis().PersistPrefix("config:")
+	assert.NoError(t, ch.Memdis().Set("config:theme", "dark"))
+	assert.NoError(t, ch.Memdis().Set("session:1", "user1"))
+	assert.NoError(t, ch.Memdis().SaveSnapshot())
+
+	loaded := Cache{}
+	assert.NoError(t, loaded.Memdis().LoadSnapshot())
+	assert.EqualValues(t, []string{"config:theme"}, loaded.Memdis().Keys())
+}
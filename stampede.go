@@ -0,0 +1,30 @@
+package fscache
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// WithEarlyExpiration protects the datas set with GetOrLoad() from cache stampedes with probabilistic early
+// expiration (XFetch): each read refreshes the data in the background with a probability growing as its expiration
+// gets closer, and as the loader is slower, so a single reader refreshes a hot key before it expires instead of all
+// its readers loading it at once. beta scales how early the refreshes happen, 1 being the usual value.
+func WithEarlyExpiration(beta float64) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.earlyExpirationBeta = beta
+	}
+}
+
+// expiresEarly reports whether a read of data, expiring in remaining, triggers its early refresh:
+// it does when loadTime * beta * -ln(random) exceeds remaining.
+func (md *Memdis) expiresEarly(data MemdisData, remaining time.Duration) bool {
+	if md.earlyExpirationBeta <= 0 || data.loadTime <= 0 {
+		return false
+	}
+
+	// 1 - rand.Float64() is in (0, 1], so the log is finite
+	gap := float64(data.loadTime) * md.earlyExpirationBeta * -math.Log(1-rand.Float64())
+
+	return gap >= float64(remaining)
+}
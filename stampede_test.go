@@ -0,0 +1,58 @@
+package fscache
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEarlyExpiration(t *testing.T) {
+	ch := Cache{}
+	WithEarlyExpiration(1e9)(&ch)
+
+	refreshed := make(chan struct{}, 1)
+	var calls atomic.Int64
+	loader := func(key string) (interface{}, error) {
+		time.Sleep(time.Millisecond)
+		n := calls.Add(1)
+		if n > 1 {
+			select {
+			case refreshed <- struct{}{}:
+			default:
+			}
+		}
+		return n, nil
+	}
+
+	value, err := ch.Memdis().GetOrLoad("early1", loader, time.Minute)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, value)
+
+	// the loader is slow compared to the remaining lifetime scaled by beta, so reading the data refreshes it early
+	value, err = ch.Memdis().Get("early1")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 1, value)
+
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("data was not refreshed")
+	}
+
+	assert.Eventually(t, func() bool {
+		value, _ := ch.Memdis().Get("early1")
+		// every read refreshes the data again, so it may already be past the second load
+		return value != int64(1)
+	}, time.Second, 10*time.Millisecond)
+
+	// without early expiration, the data is not refreshed before it expires
+	other := Cache{}
+	_, err = other.Memdis().GetOrLoad("early2", func(key string) (interface{}, error) {
+		time.Sleep(time.Millisecond)
+		return key, nil
+	}, time.Minute)
+	assert.NoError(t, err)
+	assert.False(t, other.MemdisInstance.expiresEarly(other.MemdisInstance.storage["early2"], time.Minute))
+}
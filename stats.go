@@ -0,0 +1,163 @@
+package fscache
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+type (
+	// Stats object is a point-in-time copy of the counters, sizes and config of the cache, see Cache.Stats()
+	Stats struct {
+		Memdis  MemdisStats  `json:"memdis"`
+		Memgodb MemgodbStats `json:"memgodb"`
+		Config  StatsConfig  `json:"config"`
+	}
+
+	// MemdisStats object holds the counters and sizes of Memdis
+	MemdisStats struct {
+		// Datas is the number of datas which have not expired
+		Datas int `json:"datas"`
+		// Cost is the total cost of the datas against the WithMaxCost budget
+		Cost int64 `json:"cost"`
+		// Memory is the estimated size in bytes of the datas against the WithMaxMemory budget, 0 without it
+		Memory int64 `json:"memory"`
+		// Hits is the number of reads which found their data
+		Hits uint64 `json:"hits"`
+		// Misses is the number of reads which did not find their data
+		Misses uint64 `json:"misses"`
+		// Sets is the number of datas set, whether they were new or replaced
+		Sets uint64 `json:"sets"`
+		// Deletes is the number of datas deleted, by Del(), Clear() and the other deletions
+		Deletes uint64 `json:"deletes"`
+		// Evictions is the number of datas evicted to fit in the WithMaxCost, WithMaxMemory or WithMaxEntries limits,
+		// or under memory pressure
+		Evictions uint64 `json:"evictions"`
+		// Expirations is the number of expired datas removed from the storage
+		Expirations uint64 `json:"expirations"`
+		// DroppedEvents is the number of KeyEvents dropped because the receiver of Events() fell behind
+		DroppedEvents uint64 `json:"droppedEvents"`
+	}
+
+	// MemgodbStats object holds the counters and sizes of Memgodb
+	MemgodbStats struct {
+		// Records is the number of records of all the collections
+		Records int `json:"records"`
+		// Collections is the number of records by collection
+		Collections map[string]int `json:"collections"`
+		// Counters are the counts of the counters defined with DefineCounter() by name
+		Counters map[string]map[string]int `json:"counters"`
+	}
+
+	// StatsConfig object holds the config the cache was created with
+	StatsConfig struct {
+		MaxCost           int64          `json:"maxCost"`
+		MaxEntries        int            `json:"maxEntries"`
+		MaxMemory         int64          `json:"maxMemory"`
+		DefaultExpiration time.Duration  `json:"defaultExpiration"`
+		JanitorInterval   time.Duration  `json:"janitorInterval"`
+		OpTimeout         time.Duration  `json:"opTimeout"`
+		FullPolicy        FullPolicy     `json:"fullPolicy"`
+		IterationOrder    IterationOrder `json:"iterationOrder"`
+		MemoryPressure    bool           `json:"memoryPressure"`
+		Admission         bool           `json:"admission"`
+	}
+)
+
+// Stats() returns all the counters, the sizes of Memdis and of each Memgodb collection, and the config of the cache
+func (c *Cache) Stats() Stats {
+	md := &c.MemdisInstance
+	stats := Stats{
+		Memdis: md.Stats(),
+		Memgodb: MemgodbStats{
+			Collections: make(map[string]int),
+			Counters:    make(map[string]map[string]int),
+		},
+		Config: StatsConfig{
+			DefaultExpiration: md.defaultExpiration,
+			JanitorInterval:   md.janitorInterval,
+			OpTimeout:         md.opTimeout,
+			FullPolicy:        md.fullPolicy,
+			IterationOrder:    md.iterationOrder,
+			MemoryPressure:    md.memoryPressure != nil,
+		},
+	}
+
+	md.state().mu.RLock()
+	stats.Config.MaxCost = md.maxCost
+	stats.Config.MaxEntries = md.maxEntries
+	stats.Config.MaxMemory = md.maxMemory
+	stats.Config.Admission = md.admission != nil
+	md.state().mu.RUnlock()
+
+	memgodbMu.RLock()
+	defer memgodbMu.RUnlock()
+
+	stats.Memgodb.Records = len(MemgodbStorage)
+	for _, record := range MemgodbStorage {
+		if objMap, ok := record.(map[string]interface{}); ok {
+			if colName, ok := objMap["colName"].(string); ok {
+				stats.Memgodb.Collections[colName]++
+			}
+		}
+	}
+
+	for name, counter := range memgodbCounters {
+		counts := make(map[string]int, len(counter.counts))
+		for value, count := range counter.counts {
+			counts[value] = count
+		}
+		stats.Memgodb.Counters[name] = counts
+	}
+
+	return stats
+}
+
+// Stats() returns the counters of Memdis, maintained atomically, along with its number of datas and their
+// total cost and estimated memory, to monitor the effectiveness of the cache
+func (md *Memdis) Stats() MemdisStats {
+	stats := MemdisStats{
+		Datas:       md.Size(),
+		Hits:        md.state().hits.Load(),
+		Misses:      md.state().misses.Load(),
+		Sets:        md.state().sets.Load(),
+		Deletes:     md.state().deletes.Load(),
+		Evictions:   md.state().evictions.Load(),
+		Expirations: md.state().expirations.Load(),
+
+		DroppedEvents: md.state().droppedEvents.Load(),
+	}
+
+	md.state().mu.RLock()
+	stats.Cost = md.totalCost
+	stats.Memory = md.totalSize
+	md.state().mu.RUnlock()
+
+	return stats
+}
+
+// JSON() returns the stats as a single json document
+func (s Stats) JSON() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// StatsHandler() returns an http.Handler serving Stats() as json, to be scraped by custom tooling, e.g.
+// http.Handle("/stats", cache.StatsHandler())
+func (c *Cache) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+
+		data, err := c.Stats().JSON()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	})
+}
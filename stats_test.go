@@ -0,0 +1,95 @@
+package fscache
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	ch := Cache{}
+	WithMaxCost(2)(&ch)
+
+	assert.NoError(t, ch.Memdis().SetWithCost("a", "value", 1))
+	assert.NoError(t, ch.Memdis().SetWithCost("b", "value", 1))
+	assert.NoError(t, ch.Memdis().SetWithCost("c", "value", 1))
+	_, err := ch.Memdis().Get("c")
+	assert.NoError(t, err)
+	_, err = ch.Memdis().Get("missing")
+	assert.Error(t, err)
+
+	_, err = ch.Memgodb().Collection("statsrecord").Insert(map[string]interface{}{"name": "stats"}).One()
+	assert.NoError(t, err)
+
+	stats := ch.Stats()
+	assert.Equal(t, MemdisStats{Datas: 2, Cost: 2, Hits: 1, Misses: 1, Sets: 3, Evictions: 1}, stats.Memdis)
+	assert.Equal(t, 1, stats.Memgodb.Collections["statsrecords"])
+	assert.EqualValues(t, 2, stats.Config.MaxCost)
+
+	rec := httptest.NewRecorder()
+	ch.StatsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/stats", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var served Stats
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &served))
+	assert.Equal(t, stats.Memdis, served.Memdis)
+
+	rec = httptest.NewRecorder()
+	ch.StatsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/stats", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestMemdisStats(t *testing.T) {
+	ch := Cache{}
+	WithMaxEntries(2)(&ch)
+	md := ch.Memdis()
+
+	assert.NoError(t, md.Set("a", 1))
+	assert.NoError(t, md.OverWrite("a", 2))
+	assert.NoError(t, md.Set("b", 1, time.Nanosecond))
+	time.Sleep(time.Millisecond)
+	md.expire()
+	assert.NoError(t, md.Set("c", 1))
+	assert.NoError(t, md.Set("d", 1))
+	assert.NoError(t, md.Del("c"))
+
+	_, err := md.Get("d")
+	assert.NoError(t, err)
+	_, err = md.Get("a")
+	assert.Error(t, err)
+
+	stats := md.Stats()
+	assert.Equal(t, 1, stats.Datas)
+	assert.EqualValues(t, 1, stats.Hits)
+	assert.EqualValues(t, 1, stats.Misses)
+	assert.EqualValues(t, 5, stats.Sets)
+	assert.EqualValues(t, 1, stats.Deletes)
+	assert.EqualValues(t, 1, stats.Expirations)
+	assert.EqualValues(t, 1, stats.Evictions)
+	assert.Equal(t, stats, ch.Stats().Memdis)
+
+	assert.NoError(t, md.Clear())
+	assert.EqualValues(t, 2, md.Stats().Deletes)
+	assert.Equal(t, 0, md.Stats().Datas)
+
+	// the counters are updated atomically by concurrent writers
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				md.Set(fmt.Sprintf("key%d-%d", i, j), j)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.EqualValues(t, 105, md.Stats().Sets)
+}
@@ -0,0 +1,195 @@
+package fscache
+
+import "time"
+
+// storedIndex is the index lookup() returns for the datas of the storage
+const storedIndex = 0
+
+// lookup finds the data of key and where it is stored: storedIndex for the storage, or mappedIndex when it is
+// served from the mapped snapshot. Expired datas are not found. The caller must hold md.state().mu.
+func (md *Memdis) lookup(key string) (int, MemdisData, bool) {
+	if val, ok := md.storage[key]; ok {
+		if val.expired(time.Now()) {
+			return -1, MemdisData{}, false
+		}
+		return storedIndex, val, true
+	}
+
+	if md.mapped != nil {
+		if val, ok := md.mapped.get(key, time.Now()); ok {
+			return mappedIndex, val, true
+		}
+	}
+
+	return -1, MemdisData{}, false
+}
+
+// insert adds a new data to the storage, unless the admission filter rejects it. The caller must hold md.state().mu.
+func (md *Memdis) insert(key string, data MemdisData) {
+	if !md.admit(key, data) {
+		return
+	}
+
+	md.store(key, data)
+	md.evict()
+}
+
+// insertMany adds many data objects to the storage. The caller must hold md.state().mu.
+func (md *Memdis) insertMany(data []map[string]MemdisData) {
+	for _, cache := range data {
+		md.objects++
+		for key, value := range cache {
+			value.object = md.objects
+			md.store(key, md.withDeadline(value))
+		}
+	}
+
+	md.evict()
+}
+
+// store sets the data of key in the storage, replacing the one already set if any. The caller must hold md.state().mu.
+func (md *Memdis) store(key string, data MemdisData) {
+	// the reads not applied yet happened before the write
+	md.applyReads()
+
+	if md.storage == nil {
+		md.storage = make(map[string]MemdisData)
+	}
+
+	prev, existed := md.storage[key]
+	// a data replacing another one stays in its object, a new one is an object of its own
+	if data.object == 0 && existed {
+		data.object = prev.object
+	} else if data.object == 0 {
+		md.objects++
+		data.object = md.objects
+	}
+	if existed {
+		md.removed(prev)
+		// an expired data replaced before being swept is reported as expired
+		if prev.expired(time.Now()) {
+			md.state().expirations.Add(1)
+			md.notify(KeyExpire, key)
+			existed = false
+		}
+	} else if md.mapped != nil {
+		_, existed = md.mapped.index[key]
+		existed = existed && !md.mapped.deleted[key]
+	}
+	if data.digestOf != "" {
+		md.rememberDigest(key, data.digestOf)
+		data.digestOf = ""
+	}
+	if md.maxMemory > 0 {
+		data.size = entrySize(key, data.Value)
+	}
+	md.storage[key] = md.added(data)
+	md.track(key, md.storage[key])
+	md.state().sets.Add(1)
+
+	if existed {
+		md.notify(KeyOverwrite, key)
+	} else {
+		md.notify(KeySet, key)
+	}
+}
+
+// replace replaces the data of key stored at index. The caller must hold md.state().mu.
+func (md *Memdis) replace(index int, key string, data MemdisData) {
+	md.store(key, data)
+	if index == mappedIndex {
+		md.mapped.deleted[key] = true
+	}
+	md.evict()
+}
+
+// remove deletes key from where it is stored at index. The caller must hold md.state().mu.
+func (md *Memdis) remove(index int, key string) MemdisData {
+	if index == mappedIndex {
+		data, _ := md.mapped.get(key, time.Now())
+		md.mapped.deleted[key] = true
+		md.state().dirty.Store(true)
+		return data
+	}
+
+	data := md.storage[key]
+	md.removed(data)
+	delete(md.storage, key)
+	md.untrack(key)
+
+	return data
+}
+
+// deleteKey removes the data of key stored at index, and records its deletion. The caller must hold md.state().mu.
+func (md *Memdis) deleteKey(index int, key string) {
+	md.remove(index, key)
+	md.state().deletes.Add(1)
+	md.notify(KeyDelete, key)
+	md.forgetDigest(key)
+}
+
+// withDeadline derives the Duration of a data given to SetMany() from its TTL when it is not set
+func (md *Memdis) withDeadline(data MemdisData) MemdisData {
+	if data.Duration.IsZero() && data.TTL != NoExpiration {
+		data.TTL = md.resolveTTL(data.TTL)
+		data.Duration = expiresAt(data.TTL)
+	}
+
+	return data
+}
+
+// reset deletes all the datas from the storage. The caller must hold md.state().mu.
+func (md *Memdis) reset() {
+	// the datas are deleted at once, without an event each
+	md.unorderedSnapshot(func(_ int, _ string, _ MemdisData) {
+		md.state().deletes.Add(1)
+	})
+
+	md.state().digestsMu.Lock()
+	md.digests = nil
+	md.state().digestsMu.Unlock()
+
+	md.storage = nil
+	md.recency = nil
+	md.priorities = nil
+	md.totalCost = 0
+	md.totalSize = 0
+	md.series = nil
+	md.geo = nil
+	md.unmap()
+	md.state().dirty.Store(true)
+	if md.interned != nil {
+		md.interned = make(map[string]*internedValue)
+	}
+}
+
+// added accounts for a data being added to the storage and returns it ready to be stored
+func (md *Memdis) added(data MemdisData) MemdisData {
+	if data.createdAt.IsZero() {
+		data.createdAt = time.Now()
+	}
+	if data.sequence == 0 {
+		md.sequence++
+		data.sequence = md.sequence
+	}
+	md.version++
+	data.version = md.version
+	md.clock++
+	data.accessed = md.clock
+	data = md.intern(data)
+	data.cost = costOf(data)
+	data.priority = md.inflation + float64(data.cost)
+	md.totalCost += data.cost
+	md.totalSize += data.size
+	md.state().dirty.Store(true)
+
+	return data
+}
+
+// removed accounts for a data being taken off the storage
+func (md *Memdis) removed(data MemdisData) {
+	md.release(data)
+	md.totalCost -= costOf(data)
+	md.totalSize -= data.size
+	md.state().dirty.Store(true)
+}
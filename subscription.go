@@ -0,0 +1,250 @@
+package fscache
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// subscriptionQueueSize is the number of QueryEvents queued for a subscriber which falls behind
+const subscriptionQueueSize = 4096
+
+// QueryEventType is the type of a QueryEvent
+type QueryEventType int
+
+const (
+	// QueryInitial holds the result set of the query when it was subscribed to
+	QueryInitial QueryEventType = iota
+	// QueryAdded is sent when a record starts matching the query
+	QueryAdded
+	// QueryUpdated is sent when a record matching the query changed
+	QueryUpdated
+	// QueryRemoved is sent when a record stops matching the query
+	QueryRemoved
+)
+
+var (
+	// memgodbSubscriptions are the live queries, guarded by memgodbMu
+	memgodbSubscriptions map[*Subscription]struct{}
+)
+
+type (
+	// QueryEvent object is a change of the result set of a live query
+	QueryEvent struct {
+		Type QueryEventType
+		// Records is the result set of a QueryInitial event
+		Records []map[string]interface{}
+		// Record is the record added, updated or removed
+		Record map[string]interface{}
+	}
+
+	// Subscription object is a live query created by Subscribe()
+	Subscription struct {
+		collection Collection
+		filter     map[string]interface{}
+		// results are the records matching the query by id
+		results map[string]map[string]interface{}
+
+		events chan QueryEvent
+		// queue holds the events not delivered yet, so mutations never wait for the subscribers
+		queue   []QueryEvent
+		queueMu sync.Mutex
+		wake    chan struct{}
+		done    chan struct{}
+		once    sync.Once
+		// dropped counts the events dropped because the queue was full
+		dropped atomic.Uint64
+	}
+)
+
+// Subscribe is a method available in Filter(). It returns a live query which first sends the records matching
+// the filter, then a notification each time a record is added to, updated in or removed from the result.
+// Without filter, all the records of the collection match. Up to 4096 events are queued until they are received, so
+// mutations never wait for the subscriber: the events happening while the queue is full are dropped and counted by
+// Dropped(). Close() must be called once done.
+func (f *Filter) Subscribe() *Subscription {
+	s := &Subscription{
+		collection: f.collection,
+		filter:     f.filter,
+		results:    make(map[string]map[string]interface{}),
+		events:     make(chan QueryEvent),
+		wake:       make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+
+	memgodbMu.Lock()
+	records := s.matching()
+	for _, record := range records {
+		s.results[fmt.Sprint(record["id"])] = record
+	}
+	s.push(QueryEvent{Type: QueryInitial, Records: records})
+
+	if memgodbSubscriptions == nil {
+		memgodbSubscriptions = make(map[*Subscription]struct{})
+	}
+	memgodbSubscriptions[s] = struct{}{}
+	memgodbMu.Unlock()
+
+	go s.deliver()
+
+	return s
+}
+
+// Events returns the channel the events of the live query are sent on. It is closed by Close().
+func (s *Subscription) Events() <-chan QueryEvent {
+	return s.events
+}
+
+// Dropped returns the number of events dropped because the subscriber fell behind. Once it is not zero, the result
+// set received so far may be stale: subscribe again to get it whole.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close stops the live query
+func (s *Subscription) Close() {
+	memgodbMu.Lock()
+	delete(memgodbSubscriptions, s)
+	memgodbMu.Unlock()
+
+	s.once.Do(func() {
+		close(s.done)
+	})
+}
+
+// memgodbChanged marks the storage as changed. The caller must hold memgodbMu.
+func memgodbChanged() {
+	memgodbDirty.Store(true)
+}
+
+// memgodbRecordChanged updates the counters and the live queries for a record changing from previous to record,
+// either being nil when the record is inserted or deleted. The caller must hold memgodbMu.
+func memgodbRecordChanged(previous, record map[string]interface{}) {
+	memgodbCount(previous, record)
+
+	for s := range memgodbSubscriptions {
+		s.changed(previous, record)
+	}
+}
+
+// memgodbCleared resets the counters and empties the result sets of the live queries once the storage is emptied.
+// The caller must hold memgodbMu.
+func memgodbCleared() {
+	resetMemgodbCounters()
+
+	for s := range memgodbSubscriptions {
+		for id, record := range s.results {
+			s.push(QueryEvent{Type: QueryRemoved, Record: record})
+			delete(s.results, id)
+		}
+	}
+}
+
+// changed sends the change of the result set caused by a record changing from previous to record, only decoding
+// that record rather than the whole storage. The caller must hold memgodbMu.
+func (s *Subscription) changed(previous, record map[string]interface{}) {
+	current := record
+	if current == nil {
+		current = previous
+	}
+	if current == nil || current["colName"] != s.collection.collectionName {
+		return
+	}
+	id := fmt.Sprint(current["id"])
+	result, ok := s.results[id]
+
+	if record != nil {
+		item, err := s.collection.decode(record)
+		if err != nil {
+			return
+		}
+		s.collection.decrypt([]map[string]interface{}{item})
+
+		if s.filter == nil || s.collection.matches(item, s.filter) {
+			s.results[id] = item
+			switch {
+			case !ok:
+				s.push(QueryEvent{Type: QueryAdded, Record: item})
+			case !reflect.DeepEqual(result, item):
+				s.push(QueryEvent{Type: QueryUpdated, Record: item})
+			}
+			return
+		}
+	}
+
+	if ok {
+		delete(s.results, id)
+		s.push(QueryEvent{Type: QueryRemoved, Record: result})
+	}
+}
+
+// matching returns the records matching the query, decrypted. The caller must hold memgodbMu.
+func (s *Subscription) matching() []map[string]interface{} {
+	objMaps, err := s.collection.decodeMany(MemgodbStorage)
+	if err != nil {
+		return nil
+	}
+
+	var records []map[string]interface{}
+	for _, item := range objMaps {
+		if item["colName"] != s.collection.collectionName {
+			continue
+		}
+		s.collection.decrypt([]map[string]interface{}{item})
+
+		if s.filter == nil || s.collection.matches(item, s.filter) {
+			records = append(records, item)
+		}
+	}
+
+	return records
+}
+
+// push queues an event to be delivered, or drops it if the queue is full
+func (s *Subscription) push(event QueryEvent) {
+	s.queueMu.Lock()
+	if len(s.queue) >= subscriptionQueueSize {
+		s.queueMu.Unlock()
+		s.dropped.Add(1)
+		return
+	}
+	s.queue = append(s.queue, event)
+	s.queueMu.Unlock()
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// deliver sends the queued events until Close() is called, then closes the channel. The events are sent one at a
+// time without holding memgodbMu nor the queue lock, so a slow subscriber never blocks the mutations.
+func (s *Subscription) deliver() {
+	defer close(s.events)
+
+	for {
+		select {
+		case <-s.wake:
+		case <-s.done:
+			return
+		}
+
+		for {
+			s.queueMu.Lock()
+			if len(s.queue) == 0 {
+				s.queueMu.Unlock()
+				break
+			}
+			event := s.queue[0]
+			s.queue = s.queue[1:]
+			s.queueMu.Unlock()
+
+			select {
+			case s.events <- event:
+			case <-s.done:
+				return
+			}
+		}
+	}
+}
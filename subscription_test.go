@@ -0,0 +1,71 @@
+package fscache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_Subscribe(t *testing.T) {
+	ch := Cache{}
+
+	_, err := ch.Memgodb().Collection("live").Insert(map[string]interface{}{"city": "Paris", "name": "john"}).One()
+	assert.NoError(t, err)
+
+	filter := map[string]interface{}{"city": "Paris"}
+	sub := ch.Memgodb().Collection("live").Filter(filter).Subscribe()
+	defer sub.Close()
+
+	event := <-sub.Events()
+	assert.Equal(t, QueryInitial, event.Type)
+	assert.Len(t, event.Records, 1)
+
+	_, err = ch.Memgodb().Collection("live").Insert(map[string]interface{}{"city": "Paris", "name": "jane"}).One()
+	assert.NoError(t, err)
+	event = <-sub.Events()
+	assert.Equal(t, QueryAdded, event.Type)
+	assert.Equal(t, "jane", event.Record["name"])
+
+	// records of other collections or not matching are ignored
+	_, err = ch.Memgodb().Collection("live").Insert(map[string]interface{}{"city": "Lagos", "name": "jack"}).One()
+	assert.NoError(t, err)
+	_, err = ch.Memgodb().Collection("other").Insert(map[string]interface{}{"city": "Paris"}).One()
+	assert.NoError(t, err)
+
+	assert.NoError(t, ch.Memgodb().Collection("live").Patch(map[string]interface{}{"name": "jane"}, map[string]interface{}{"age": 30}))
+	event = <-sub.Events()
+	assert.Equal(t, QueryUpdated, event.Type)
+	assert.Equal(t, 30.0, event.Record["age"])
+
+	assert.NoError(t, ch.Memgodb().Collection("live").Patch(map[string]interface{}{"name": "john"}, map[string]interface{}{"city": "Lyon"}))
+	event = <-sub.Events()
+	assert.Equal(t, QueryRemoved, event.Type)
+	assert.Equal(t, "john", event.Record["name"])
+
+	assert.NoError(t, ch.Memgodb().Collection("live").Update(map[string]interface{}{"name": "jane"}, map[string]interface{}{"name": "janet"}).One())
+	event = <-sub.Events()
+	assert.Equal(t, QueryUpdated, event.Type)
+	assert.Equal(t, "janet", event.Record["name"])
+	assert.Equal(t, 30.0, event.Record["age"])
+
+	assert.NoError(t, ch.Memgodb().Collection("live").Delete(map[string]interface{}{"name": "janet"}).One())
+	event = <-sub.Events()
+	assert.Equal(t, QueryRemoved, event.Type)
+	assert.Equal(t, "janet", event.Record["name"])
+	assert.Zero(t, sub.Dropped())
+
+	sub.Close()
+	_, ok := <-sub.Events()
+	assert.False(t, ok)
+}
+
+func Test_SubscribeQueue(t *testing.T) {
+	// without a subscriber receiving them, the events beyond the size of the queue are dropped
+	sub := &Subscription{wake: make(chan struct{}, 1)}
+	for i := 0; i < subscriptionQueueSize+3; i++ {
+		sub.push(QueryEvent{Type: QueryAdded})
+	}
+
+	assert.Len(t, sub.queue, subscriptionQueueSize)
+	assert.Equal(t, uint64(3), sub.Dropped())
+}
@@ -0,0 +1,77 @@
+package fscache
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// timeoutCheckInterval is the number of records or datas scanned between two checks of the deadline
+	timeoutCheckInterval = 256
+)
+
+var (
+	// ErrTimeout is returned by the scans aborted once the timeout set with WithOpTimeout() elapsed
+	ErrTimeout = errors.New("operation timed out")
+)
+
+// WithOpTimeout aborts the Memgodb queries and the Memdis ForEachParallel() scans with ErrTimeout once they ran
+// for timeout, so a degenerate full-collection query can't blow the latency of a request.
+// Collection.WithTimeout() overrides it for the queries of a collection.
+func WithOpTimeout(timeout time.Duration) Option {
+	return func(c *Cache) {
+		c.MemdisInstance.opTimeout = timeout
+		c.MemgodbInstance.opTimeout = timeout
+	}
+}
+
+// WithTimeout returns the collection aborting its queries with ErrTimeout once they ran for timeout,
+// whatever the timeout set with WithOpTimeout(). A timeout of 0 disables it.
+func (c *Collection) WithTimeout(timeout time.Duration) *Collection {
+	timed := *c
+	timed.timeout = timeout
+
+	return &timed
+}
+
+// deadlineOf returns the deadline of an operation starting now, a zero time when timeout is disabled
+func deadlineOf(timeout time.Duration) time.Time {
+	if timeout <= 0 {
+		return time.Time{}
+	}
+
+	return time.Now().Add(timeout)
+}
+
+// pastDeadline reports whether the deadline has passed. It is only checked every timeoutCheckInterval scanned
+// items, i being the index of the item being scanned, so it must not be used where a callback runs for each item.
+func pastDeadline(deadline time.Time, i int) bool {
+	return i%timeoutCheckInterval == 0 && deadlinePassed(deadline)
+}
+
+// deadlinePassed reports whether the deadline has passed, a zero deadline never passing
+func deadlinePassed(deadline time.Time) bool {
+	return !deadline.IsZero() && time.Now().After(deadline)
+}
+
+// decodeStorage decodes the records of storage like decodeMany(), unless the deadline passes
+func (c *Collection) decodeStorage(storage []interface{}, deadline time.Time) ([]map[string]interface{}, error) {
+	if deadline.IsZero() {
+		return c.decodeMany(storage)
+	}
+
+	objMaps := make([]map[string]interface{}, 0, len(storage))
+	for i, record := range storage {
+		if pastDeadline(deadline, i) {
+			return nil, ErrTimeout
+		}
+
+		objMap, err := c.decode(record)
+		if err != nil {
+			return nil, err
+		}
+		objMaps = append(objMaps, objMap)
+	}
+
+	return objMaps, nil
+}
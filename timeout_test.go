@@ -0,0 +1,39 @@
+package fscache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestForEachParallelTimeout(t *testing.T) {
+	ch := Cache{}
+	WithOpTimeout(time.Nanosecond)(&ch)
+	assert.NoError(t, ch.Memdis().Set("timed", "value"))
+
+	err := ch.Memdis().ForEachParallel(func(entry Entry) error {
+		return nil
+	}, 2)
+	assert.ErrorIs(t, err, ErrTimeout)
+}
+
+func Test_OpTimeout(t *testing.T) {
+	ch := Cache{}
+	WithOpTimeout(time.Nanosecond)(&ch)
+
+	_, err := ch.Memgodb().Collection("timed").Insert(map[string]interface{}{"name": "john"}).One()
+	assert.NoError(t, err)
+
+	_, err = ch.Memgodb().Collection("timed").Filter(map[string]interface{}{"name": "john"}).All()
+	assert.Equal(t, ErrTimeout, err)
+	_, err = ch.Memgodb().Collection("timed").Filter(map[string]interface{}{"name": "john"}).First()
+	assert.Equal(t, ErrTimeout, err)
+	_, err = ch.Memgodb().Collection("timed").Filter(nil).All()
+	assert.Equal(t, ErrTimeout, err)
+
+	// the timeout of a collection overrides WithOpTimeout()
+	record, err := ch.Memgodb().Collection("timed").WithTimeout(time.Minute).Filter(map[string]interface{}{"name": "john"}).First()
+	assert.NoError(t, err)
+	assert.Equal(t, "john", record["name"])
+}
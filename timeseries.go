@@ -0,0 +1,241 @@
+package fscache
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Aggregation defines how TSDownsample() combines the samples of a bucket
+type Aggregation int
+
+const (
+	// TSAvg averages the samples of a bucket
+	TSAvg Aggregation = iota
+	// TSSum sums the samples of a bucket
+	TSSum
+	// TSMin keeps the lowest sample of a bucket
+	TSMin
+	// TSMax keeps the highest sample of a bucket
+	TSMax
+	// TSCount counts the samples of a bucket
+	TSCount
+	// TSLast keeps the latest sample of a bucket
+	TSLast
+)
+
+var (
+	// errInvalidBucket the bucket duration is not positive
+	errInvalidBucket = errors.New("bucket must be positive")
+)
+
+type (
+	// TSSample object is a value of a time series at a timestamp
+	TSSample struct {
+		Timestamp time.Time
+		Value     float64
+	}
+
+	// timeSeries holds the samples of a time series sorted by timestamp
+	timeSeries struct {
+		samples []TSSample
+		// retention is the age, relative to the latest sample, after which samples are dropped
+		retention time.Duration
+	}
+)
+
+// TSCreate() creates an empty time series which drops the samples older than retention relatively to its
+// latest sample, 0 keeping them all. Time series live next to the key value datas in their own keyspace.
+func (md *Memdis) TSCreate(key string, retention time.Duration) (err error) {
+	defer recoverPanic(&err)
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	if _, ok := md.series[key]; ok {
+		return errKeyExists
+	}
+
+	if md.series == nil {
+		md.series = make(map[string]*timeSeries)
+	}
+	md.series[key] = &timeSeries{retention: retention}
+
+	return nil
+}
+
+// TSAdd() adds a sample to a time series, creating it without retention if it does not exist.
+// A sample added at the timestamp of another one replaces it.
+func (md *Memdis) TSAdd(key string, timestamp time.Time, value float64) (err error) {
+	defer recoverPanic(&err)
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	ts, ok := md.series[key]
+	if !ok {
+		if md.series == nil {
+			md.series = make(map[string]*timeSeries)
+		}
+		ts = &timeSeries{}
+		md.series[key] = ts
+	}
+	ts.add(TSSample{Timestamp: timestamp, Value: value})
+
+	return nil
+}
+
+// TSRange() returns the samples of a time series between from and to, both included, sorted by timestamp
+func (md *Memdis) TSRange(key string, from, to time.Time) (_ []TSSample, err error) {
+	defer recoverPanic(&err)
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return nil, err
+	}
+
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
+	ts, ok := md.series[key]
+	if !ok {
+		return nil, errKeyNotFound
+	}
+
+	return append([]TSSample(nil), ts.between(from, to)...), nil
+}
+
+// TSDownsample() returns the samples of a time series between from and to combined into buckets of the given
+// duration with aggregation. Each bucket is timestamped with its start, and buckets without samples are left out.
+func (md *Memdis) TSDownsample(key string, from, to time.Time, bucket time.Duration, aggregation Aggregation) (_ []TSSample, err error) {
+	defer recoverPanic(&err)
+
+	if bucket <= 0 {
+		return nil, errInvalidBucket
+	}
+
+	samples, err := md.TSRange(key, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var downsampled []TSSample
+	var bucketSamples []TSSample
+	for i, sample := range samples {
+		bucketSamples = append(bucketSamples, sample)
+
+		start := sample.Timestamp.Truncate(bucket)
+		if i == len(samples)-1 || !samples[i+1].Timestamp.Truncate(bucket).Equal(start) {
+			downsampled = append(downsampled, TSSample{
+				Timestamp: start,
+				Value:     aggregate(bucketSamples, aggregation),
+			})
+			bucketSamples = bucketSamples[:0]
+		}
+	}
+
+	return downsampled, nil
+}
+
+// TSDel() deletes a time series
+func (md *Memdis) TSDel(key string) (err error) {
+	defer recoverPanic(&err)
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return err
+	}
+
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	if _, ok := md.series[key]; !ok {
+		return errKeyNotFound
+	}
+	delete(md.series, key)
+
+	return nil
+}
+
+// add inserts sample at its place, then drops the samples past the retention
+func (ts *timeSeries) add(sample TSSample) {
+	i := sort.Search(len(ts.samples), func(i int) bool {
+		return !ts.samples[i].Timestamp.Before(sample.Timestamp)
+	})
+
+	switch {
+	case i < len(ts.samples) && ts.samples[i].Timestamp.Equal(sample.Timestamp):
+		ts.samples[i] = sample
+	case i == len(ts.samples):
+		ts.samples = append(ts.samples, sample)
+	default:
+		ts.samples = append(ts.samples, TSSample{})
+		copy(ts.samples[i+1:], ts.samples[i:])
+		ts.samples[i] = sample
+	}
+
+	if ts.retention > 0 {
+		oldest := ts.samples[len(ts.samples)-1].Timestamp.Add(-ts.retention)
+		expired := sort.Search(len(ts.samples), func(i int) bool {
+			return !ts.samples[i].Timestamp.Before(oldest)
+		})
+		ts.samples = append(ts.samples[:0], ts.samples[expired:]...)
+	}
+}
+
+// between returns the samples between from and to, both included
+func (ts *timeSeries) between(from, to time.Time) []TSSample {
+	start := sort.Search(len(ts.samples), func(i int) bool {
+		return !ts.samples[i].Timestamp.Before(from)
+	})
+	end := sort.Search(len(ts.samples), func(i int) bool {
+		return ts.samples[i].Timestamp.After(to)
+	})
+	if start >= end {
+		return nil
+	}
+
+	return ts.samples[start:end]
+}
+
+// aggregate combines samples with aggregation
+func aggregate(samples []TSSample, aggregation Aggregation) float64 {
+	switch aggregation {
+	case TSCount:
+		return float64(len(samples))
+	case TSLast:
+		return samples[len(samples)-1].Value
+	}
+
+	result := samples[0].Value
+	if aggregation == TSSum || aggregation == TSAvg {
+		result = 0
+	}
+	for _, sample := range samples {
+		switch aggregation {
+		case TSMin:
+			result = math.Min(result, sample.Value)
+		case TSMax:
+			result = math.Max(result, sample.Value)
+		default:
+			result += sample.Value
+		}
+	}
+
+	if aggregation == TSAvg {
+		result /= float64(len(samples))
+	}
+
+	return result
+}
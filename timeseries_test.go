@@ -0,0 +1,45 @@
+package fscache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeSeries(t *testing.T) {
+	ch := Cache{}
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, ch.Memdis().TSCreate("cpu", 10*time.Minute))
+	assert.Equal(t, errKeyExists, ch.Memdis().TSCreate("cpu", 0))
+
+	for i := 0; i < 20; i++ {
+		assert.NoError(t, ch.Memdis().TSAdd("cpu", start.Add(time.Duration(i)*time.Minute), float64(i)))
+	}
+	// samples added out of order are kept sorted, and replace the ones at the same timestamp
+	assert.NoError(t, ch.Memdis().TSAdd("cpu", start.Add(15*time.Minute), 100))
+
+	// the samples older than the retention were dropped
+	samples, err := ch.Memdis().TSRange("cpu", start, start.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Len(t, samples, 11)
+	assert.Equal(t, start.Add(9*time.Minute), samples[0].Timestamp)
+	assert.Equal(t, 100.0, samples[6].Value)
+
+	downsampled, err := ch.Memdis().TSDownsample("cpu", start, start.Add(time.Hour), 5*time.Minute, TSMax)
+	assert.NoError(t, err)
+	assert.Equal(t, []TSSample{
+		{Timestamp: start.Add(5 * time.Minute), Value: 9},
+		{Timestamp: start.Add(10 * time.Minute), Value: 14},
+		{Timestamp: start.Add(15 * time.Minute), Value: 100},
+	}, downsampled)
+
+	downsampled, err = ch.Memdis().TSDownsample("cpu", start.Add(10*time.Minute), start.Add(14*time.Minute), time.Hour, TSAvg)
+	assert.NoError(t, err)
+	assert.Equal(t, []TSSample{{Timestamp: start, Value: 12}}, downsampled)
+
+	assert.NoError(t, ch.Memdis().TSDel("cpu"))
+	_, err = ch.Memdis().TSRange("cpu", start, start.Add(time.Hour))
+	assert.Equal(t, errKeyNotFound, err)
+}
@@ -0,0 +1,97 @@
+package fscache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var (
+	// ErrTypeMismatch is returned by the reads of a Typed cache when the value of the key is not of its type,
+	// e.g. a value set through Memdis directly
+	ErrTypeMismatch = errors.New("value is not of the type of the typed cache")
+)
+
+// Typed object is a view of the Memdis storage whose values are all of type T, so callers read them without type
+// assertions. The keys are shared with Memdis and the other Typed caches of the same Cache: use a key prefix per
+// type to keep them apart.
+type Typed[T any] struct {
+	memdis *Memdis
+}
+
+// NewTyped returns a Typed cache storing its values of type T in the Memdis storage of c, e.g. the cache returned by New()
+func NewTyped[T any](c Operations) *Typed[T] {
+	return &Typed[T]{memdis: c.Memdis()}
+}
+
+// Get() retrieves the value of key like Memdis.Get(), and returns ErrTypeMismatch if it is not a T
+func (t *Typed[T]) Get(key string) (T, error) {
+	value, err := t.memdis.Get(key)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return typedValue[T](value)
+}
+
+// Set() sets the value of key like Memdis.Set()
+func (t *Typed[T]) Set(key string, value T, duration ...time.Duration) error {
+	return t.memdis.Set(key, value, duration...)
+}
+
+// OverWrite() replaces the value of key like Memdis.OverWrite()
+func (t *Typed[T]) OverWrite(key string, value T, duration ...time.Duration) error {
+	return t.memdis.OverWrite(key, value, duration...)
+}
+
+// GetDel() retrieves the value of key and deletes it like Memdis.GetDel(), and returns ErrTypeMismatch if it is
+// not a T, the key being deleted anyway
+func (t *Typed[T]) GetDel(key string) (T, error) {
+	value, err := t.memdis.GetDel(key)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return typedValue[T](value)
+}
+
+// Del() deletes the value of key like Memdis.Del()
+func (t *Typed[T]) Del(key string) error {
+	return t.memdis.Del(key)
+}
+
+// GetOrLoad() retrieves the value of key, or loads and sets it using loader if it is not found, like
+// Memdis.GetOrLoad()
+func (t *Typed[T]) GetOrLoad(key string, loader func(key string) (T, error), duration ...time.Duration) (T, error) {
+	return t.GetOrLoadContext(context.Background(), key, func(_ context.Context, key string) (T, error) {
+		return loader(key)
+	}, duration...)
+}
+
+// GetOrLoadContext() retrieves the value of key like GetOrLoad(), and passes ctx to loader like
+// Memdis.GetOrLoadContext()
+func (t *Typed[T]) GetOrLoadContext(ctx context.Context, key string, loader func(ctx context.Context, key string) (T, error), duration ...time.Duration) (T, error) {
+	value, err := t.memdis.GetOrLoadContext(ctx, key, func(ctx context.Context, key string) (interface{}, error) {
+		return loader(ctx, key)
+	}, duration...)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return typedValue[T](value)
+}
+
+// typedValue asserts that value is a T
+func typedValue[T any](value interface{}) (T, error) {
+	typed, ok := value.(T)
+	if !ok {
+		return typed, fmt.Errorf("%w: %T is not %s", ErrTypeMismatch, value, reflect.TypeFor[T]())
+	}
+
+	return typed, nil
+}
@@ -0,0 +1,63 @@
+package fscache
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTyped(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	ch := Cache{}
+	users := NewTyped[user](&ch)
+
+	assert.NoError(t, users.Set("user:1", user{Name: "ada"}))
+	got, err := users.Get("user:1")
+	assert.NoError(t, err)
+	assert.Equal(t, user{Name: "ada"}, got)
+
+	assert.NoError(t, users.OverWrite("user:1", user{Name: "grace"}))
+	got, err = users.Get("user:1")
+	assert.NoError(t, err)
+	assert.Equal(t, "grace", got.Name)
+
+	_, err = users.Get("missing")
+	assert.ErrorIs(t, err, errKeyNotFound)
+
+	// the values set through Memdis directly may be of another type
+	assert.NoError(t, ch.Memdis().Set("user:2", "ada"))
+	got, err = users.Get("user:2")
+	assert.ErrorIs(t, err, ErrTypeMismatch)
+	assert.Equal(t, user{}, got)
+
+	loads := 0
+	loader := func(key string) (user, error) {
+		loads++
+		return user{Name: key}, nil
+	}
+	for i := 0; i < 2; i++ {
+		got, err = users.GetOrLoad("user:3", loader)
+		assert.NoError(t, err)
+		assert.Equal(t, user{Name: "user:3"}, got)
+	}
+	assert.Equal(t, 1, loads)
+
+	_, err = users.GetOrLoad("user:4", func(string) (user, error) { return user{}, errors.New("unavailable") })
+	assert.Error(t, err)
+
+	got, err = users.GetDel("user:3")
+	assert.NoError(t, err)
+	assert.Equal(t, "user:3", got.Name)
+	assert.ErrorIs(t, users.Del("user:3"), errKeyNotFound)
+
+	fs := New()
+	names := NewTyped[string](fs)
+	assert.NoError(t, names.Set("name", "ada"))
+	name, err := fs.Memdis().Get("name")
+	assert.NoError(t, err)
+	assert.Equal(t, "ada", name)
+}
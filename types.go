@@ -0,0 +1,137 @@
+package fscache
+
+import (
+	"reflect"
+)
+
+// Kind is the shape of a value stored in Memdis
+type Kind string
+
+const (
+	// KindNil is the kind of nil values
+	KindNil Kind = "nil"
+	// KindString is the kind of strings
+	KindString Kind = "string"
+	// KindInt is the kind of signed and unsigned integers
+	KindInt Kind = "int"
+	// KindFloat is the kind of floating point numbers
+	KindFloat Kind = "float"
+	// KindBool is the kind of booleans
+	KindBool Kind = "bool"
+	// KindSlice is the kind of slices and arrays
+	KindSlice Kind = "slice"
+	// KindMap is the kind of maps
+	KindMap Kind = "map"
+	// KindStruct is the kind of structs
+	KindStruct Kind = "struct"
+	// KindOther is the kind of any other value, e.g. channels or functions
+	KindOther Kind = "other"
+)
+
+// RegisterTypeName() registers a friendly name returned by TypeOf() for values of the same type as sample
+func (md *Memdis) RegisterTypeName(sample interface{}, name string) {
+	md.state().mu.Lock()
+	defer md.state().mu.Unlock()
+
+	if md.typeNames == nil {
+		md.typeNames = make(map[reflect.Type]string)
+	}
+	md.typeNames[reflect.TypeOf(sample)] = name
+}
+
+// KindOf() returns the shape of a value, so callers can branch on it without using reflect
+func (md *Memdis) KindOf(key string) (_ Kind, err error) {
+	defer recoverPanic(&err)
+
+	key, err = md.canonicalKey(key)
+	if err != nil {
+		return "", err
+	}
+
+	md.state().mu.RLock()
+	defer md.state().mu.RUnlock()
+
+	_, value, ok := md.lookup(key)
+	if !ok {
+		return "", errKeyNotFound
+	}
+
+	return kindOf(value.Value), nil
+}
+
+// typeName returns the registered name of the type of value, or its Go type name. The caller must hold md.state().mu.
+func (md *Memdis) typeName(value interface{}) string {
+	if len(md.typeNames) > 0 && value != nil {
+		if name, ok := md.typeNames[reflect.TypeOf(value)]; ok {
+			return name
+		}
+	}
+
+	// the common types are resolved without reflection
+	switch value.(type) {
+	case nil:
+		return "nil"
+	case string:
+		return "string"
+	case int:
+		return "int"
+	case int64:
+		return "int64"
+	case float64:
+		return "float64"
+	case bool:
+		return "bool"
+	case []interface{}:
+		return "[]interface {}"
+	case map[string]interface{}:
+		return "map[string]interface {}"
+	}
+
+	return reflect.TypeOf(value).String()
+}
+
+// kindOf returns the kind of value
+func kindOf(value interface{}) Kind {
+	// the common types are resolved without reflection
+	switch value.(type) {
+	case nil:
+		return KindNil
+	case string:
+		return KindString
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr:
+		return KindInt
+	case float32, float64:
+		return KindFloat
+	case bool:
+		return KindBool
+	case []interface{}, []string, []int, []float64, []byte:
+		return KindSlice
+	case map[string]interface{}, map[string]string:
+		return KindMap
+	}
+
+	switch reflect.TypeOf(value).Kind() {
+	case reflect.String:
+		return KindString
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return KindInt
+	case reflect.Float32, reflect.Float64:
+		return KindFloat
+	case reflect.Bool:
+		return KindBool
+	case reflect.Slice, reflect.Array:
+		return KindSlice
+	case reflect.Map:
+		return KindMap
+	case reflect.Struct:
+		return KindStruct
+	case reflect.Ptr:
+		if v := reflect.ValueOf(value); !v.IsNil() {
+			return kindOf(v.Elem().Interface())
+		}
+		return KindNil
+	}
+
+	return KindOther
+}
@@ -0,0 +1,46 @@
+package fscache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKindOf(t *testing.T) {
+	type user struct {
+		Name string
+	}
+
+	ch := Cache{}
+	testCases := map[string]struct {
+		value interface{}
+		kind  Kind
+	}{
+		"string": {value: "value", kind: KindString},
+		"int":    {value: 10, kind: KindInt},
+		"float":  {value: 1.5, kind: KindFloat},
+		"bool":   {value: true, kind: KindBool},
+		"slice":  {value: []string{"a"}, kind: KindSlice},
+		"map":    {value: map[string]int{"a": 1}, kind: KindMap},
+		"struct": {value: user{Name: "jane"}, kind: KindStruct},
+		"ptr":    {value: &user{Name: "jane"}, kind: KindStruct},
+		"nil":    {value: nil, kind: KindNil},
+	}
+
+	for key, testCase := range testCases {
+		t.Run(key, func(t *testing.T) {
+			assert.NoError(t, ch.Memdis().Set(key, testCase.value))
+			kind, err := ch.Memdis().KindOf(key)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.kind, kind)
+		})
+	}
+
+	ch.Memdis().RegisterTypeName(user{}, "User")
+	typeOf, err := ch.Memdis().TypeOf("struct")
+	assert.NoError(t, err)
+	assert.Equal(t, "User", typeOf)
+
+	_, err = ch.Memdis().KindOf("unknown")
+	assert.Equal(t, errKeyNotFound, err)
+}